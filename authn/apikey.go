@@ -0,0 +1,64 @@
+package authn
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Prefix distinguishing API keys from JWTs.
+const APIKeyPrefix = "fzk_"
+
+// APIKey is an issued API key, identified by the SHA-256 hash of its value
+// so that the keys themselves are never stored.
+type APIKey struct {
+	Hash     string `json:"hash"`
+	UserID   string `json:"user_id"`
+	TenantID string `json:"tenant_id"`
+	Role     Role   `json:"role"`
+}
+
+type APIKeys []APIKey
+
+// ParseAPIKeys decodes a JSON list of issued API keys.
+func ParseAPIKeys(raw []byte) (APIKeys, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var keys APIKeys
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		if _, err := uuid.Parse(k.UserID); err != nil {
+			return nil, fmt.Errorf("invalid user ID for API key: %v", err)
+		}
+		if k.Role != RoleUser && k.Role != RoleAdmin {
+			return nil, fmt.Errorf("invalid role for API key: %s", k.Role)
+		}
+	}
+
+	return keys, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash an API key is stored under.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (keys APIKeys) authenticate(key string) (*Data, error) {
+	hash := HashAPIKey(key)
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash)) == 1 {
+			return &Data{UserID: k.UserID, TenantID: k.TenantID, Role: k.Role}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid API key")
+}
@@ -0,0 +1,39 @@
+package authn
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	userID := uuid.NewString()
+	raw := fmt.Sprintf(`[{"hash": %q, "user_id": %q, "tenant_id": "default", "role": "admin"}]`,
+		HashAPIKey("fzk_live_secret"), userID)
+
+	keys, err := ParseAPIKeys([]byte(raw))
+	assert.NoError(t, err)
+
+	data, err := keys.authenticate("fzk_live_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, &Data{UserID: userID, TenantID: "default", Role: RoleAdmin}, data)
+
+	_, err = keys.authenticate("fzk_live_other")
+	assert.Error(t, err)
+
+	// Keys with unknown roles or malformed owners are rejected up front
+	_, err = ParseAPIKeys([]byte(`[{"hash": "x", "user_id": "alice", "tenant_id": "default", "role": "user"}]`))
+	assert.Error(t, err)
+	_, err = ParseAPIKeys([]byte(fmt.Sprintf(`[{"hash": "x", "user_id": %q, "tenant_id": "default", "role": "root"}]`, userID)))
+	assert.Error(t, err)
+}
+
+func TestCanAccess(t *testing.T) {
+	owner := uuid.NewString()
+
+	assert.True(t, (&Data{UserID: owner, Role: RoleUser}).CanAccess(owner))
+	assert.False(t, (&Data{UserID: uuid.NewString(), Role: RoleUser}).CanAccess(owner))
+	assert.True(t, (&Data{UserID: uuid.NewString(), Role: RoleAdmin}).CanAccess(owner))
+}
@@ -0,0 +1,103 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"github.com/vvvakho/feezy/billing/tenant"
+)
+
+type Role string
+
+var RoleUser Role = "user"
+var RoleAdmin Role = "admin"
+
+// Data describes the authenticated caller of a request.
+type Data struct {
+	UserID   string
+	TenantID string
+	Role     Role
+}
+
+// IsAdmin reports whether the caller may act on any user's resources within their tenant.
+func (d *Data) IsAdmin() bool {
+	return d.Role == RoleAdmin
+}
+
+// CanAccess reports whether the caller may act on resources owned by the given user.
+func (d *Data) CanAccess(ownerID string) bool {
+	return d.IsAdmin() || d.UserID == ownerID
+}
+
+var secrets struct {
+	// JSON Web Key Set holding the public keys JWTs are verified against
+	JWKS string
+	// JSON list of the SHA-256 hashes of issued API keys and their owners
+	APIKeys string
+}
+
+type keys struct {
+	jwks    KeySet
+	apiKeys APIKeys
+}
+
+var loadKeys = sync.OnceValues(func() (*keys, error) {
+	jwks, err := ParseKeySet([]byte(secrets.JWKS))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse JWKS: %v", err)
+	}
+
+	apiKeys, err := ParseAPIKeys([]byte(secrets.APIKeys))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse API keys: %v", err)
+	}
+
+	return &keys{jwks: jwks, apiKeys: apiKeys}, nil
+})
+
+// AuthHandler authenticates requests bearing either an API key or a JWT
+// in the Authorization header.
+//
+//encore:authhandler
+func AuthHandler(ctx context.Context, token string) (auth.UID, *Data, error) {
+	k, err := loadKeys()
+	if err != nil {
+		return "", nil, &errs.Error{Code: errs.Internal, Message: "authentication is misconfigured"}
+	}
+
+	var data *Data
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		data, err = k.apiKeys.authenticate(token)
+	} else {
+		data, err = k.jwks.authenticate(token)
+	}
+	if err != nil {
+		return "", nil, &errs.Error{Code: errs.Unauthenticated, Message: err.Error()}
+	}
+
+	if _, err := tenant.Lookup(data.TenantID); err != nil {
+		return "", nil, &errs.Error{Code: errs.Unauthenticated, Message: err.Error()}
+	}
+
+	return auth.UID(data.UserID), data, nil
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated caller.
+func NewContext(ctx context.Context, data *Data) context.Context {
+	return context.WithValue(ctx, ctxKey{}, data)
+}
+
+// FromContext returns the authenticated caller carried by the context.
+func FromContext(ctx context.Context) (*Data, error) {
+	data, ok := ctx.Value(ctxKey{}).(*Data)
+	if !ok || data == nil {
+		return nil, fmt.Errorf("no authenticated caller in request context")
+	}
+	return data, nil
+}
@@ -0,0 +1,207 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/conf"
+)
+
+// Tolerated clock difference between the token issuer and this service.
+const clockSkew = time.Minute
+
+// KeySet holds the public keys JWTs are verified against, indexed by key ID.
+// Only asymmetric keys are accepted: RSA keys for RS256 and Ed25519 keys for EdDSA.
+type KeySet map[string]crypto.PublicKey
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// ParseKeySet decodes a JSON Web Key Set.
+func ParseKeySet(raw []byte) (KeySet, error) {
+	set := KeySet{}
+	if len(raw) == 0 {
+		return set, nil
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, k := range doc.Keys {
+		if k.Kid == "" {
+			return nil, fmt.Errorf("key without kid")
+		}
+		if _, exists := set[k.Kid]; exists {
+			return nil, fmt.Errorf("duplicate kid: %s", k.Kid)
+		}
+
+		switch {
+		case k.Kty == "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("invalid modulus for key %s: %v", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exponent for key %s: %v", k.Kid, err)
+			}
+			set[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("invalid Ed25519 key %s", k.Kid)
+			}
+			set[k.Kid] = ed25519.PublicKey(x)
+		default:
+			return nil, fmt.Errorf("unsupported key type for key %s: %s", k.Kid, k.Kty)
+		}
+	}
+
+	return set, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject   string   `json:"sub"`
+	TenantID  string   `json:"tid"`
+	Role      Role     `json:"role"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+}
+
+// The aud claim is either a single string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (ks KeySet) authenticate(token string) (*Data, error) {
+	return ks.verify(token, time.Now())
+}
+
+// Verify the signature and claims of a compact serialized JWT.
+func (ks KeySet) verify(token string, now time.Time) (*Data, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("malformed token header: %v", err)
+	}
+
+	key, ok := ks[h.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature")
+	}
+
+	// The algorithm must match the key type, so a token cannot pick a weaker algorithm
+	signed := []byte(parts[0] + "." + parts[1])
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if h.Alg != "RS256" {
+			return nil, fmt.Errorf("unexpected signing algorithm: %s", h.Alg)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("invalid token signature")
+		}
+	case ed25519.PublicKey:
+		if h.Alg != "EdDSA" {
+			return nil, fmt.Errorf("unexpected signing algorithm: %s", h.Alg)
+		}
+		if !ed25519.Verify(k, signed, sig) {
+			return nil, fmt.Errorf("invalid token signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing key")
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if c.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+	if conf.JWT_ISSUER != "" && c.Issuer != conf.JWT_ISSUER {
+		return nil, fmt.Errorf("unexpected token issuer")
+	}
+	if conf.JWT_AUDIENCE != "" && !slices.Contains(c.Audience, conf.JWT_AUDIENCE) {
+		return nil, fmt.Errorf("unexpected token audience")
+	}
+
+	if _, err := uuid.Parse(c.Subject); err != nil {
+		return nil, fmt.Errorf("invalid token subject")
+	}
+	if c.TenantID == "" {
+		return nil, fmt.Errorf("token has no tenant")
+	}
+
+	// Tokens without a role act as regular users
+	role := c.Role
+	if role == "" {
+		role = RoleUser
+	}
+	if role != RoleUser && role != RoleAdmin {
+		return nil, fmt.Errorf("invalid token role: %s", role)
+	}
+
+	return &Data{UserID: c.Subject, TenantID: c.TenantID, Role: role}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,149 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vvvakho/feezy/billing/conf"
+)
+
+// Sign a token with the given algorithm, key ID and claims.
+func signToken(t *testing.T, alg string, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+
+	h, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		sig, err = key.Sign(rand.Reader, []byte(signed), crypto.Hash(0))
+	}
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestParseKeySet(t *testing.T) {
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	raw := fmt.Sprintf(`{"keys": [
+		{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": %q},
+		{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(edPub),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	)
+
+	set, err := ParseKeySet([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, edPub, set["ed"])
+	assert.True(t, rsaKey.PublicKey.Equal(set["rsa"]))
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"Missing Kid", `{"keys": [{"kty": "OKP", "crv": "Ed25519", "x": "AAAA"}]}`},
+		{"Symmetric Key", `{"keys": [{"kty": "oct", "kid": "hs", "k": "c2VjcmV0"}]}`},
+		{"Short Ed25519 Key", `{"keys": [{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": "AAAA"}]}`},
+		{"Malformed JSON", `{"keys": [`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseKeySet([]byte(tc.raw))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	set := KeySet{"ed": edPub, "rsa": &rsaKey.PublicKey}
+
+	conf.JWT_ISSUER = "https://auth.feezy.dev"
+	defer func() { conf.JWT_ISSUER = "" }()
+
+	now := time.Now()
+	userID := uuid.NewString()
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"sub": userID,
+			"tid": "default",
+			"iss": "https://auth.feezy.dev",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		expectErr  bool
+		expectRole Role
+	}{
+		{"Valid EdDSA Token", signToken(t, "EdDSA", "ed", edKey, validClaims()), false, RoleUser},
+		{"Valid RS256 Token", signToken(t, "RS256", "rsa", rsaKey, validClaims()), false, RoleUser},
+		{"Admin Role", signToken(t, "EdDSA", "ed", edKey, with("role", "admin")), false, RoleAdmin},
+		{"Unknown Role", signToken(t, "EdDSA", "ed", edKey, with("role", "root")), true, ""},
+		{"Unknown Key", signToken(t, "EdDSA", "other", edKey, validClaims()), true, ""},
+		{"Algorithm Mismatch", signToken(t, "EdDSA", "rsa", edKey, validClaims()), true, ""},
+		{"Expired", signToken(t, "EdDSA", "ed", edKey, with("exp", now.Add(-time.Hour).Unix())), true, ""},
+		{"Missing Expiry", signToken(t, "EdDSA", "ed", edKey, with("exp", nil)), true, ""},
+		{"Not Yet Valid", signToken(t, "EdDSA", "ed", edKey, with("nbf", now.Add(time.Hour).Unix())), true, ""},
+		{"Wrong Issuer", signToken(t, "EdDSA", "ed", edKey, with("iss", "https://evil.dev")), true, ""},
+		{"Invalid Subject", signToken(t, "EdDSA", "ed", edKey, with("sub", "alice")), true, ""},
+		{"Missing Tenant", signToken(t, "EdDSA", "ed", edKey, with("tid", nil)), true, ""},
+		{"Malformed", "not-a-token", true, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := set.verify(tc.token, now)
+			if tc.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, data)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &Data{UserID: userID, TenantID: "default", Role: tc.expectRole}, data)
+			}
+		})
+	}
+
+	// Tampering with the claims invalidates the signature
+	token := signToken(t, "EdDSA", "ed", edKey, validClaims())
+	forged, _ := json.Marshal(with("role", "admin"))
+	parts := strings.Split(token, ".")
+	_, err := set.verify(parts[0]+"."+base64.RawURLEncoding.EncodeToString(forged)+"."+parts[2], now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package billerr
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Kinds of failure shared by the billing activities, workflows and API.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrInvalidRequest   = errors.New("invalid request")
+	ErrUserInput        = errors.New("invalid input")
+	ErrDuplicateRequest = errors.New("duplicate request")
+	ErrVersionConflict  = errors.New("version conflict")
+	ErrStaleRevision    = errors.New("stale revision")
+	ErrBillClosed       = errors.New("bill already closed")
+	ErrBillClosing      = errors.New("bill is closing")
+	ErrBillCancelled    = errors.New("bill is cancelled")
+	ErrBillAmended      = errors.New("bill was amended")
+	ErrAwaitingApproval = errors.New("bill is awaiting close approval")
+	ErrCloseRejected    = errors.New("bill close was rejected")
+	ErrWorkflowExists   = errors.New("workflow already exists")
+	ErrInternal         = errors.New("internal error")
+	ErrTransient        = errors.New("transient failure")
+)
+
+// Temporal application error types carrying each kind across activity, workflow and client boundaries.
+var kinds = []struct {
+	kind    error
+	errType string
+}{
+	{ErrNotFound, "NotFoundError"},
+	{ErrInvalidRequest, "InvalidRequestError"},
+	{ErrUserInput, "UserInputError"},
+	{ErrDuplicateRequest, "DuplicateRequestError"},
+	{ErrVersionConflict, "VersionConflictError"},
+	{ErrStaleRevision, "StaleRevisionError"},
+	{ErrBillClosed, "BillClosedError"},
+	{ErrBillClosing, "BillClosingError"},
+	{ErrBillCancelled, "BillCancelledError"},
+	{ErrBillAmended, "BillAmendedError"},
+	{ErrAwaitingApproval, "AwaitingApprovalError"},
+	{ErrCloseRejected, "CloseRejectedError"},
+	{ErrWorkflowExists, "WorkflowExistsError"},
+	{ErrInternal, "InternalError"},
+	{ErrTransient, "TransientError"},
+}
+
+// New returns a Temporal application error of the given kind.
+// Transient failures are retried by Temporal, every other kind fails immediately.
+func New(kind error, msg string, cause error) error {
+	errType := "InternalError"
+	for _, k := range kinds {
+		if k.kind == kind {
+			errType = k.errType
+			break
+		}
+	}
+
+	if kind == ErrTransient {
+		return temporal.NewApplicationErrorWithCause(msg, errType, cause)
+	}
+	return temporal.NewNonRetryableApplicationError(msg, errType, cause)
+}
+
+// Kind reports the kind of a failure, either wrapped directly or carried by a Temporal application error.
+// Returns nil for failures of no known kind.
+func Kind(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.kind
+		}
+	}
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		for _, k := range kinds {
+			if appErr.Type() == k.errType {
+				return k.kind
+			}
+		}
+	}
+	return nil
+}
+
+// FromPostgres classifies a database failure by its SQLSTATE code into a Temporal application error.
+// Failures that are not reported by Postgres, such as dropped connections, are treated as transient.
+func FromPostgres(msg string, err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return New(ErrTransient, msg, err)
+	}
+
+	// A unique violation means the row was already written, typically by a replayed request
+	if pqErr.Code == "23505" {
+		return New(ErrDuplicateRequest, msg, err)
+	}
+
+	switch pqErr.Code.Class() {
+	// Integrity constraint violations and data exceptions are caused by the data being written
+	case "22", "23":
+		return New(ErrUserInput, msg, err)
+	// Syntax errors and undefined objects will fail the same way on every attempt
+	case "42":
+		return New(ErrInternal, msg, err)
+	}
+
+	// Connection exceptions, serialization failures, deadlocks, resource exhaustion and
+	// other server-side failures may succeed when retried
+	return New(ErrTransient, msg, err)
+}
@@ -0,0 +1,67 @@
+package billerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestFromPostgres(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		retryable bool
+	}{
+		{"Unique Violation", &pq.Error{Code: "23505"}, ErrDuplicateRequest, false},
+		{"Foreign Key Violation", &pq.Error{Code: "23503"}, ErrUserInput, false},
+		{"Not Null Violation", &pq.Error{Code: "23502"}, ErrUserInput, false},
+		{"Invalid Text Representation", &pq.Error{Code: "22P02"}, ErrUserInput, false},
+		{"Undefined Column", &pq.Error{Code: "42703"}, ErrInternal, false},
+		{"Serialization Failure", &pq.Error{Code: "40001"}, ErrTransient, true},
+		{"Deadlock", &pq.Error{Code: "40P01"}, ErrTransient, true},
+		{"Connection Failure", &pq.Error{Code: "08006"}, ErrTransient, true},
+		{"Wrapped Postgres Error", fmt.Errorf("insert: %w", &pq.Error{Code: "23505"}), ErrDuplicateRequest, false},
+		{"Non Postgres Error", errors.New("connection reset"), ErrTransient, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := FromPostgres("Error inserting/updating db", tc.err)
+			assert.Equal(t, tc.kind, Kind(err))
+
+			var appErr *temporal.ApplicationError
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, !tc.retryable, appErr.NonRetryable())
+			}
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"Nil", nil, nil},
+		{"Unclassified", errors.New("boom"), nil},
+		{"Sentinel", ErrNotFound, ErrNotFound},
+		{"Wrapped Sentinel", fmt.Errorf("bill not found: %w", ErrNotFound), ErrNotFound},
+		{"Application Error", New(ErrDuplicateRequest, "duplicate", nil), ErrDuplicateRequest},
+		{"Wrapped Application Error", fmt.Errorf("update: %w", New(ErrBillClosed, "closed", nil)), ErrBillClosed},
+		{"Version Conflict", New(ErrVersionConflict, "conflict", nil), ErrVersionConflict},
+		{"Legacy Application Error", temporal.NewApplicationError("invalid", "InvalidRequestError"), ErrInvalidRequest},
+		{"Unknown Application Error", temporal.NewApplicationError("unknown", "SomeError"), nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.kind, Kind(tc.err))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/workflows"
+)
+
+// Export the general ledger of a tenant for a period, for finance to import into their accounting system.
+// Reads PostgreSQL directly, like the workers, so it runs without the API:
+//
+//	go run ./billing/export -tenant default -from 2025-01-01T00:00:00Z -to 2025-02-01T00:00:00Z -format ledger -o january.ledger
+func main() {
+	tenantID := flag.String("tenant", "default", "tenant to export")
+	fromFlag := flag.String("from", "", "start of the period, inclusive, in RFC 3339")
+	toFlag := flag.String("to", "", "end of the period, exclusive, in RFC 3339")
+	formatFlag := flag.String("format", string(domain.ExportCSV), "export format, csv or ledger")
+	outFlag := flag.String("o", "", "file to write the export to, standard output if empty")
+	flag.Parse()
+
+	if _, ok := conf.TENANTS[*tenantID]; !ok {
+		log.Fatalf("Unknown tenant %s", *tenantID)
+	}
+
+	format, err := domain.ParseExportFormat(*formatFlag)
+	if err != nil {
+		log.Fatalf("Invalid format: %v", err)
+	}
+
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid from time, expected RFC 3339: %v", err)
+	}
+	to, err := time.Parse(time.RFC3339, *toFlag)
+	if err != nil {
+		log.Fatalf("Invalid to time, expected RFC 3339: %v", err)
+	}
+	if !to.After(from) {
+		log.Fatalf("Period ends before it starts")
+	}
+
+	postgres, err := sql.Open("postgres", conf.WORKER_DB_CONN)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer postgres.Close()
+
+	db := workflows.Repo{DB: postgres}
+	entries, err := db.ListJournalEntries(context.Background(), *tenantID, from, to)
+	if err != nil {
+		log.Fatalf("Failed to read ledger: %v", err)
+	}
+
+	if err := export(*outFlag, format, entries); err != nil {
+		log.Fatalf("Failed to write export: %v", err)
+	}
+	log.Printf("Exported %d journal entries of tenant %s", len(entries), *tenantID)
+}
+
+// Write the export to the given file, or to standard output if no file is given.
+func export(path string, format domain.ExportFormat, entries []domain.JournalEntry) error {
+	if path == "" {
+		return domain.WriteExport(os.Stdout, format, entries)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := domain.WriteExport(f, format, entries); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
+)
+
+// Actor recorded in the history of imported bills
+const importActor = "import"
+
+// Namespace of the IDs derived for imported bills, so re-running an import yields the same bills
+var importNamespace = uuid.MustParse("5b0e7f8e-3d4f-4a61-9b4c-2f1d6c8a9e01")
+
+// Record is a bill read from an import file, before it is validated.
+// Its ref becomes the external reference of the imported bill.
+type Record struct {
+	Line       int               `json:"-"` // line of the file the bill starts on
+	Ref        string            `json:"ref"`
+	UserID     string            `json:"user_id"`
+	Currency   string            `json:"currency"`
+	Status     string            `json:"status"`     // open or closed
+	Collection string            `json:"collection"` // paid or uncollectible, for closed bills that were settled
+	CreatedAt  string            `json:"created_at"`
+	ClosedAt   string            `json:"closed_at"`
+	Metadata   map[string]string `json:"metadata"`
+	Items      []RecordItem      `json:"items"`
+}
+
+// RecordItem is a line item of an imported bill, priced in minor units of the bill currency
+// unless it names a currency of its own.
+type RecordItem struct {
+	Description  string               `json:"description"`
+	Quantity     int64                `json:"quantity"`
+	PricePerUnit int64                `json:"price_per_unit"`
+	Currency     string               `json:"currency"`
+	Metadata     *domain.ItemMetadata `json:"metadata"`
+}
+
+// RowError reports a line of an import file that could not be read.
+type RowError struct {
+	Line int
+	Ref  string
+	Err  error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ReadJSONL reads one bill per line. Lines that cannot be decoded are reported and skipped.
+func ReadJSONL(r io.Reader) ([]Record, []RowError) {
+	var records []Record
+	var rowErrs []RowError
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record Record
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&record); err != nil {
+			rowErrs = append(rowErrs, RowError{Line: line, Err: fmt.Errorf("invalid JSON: %v", err)})
+			continue
+		}
+		record.Line = line
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		rowErrs = append(rowErrs, RowError{Line: line + 1, Err: err})
+	}
+
+	return records, rowErrs
+}
+
+var csvColumns = []string{
+	"ref", "user_id", "currency", "status", "collection", "created_at", "closed_at",
+	"item_description", "item_quantity", "item_price_per_unit", "item_currency",
+}
+
+// ReadCSV reads one line item per row, under a header naming the columns of csvColumns in any order,
+// and optionally item_category and item_sku columns classifying the items.
+// Rows of a bill share its ref and bill columns, and must be adjacent. Bills without items have a single
+// row with empty item columns. Rows that cannot be read are reported, and their bill skipped.
+func ReadCSV(r io.Reader) ([]Record, []RowError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, []RowError{{Line: 1, Err: fmt.Errorf("invalid header: %v", err)}}
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, name := range csvColumns {
+		if _, ok := index[name]; !ok {
+			return nil, []RowError{{Line: 1, Err: fmt.Errorf("missing column %s", name)}}
+		}
+	}
+
+	var records []Record
+	var rowErrs []RowError
+	failed := map[string]bool{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrs = append(rowErrs, RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return records, append(rowErrs, RowError{Err: err})
+		}
+		line, _ := reader.FieldPos(0)
+		if len(row) != len(header) {
+			rowErrs = append(rowErrs, RowError{Line: line, Err: fmt.Errorf("expected %d columns, got %d", len(header), len(row))})
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		record := Record{
+			Line:       line,
+			Ref:        field("ref"),
+			UserID:     field("user_id"),
+			Currency:   field("currency"),
+			Status:     field("status"),
+			Collection: field("collection"),
+			CreatedAt:  field("created_at"),
+			ClosedAt:   field("closed_at"),
+		}
+		if failed[record.Ref] {
+			continue
+		}
+
+		// Continue the previous bill while rows share its ref
+		var previous *Record
+		if n := len(records); n > 0 && records[n-1].Ref == record.Ref && record.Ref != "" {
+			previous = &records[n-1]
+		}
+
+		// A bill with an unreadable row is skipped as a whole
+		item, err := csvItem(field)
+		if err == nil && previous != nil && !sameBill(*previous, record) {
+			err = errors.New("bill columns differ from the bill's first row")
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: line, Ref: record.Ref, Err: err})
+			failed[record.Ref] = true
+			if previous != nil {
+				records = records[:len(records)-1]
+			}
+			continue
+		}
+
+		if previous != nil {
+			if item != nil {
+				previous.Items = append(previous.Items, *item)
+			}
+			continue
+		}
+
+		if item != nil {
+			record.Items = []RecordItem{*item}
+		}
+		records = append(records, record)
+	}
+
+	return records, rowErrs
+}
+
+// Read the item columns of a CSV row, which are all empty for bills without items.
+func csvItem(field func(string) string) (*RecordItem, error) {
+	if field("item_description") == "" && field("item_quantity") == "" && field("item_price_per_unit") == "" {
+		return nil, nil
+	}
+
+	quantity, err := strconv.ParseInt(field("item_quantity"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid item_quantity: %v", err)
+	}
+	price, err := strconv.ParseInt(field("item_price_per_unit"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid item_price_per_unit: %v", err)
+	}
+
+	item := &RecordItem{
+		Description:  field("item_description"),
+		Quantity:     quantity,
+		PricePerUnit: price,
+		Currency:     field("item_currency"),
+	}
+	if field("item_category") != "" || field("item_sku") != "" {
+		item.Metadata = &domain.ItemMetadata{Category: field("item_category"), SKU: field("item_sku")}
+	}
+	return item, nil
+}
+
+func sameBill(a Record, b Record) bool {
+	return a.UserID == b.UserID && a.Currency == b.Currency && a.Status == b.Status &&
+		a.Collection == b.Collection && a.CreatedAt == b.CreatedAt && a.ClosedAt == b.ClosedAt
+}
+
+// BillID derives the ID of an imported bill from its tenant and reference in the legacy system.
+func BillID(tenantID string, ref string) uuid.UUID {
+	return uuid.NewSHA1(importNamespace, []byte(tenantID+":"+ref))
+}
+
+// NewImportedBill validates a record and builds the bill it describes, through the same domain rules as bills
+// created through the API. Its history records its creation, items and close at the times given by the record.
+func NewImportedBill(tenantID string, cfg conf.TenantConfig, record Record) (*domain.Bill, error) {
+	if record.Ref == "" {
+		return nil, errors.New("missing ref")
+	}
+	if err := tenant.ValidateCurrency(tenantID, record.Currency); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateBillMetadata(record.Ref, record.Metadata); err != nil {
+		return nil, err
+	}
+
+	bill, err := domain.NewBill(record.UserID, record.Currency)
+	if err != nil {
+		return nil, err
+	}
+	bill.ID = BillID(tenantID, record.Ref)
+	bill.TenantID = tenantID
+	bill.TaxRate = cfg.TaxRate
+	bill.ExternalRef = record.Ref
+	bill.Metadata = record.Metadata
+
+	createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at, expected RFC 3339: %v", err)
+	}
+	bill.CreatedAt = createdAt
+	bill.UpdatedAt = createdAt
+	bill.Record(domain.EventBillCreated, importActor, "", nil, bill.Total, createdAt)
+
+	for i, recordItem := range record.Items {
+		if recordItem.Quantity <= 0 {
+			return nil, fmt.Errorf("item %d: quantity must be positive", i+1)
+		}
+		if recordItem.PricePerUnit < 0 {
+			return nil, fmt.Errorf("item %d: price must not be negative", i+1)
+		}
+		currency := recordItem.Currency
+		if currency == "" {
+			currency = record.Currency
+		}
+		if _, err := domain.IsValidCurrency(currency); err != nil {
+			return nil, fmt.Errorf("item %d: %v", i+1, err)
+		}
+
+		item := domain.Item{
+			ID:           uuid.NewSHA1(bill.ID, []byte(strconv.Itoa(i))),
+			Quantity:     recordItem.Quantity,
+			Description:  recordItem.Description,
+			PricePerUnit: domain.Money{Amount: domain.MinorUnit(recordItem.PricePerUnit), Currency: currency},
+			Metadata:     recordItem.Metadata,
+		}
+		totalBefore := bill.Total
+		if err := bill.AddLineItem(item); err != nil {
+			return nil, fmt.Errorf("item %d: %v", i+1, err)
+		}
+		bill.Record(domain.EventItemAdded, importActor, "", &item, totalBefore, createdAt)
+	}
+
+	switch record.Status {
+	case "open":
+		if record.ClosedAt != "" || record.Collection != "" {
+			return nil, errors.New("open bills have no closed_at or collection")
+		}
+		if cfg.PaymentTerms > 0 {
+			bill.PaymentTerms = &domain.PaymentTerms{DueIn: cfg.PaymentTerms, Dunning: conf.DUNNING_SCHEDULE}
+		}
+	case "closed":
+		closedAt, err := time.Parse(time.RFC3339, record.ClosedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid closed_at, expected RFC 3339: %v", err)
+		}
+		if closedAt.Before(createdAt) {
+			return nil, errors.New("bill closed before it was created")
+		}
+
+		switch domain.CollectionStatus(record.Collection) {
+		case "", domain.CollectionPaid, domain.CollectionUncollectible:
+		default:
+			return nil, fmt.Errorf("invalid collection %s, expected paid or uncollectible", record.Collection)
+		}
+
+		bill.Status = domain.BillClosed
+		bill.ClosedAt = closedAt
+		bill.UpdatedAt = closedAt
+		bill.Collection = domain.CollectionStatus(record.Collection)
+		bill.Record(domain.EventBillClosed, importActor, "", nil, bill.Total, closedAt)
+	default:
+		return nil, fmt.Errorf("invalid status %s, expected open or closed", record.Status)
+	}
+
+	return bill, nil
+}
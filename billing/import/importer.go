@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
+// ClosedBillStore writes imported closed bills to the database.
+type ClosedBillStore interface {
+	ImportClosedBill(ctx context.Context, bill *domain.Bill, requestID string) (bool, error)
+}
+
+// OpenBillStarter starts the workflows of imported open bills.
+type OpenBillStarter interface {
+	StartBill(ctx context.Context, bill *domain.Bill) (bool, error)
+}
+
+// Result is the outcome of importing a single bill.
+type Result string
+
+var ResultValid Result = "valid" // validated in a dry run
+var ResultImported Result = "imported"
+var ResultSkipped Result = "skipped" // imported by an earlier run
+var ResultFailed Result = "failed"
+
+// ReportRow reports the outcome of importing the bill on a line of the import file.
+type ReportRow struct {
+	Line   int
+	Ref    string
+	BillID string
+	Result Result
+	Error  string
+}
+
+// Importer validates bills read from an import file, and writes them unless it runs dry.
+// Closed bills are written directly to the database, while open bills start a bill workflow,
+// as bills created through the API do.
+type Importer struct {
+	TenantID string
+	Config   conf.TenantConfig
+	Closed   ClosedBillStore
+	Open     OpenBillStarter
+	DryRun   bool
+}
+
+// Run imports the given records, returning a report row per bill and per line that could not be read,
+// in the order of the file. A bill that fails is reported and does not stop the import.
+func (im *Importer) Run(ctx context.Context, records []Record, rowErrs []RowError) []ReportRow {
+	var report []ReportRow
+	for _, rowErr := range rowErrs {
+		report = append(report, ReportRow{Line: rowErr.Line, Ref: rowErr.Ref, Result: ResultFailed, Error: rowErr.Err.Error()})
+	}
+
+	seen := map[string]int{}
+	for _, record := range records {
+		row := ReportRow{Line: record.Line, Ref: record.Ref}
+
+		// Refs identify bills, so a ref repeated within the file would import over its first bill
+		if first, ok := seen[record.Ref]; ok && record.Ref != "" {
+			row.Result = ResultFailed
+			row.Error = "ref already used on line " + strconv.Itoa(first)
+			report = append(report, row)
+			continue
+		}
+		seen[record.Ref] = record.Line
+
+		bill, err := NewImportedBill(im.TenantID, im.Config, record)
+		if err != nil {
+			row.Result = ResultFailed
+			row.Error = err.Error()
+			report = append(report, row)
+			continue
+		}
+		row.BillID = bill.ID.String()
+
+		row.Result, err = im.write(ctx, bill)
+		if err != nil {
+			row.Error = err.Error()
+		}
+		report = append(report, row)
+	}
+
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Line < report[j].Line })
+	return report
+}
+
+// Write a validated bill, unless the import runs dry.
+func (im *Importer) write(ctx context.Context, bill *domain.Bill) (Result, error) {
+	if im.DryRun {
+		return ResultValid, nil
+	}
+
+	var written bool
+	var err error
+	if bill.Status == domain.BillClosed {
+		// Derive the request from the bill, so a re-run is recognised as the same request
+		written, err = im.Closed.ImportClosedBill(ctx, bill, bill.ID.String())
+	} else {
+		written, err = im.Open.StartBill(ctx, bill)
+	}
+
+	switch {
+	case err != nil:
+		return ResultFailed, err
+	case !written:
+		return ResultSkipped, nil
+	}
+	return ResultImported, nil
+}
+
+// WriteReport writes the import report as CSV.
+func WriteReport(w io.Writer, report []ReportRow) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"line", "ref", "bill_id", "result", "error"}); err != nil {
+		return err
+	}
+	for _, row := range report {
+		err := out.Write([]string{strconv.Itoa(row.Line), row.Ref, row.BillID, string(row.Result), row.Error})
+		if err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
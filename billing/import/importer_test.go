@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
+var customerID = uuid.MustParse("0194d3a0-0000-7000-8000-00000000c001")
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"ref":"INV-1","user_id":"` + customerID.String() + `","currency":"USD","status":"closed","collection":"paid","created_at":"2024-01-01T00:00:00Z","closed_at":"2024-01-31T00:00:00Z","items":[{"description":"Seat","quantity":2,"price_per_unit":500,"metadata":{"Category":"seats"}}]}
+
+{"ref":"INV-2",
+{"ref":"INV-3","user_id":"` + customerID.String() + `","currency":"USD","status":"open","created_at":"2024-02-01T00:00:00Z","unknown":1}
+{"ref":"INV-4","user_id":"` + customerID.String() + `","currency":"GEL","status":"open","created_at":"2024-02-01T00:00:00Z"}
+`
+
+	records, rowErrs := ReadJSONL(strings.NewReader(input))
+
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, 1, records[0].Line)
+		assert.Equal(t, "INV-1", records[0].Ref)
+		assert.Equal(t, []RecordItem{{Description: "Seat", Quantity: 2, PricePerUnit: 500, Metadata: &domain.ItemMetadata{Category: "seats"}}}, records[0].Items)
+		assert.Equal(t, 5, records[1].Line)
+	}
+
+	// Malformed lines and unknown fields are reported by line
+	if assert.Len(t, rowErrs, 2) {
+		assert.Equal(t, 3, rowErrs[0].Line)
+		assert.Equal(t, 4, rowErrs[1].Line)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	user := customerID.String()
+	input := "ref,user_id,currency,status,collection,created_at,closed_at,item_description,item_quantity,item_price_per_unit,item_currency\n" +
+		"INV-1," + user + ",USD,closed,paid,2024-01-01T00:00:00Z,2024-01-31T00:00:00Z,Seat,2,500,\n" +
+		"INV-1," + user + ",USD,closed,paid,2024-01-01T00:00:00Z,2024-01-31T00:00:00Z,Support,1,1000,GEL\n" +
+		"INV-2," + user + ",USD,open,,2024-02-01T00:00:00Z,,,,,\n" +
+		"INV-3," + user + ",USD,closed,,2024-02-01T00:00:00Z,2024-02-02T00:00:00Z,Seat,two,500,\n" +
+		"INV-3," + user + ",USD,closed,,2024-02-01T00:00:00Z,2024-02-02T00:00:00Z,Seat,1,500,\n" +
+		"INV-4," + user + ",USD,closed,,2024-02-01T00:00:00Z,2024-02-02T00:00:00Z,Seat,1,500,\n" +
+		"INV-4," + user + ",GEL,closed,,2024-02-01T00:00:00Z,2024-02-02T00:00:00Z,Seat,1,500,\n"
+
+	records, rowErrs := ReadCSV(strings.NewReader(input))
+
+	// Adjacent rows of a bill are read as its items, and bills without items have none
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "INV-1", records[0].Ref)
+		assert.Equal(t, 2, records[0].Line)
+		assert.Len(t, records[0].Items, 2)
+		assert.Equal(t, "GEL", records[0].Items[1].Currency)
+		assert.Equal(t, "INV-2", records[1].Ref)
+		assert.Empty(t, records[1].Items)
+	}
+
+	// Bills with an unreadable row or conflicting bill columns are skipped as a whole
+	if assert.Len(t, rowErrs, 2) {
+		assert.Equal(t, RowError{Line: 5, Ref: "INV-3", Err: rowErrs[0].Err}, rowErrs[0])
+		assert.Equal(t, 8, rowErrs[1].Line)
+		assert.Equal(t, "INV-4", rowErrs[1].Ref)
+	}
+
+	// Items are classified by the optional category and SKU columns
+	input = "ref,user_id,currency,status,collection,created_at,closed_at,item_description,item_quantity,item_price_per_unit,item_currency,item_category,item_sku\n" +
+		"INV-5," + user + ",USD,open,,2024-02-01T00:00:00Z,,Seat,1,500,,seats,SEAT-1\n" +
+		"INV-5," + user + ",USD,open,,2024-02-01T00:00:00Z,,Setup,1,500,,,\n"
+	records, rowErrs = ReadCSV(strings.NewReader(input))
+	assert.Empty(t, rowErrs)
+	if assert.Len(t, records, 1) && assert.Len(t, records[0].Items, 2) {
+		assert.Equal(t, &domain.ItemMetadata{Category: "seats", SKU: "SEAT-1"}, records[0].Items[0].Metadata)
+		assert.Nil(t, records[0].Items[1].Metadata)
+	}
+
+	_, rowErrs = ReadCSV(strings.NewReader("ref,user_id\n"))
+	if assert.Len(t, rowErrs, 1) {
+		assert.Contains(t, rowErrs[0].Error(), "missing column")
+	}
+}
+
+func TestNewImportedBill(t *testing.T) {
+	cfg := conf.TenantConfig{Currencies: []string{"USD", "GEL"}, TaxRate: 1000, PaymentTerms: 30 * 24 * time.Hour}
+	closed := Record{
+		Ref:        "INV-1",
+		UserID:     customerID.String(),
+		Currency:   "USD",
+		Status:     "closed",
+		Collection: "paid",
+		CreatedAt:  "2024-01-01T00:00:00Z",
+		ClosedAt:   "2024-01-31T00:00:00Z",
+		Metadata:   map[string]string{"cost_center": "CC-42"},
+		Items:      []RecordItem{{Description: "Seat", Quantity: 2, PricePerUnit: 500}},
+	}
+
+	bill, err := NewImportedBill("default", cfg, closed)
+	assert.NoError(t, err)
+	assert.Equal(t, BillID("default", "INV-1"), bill.ID)
+	assert.Equal(t, domain.BillClosed, bill.Status)
+	assert.Equal(t, domain.Money{Amount: 1000, Currency: "USD"}, bill.Total)
+	assert.Equal(t, domain.Money{Amount: 100, Currency: "USD"}, bill.Tax)
+	assert.Equal(t, domain.CollectionPaid, bill.Collection)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), bill.ClosedAt)
+	assert.Nil(t, bill.DueAt)
+	assert.Equal(t, "INV-1", bill.ExternalRef)
+	assert.Equal(t, closed.Metadata, bill.Metadata)
+
+	// The history replays to the imported bill
+	types := []domain.EventType{}
+	for _, event := range bill.Events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []domain.EventType{domain.EventBillCreated, domain.EventItemAdded, domain.EventBillClosed}, types)
+	replayed, err := domain.Replay(bill, bill.Events, bill.ClosedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, bill.Total, replayed.Total)
+
+	// The same ref always yields the same bill and items
+	again, err := NewImportedBill("default", cfg, closed)
+	assert.NoError(t, err)
+	assert.Equal(t, bill.Items, again.Items)
+
+	open := Record{Ref: "INV-2", UserID: customerID.String(), Currency: "GEL", Status: "open", CreatedAt: "2024-02-01T00:00:00Z"}
+	bill, err = NewImportedBill("default", cfg, open)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BillOpen, bill.Status)
+	assert.Equal(t, cfg.PaymentTerms, bill.PaymentTerms.DueIn)
+
+	invalid := map[string]func(r *Record){
+		"Missing Ref":          func(r *Record) { r.Ref = "" },
+		"Invalid Metadata":     func(r *Record) { r.Metadata = map[string]string{"": "CC-42"} },
+		"Invalid User":         func(r *Record) { r.UserID = "legacy-42" },
+		"Invalid Currency":     func(r *Record) { r.Currency = "EUR" },
+		"Invalid Status":       func(r *Record) { r.Status = "draft" },
+		"Invalid Collection":   func(r *Record) { r.Collection = "unpaid" },
+		"Invalid Created At":   func(r *Record) { r.CreatedAt = "2024-01-01" },
+		"Closed Before Create": func(r *Record) { r.ClosedAt = "2023-12-31T00:00:00Z" },
+		"Zero Quantity":        func(r *Record) { r.Items = []RecordItem{{Quantity: 0, PricePerUnit: 500}} },
+		"Open With Closed At":  func(r *Record) { r.Status = "open" },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			record := closed
+			mutate(&record)
+			_, err := NewImportedBill("default", cfg, record)
+			assert.Error(t, err)
+		})
+	}
+}
+
+type fakeStore struct {
+	written map[uuid.UUID]bool
+	err     error
+}
+
+func (f *fakeStore) ImportClosedBill(ctx context.Context, bill *domain.Bill, requestID string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.written[bill.ID] {
+		return false, nil
+	}
+	f.written[bill.ID] = true
+	return true, nil
+}
+
+func (f *fakeStore) StartBill(ctx context.Context, bill *domain.Bill) (bool, error) {
+	return f.ImportClosedBill(ctx, bill, bill.ID.String())
+}
+
+func TestImporterRun(t *testing.T) {
+	cfg := conf.TenantConfig{Currencies: []string{"USD"}}
+	records := []Record{
+		{Line: 1, Ref: "INV-1", UserID: customerID.String(), Currency: "USD", Status: "closed", CreatedAt: "2024-01-01T00:00:00Z", ClosedAt: "2024-01-02T00:00:00Z"},
+		{Line: 3, Ref: "INV-2", UserID: customerID.String(), Currency: "USD", Status: "open", CreatedAt: "2024-01-01T00:00:00Z"},
+		{Line: 4, Ref: "INV-3", UserID: customerID.String(), Currency: "EUR", Status: "open", CreatedAt: "2024-01-01T00:00:00Z"},
+		{Line: 5, Ref: "INV-1", UserID: customerID.String(), Currency: "USD", Status: "open", CreatedAt: "2024-01-01T00:00:00Z"},
+	}
+	rowErrs := []RowError{{Line: 2, Err: errors.New("invalid JSON")}}
+
+	results := func(report []ReportRow) []Result {
+		var r []Result
+		for _, row := range report {
+			r = append(r, row.Result)
+		}
+		return r
+	}
+
+	// A dry run validates every bill without writing any
+	store := &fakeStore{written: map[uuid.UUID]bool{}}
+	importer := &Importer{TenantID: "default", Config: cfg, Closed: store, Open: store, DryRun: true}
+	report := importer.Run(context.Background(), records, rowErrs)
+	assert.Equal(t, []Result{ResultValid, ResultFailed, ResultValid, ResultFailed, ResultFailed}, results(report))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, []int{report[0].Line, report[1].Line, report[2].Line, report[3].Line, report[4].Line})
+	assert.Contains(t, report[4].Error, "line 1")
+	assert.Empty(t, store.written)
+
+	// Bills are written once, and skipped when the import is re-run
+	importer.DryRun = false
+	report = importer.Run(context.Background(), records, rowErrs)
+	assert.Equal(t, []Result{ResultImported, ResultFailed, ResultImported, ResultFailed, ResultFailed}, results(report))
+	assert.Equal(t, BillID("default", "INV-1").String(), report[0].BillID)
+
+	report = importer.Run(context.Background(), records, rowErrs)
+	assert.Equal(t, []Result{ResultSkipped, ResultFailed, ResultSkipped, ResultFailed, ResultFailed}, results(report))
+
+	// Write failures are reported per bill
+	store.err = errors.New("connection refused")
+	report = importer.Run(context.Background(), records[:1], nil)
+	assert.Equal(t, ResultFailed, report[0].Result)
+	assert.Equal(t, "connection refused", report[0].Error)
+}
+
+func TestWriteReport(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteReport(&out, []ReportRow{
+		{Line: 1, Ref: "INV-1", BillID: "id", Result: ResultImported},
+		{Line: 2, Ref: "INV-2", Result: ResultFailed, Error: "invalid status draft, expected open or closed"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "line,ref,bill_id,result,error\n1,INV-1,id,imported,\n2,INV-2,,failed,\"invalid status draft, expected open or closed\"\n", out.String())
+}
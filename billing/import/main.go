@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/lib/pq"
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
+	"github.com/vvvakho/feezy/billing/workflows"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Import historical bills of a tenant from a CSV or JSONL file, e.g. when migrating from a legacy system.
+// Reads and writes PostgreSQL directly, like the workers, and starts the workflows of open bills on Temporal:
+//
+//	go run ./billing/import -tenant default -dry-run bills.jsonl
+//
+// Writes a report with the outcome of every bill to standard output, or to the -report file.
+func main() {
+	tenantID := flag.String("tenant", "default", "tenant to import the bills into")
+	formatFlag := flag.String("format", "", "file format, csv or jsonl, inferred from the file extension if empty")
+	dryRun := flag.Bool("dry-run", false, "validate the bills without importing them")
+	reportFlag := flag.String("report", "", "file to write the report to, standard output if empty")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalf("Usage: import [flags] <file>")
+	}
+	path := flag.Arg(0)
+
+	cfg, err := tenant.Lookup(*tenantID)
+	if err != nil {
+		log.Fatalf("Unknown tenant: %v", err)
+	}
+
+	format := *formatFlag
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var records []Record
+	var rowErrs []RowError
+	switch format {
+	case "csv":
+		records, rowErrs = ReadCSV(f)
+	case "jsonl":
+		records, rowErrs = ReadJSONL(f)
+	default:
+		log.Fatalf("Unknown format %q, expected csv or jsonl", format)
+	}
+
+	importer := &Importer{TenantID: *tenantID, Config: cfg, DryRun: *dryRun}
+	if !*dryRun {
+		postgres, err := sql.Open("postgres", conf.WORKER_DB_CONN)
+		if err != nil {
+			log.Fatalf("Failed to connect to DB: %v", err)
+		}
+		defer postgres.Close()
+
+		c, err := client.Dial(conf.TEMPORAL_CLIENT_CONF)
+		if err != nil {
+			log.Fatalf("Failed to connect to Temporal: %v", err)
+		}
+		defer c.Close()
+
+		importer.Closed = &workflows.Repo{DB: postgres}
+		importer.Open = &temporalStarter{client: c}
+	}
+
+	report := importer.Run(context.Background(), records, rowErrs)
+
+	var out io.Writer = os.Stdout
+	if *reportFlag != "" {
+		reportFile, err := os.Create(*reportFlag)
+		if err != nil {
+			log.Fatalf("Failed to create report: %v", err)
+		}
+		defer reportFile.Close()
+		out = reportFile
+	}
+	if err := WriteReport(out, report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	counts := map[Result]int{}
+	for _, row := range report {
+		counts[row.Result]++
+	}
+	log.Printf("Valid: %d, imported: %d, skipped: %d, failed: %d",
+		counts[ResultValid], counts[ResultImported], counts[ResultSkipped], counts[ResultFailed])
+}
+
+// Start the workflows of imported open bills, as the API does for bills it creates.
+type temporalStarter struct {
+	client client.Client
+}
+
+// StartBill starts the workflow of an open bill, returning false if it was started by an earlier import.
+func (ts *temporalStarter) StartBill(ctx context.Context, bill *domain.Bill) (bool, error) {
+	_, err := ts.client.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                    workflows.WorkflowID(bill.TenantID, bill.ID.String()),
+		TaskQueue:             workflows.TaskQueue(bill.TenantID),
+		WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+	}, workflows.BillWorkflow, bill)
+
+	if temporal.IsWorkflowExecutionAlreadyStartedError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -12,6 +12,7 @@ package mock_billing
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/vvvakho/feezy/billing/service/domain"
 	workflows "github.com/vvvakho/feezy/billing/workflows"
@@ -43,7 +44,7 @@ func (m *MockExecution) EXPECT() *MockExecutionMockRecorder {
 }
 
 // AddLineItemSignal mocks base method.
-func (m *MockExecution) AddLineItemSignal(arg0 context.Context, arg1 string, arg2 *domain.Item) error {
+func (m *MockExecution) AddLineItemSignal(arg0 context.Context, arg1 string, arg2 *workflows.AddItemSignal) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "AddLineItemSignal", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -56,6 +57,50 @@ func (mr *MockExecutionMockRecorder) AddLineItemSignal(arg0, arg1, arg2 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLineItemSignal", reflect.TypeOf((*MockExecution)(nil).AddLineItemSignal), arg0, arg1, arg2)
 }
 
+// AddLineItemsUpdate mocks base method.
+func (m *MockExecution) AddLineItemsUpdate(arg0 context.Context, arg1 string, arg2 *workflows.AddItemsUpdate) (*domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLineItemsUpdate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLineItemsUpdate indicates an expected call of AddLineItemsUpdate.
+func (mr *MockExecutionMockRecorder) AddLineItemsUpdate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLineItemsUpdate", reflect.TypeOf((*MockExecution)(nil).AddLineItemsUpdate), arg0, arg1, arg2)
+}
+
+// AmendBillWorkflow mocks base method.
+func (m *MockExecution) AmendBillWorkflow(arg0 context.Context, arg1 *domain.Bill, arg2 *domain.Amendment, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AmendBillWorkflow", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AmendBillWorkflow indicates an expected call of AmendBillWorkflow.
+func (mr *MockExecutionMockRecorder) AmendBillWorkflow(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AmendBillWorkflow", reflect.TypeOf((*MockExecution)(nil).AmendBillWorkflow), arg0, arg1, arg2, arg3)
+}
+
+// CancelBillUpdate mocks base method.
+func (m *MockExecution) CancelBillUpdate(arg0 context.Context, arg1 string, arg2 *workflows.CancelBillSignal) (*domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelBillUpdate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBillUpdate indicates an expected call of CancelBillUpdate.
+func (mr *MockExecutionMockRecorder) CancelBillUpdate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBillUpdate", reflect.TypeOf((*MockExecution)(nil).CancelBillUpdate), arg0, arg1, arg2)
+}
+
 // Close mocks base method.
 func (m *MockExecution) Close() {
 	m.ctrl.T.Helper()
@@ -68,6 +113,20 @@ func (mr *MockExecutionMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockExecution)(nil).Close))
 }
 
+// CloseApprovalSignal mocks base method.
+func (m *MockExecution) CloseApprovalSignal(arg0 context.Context, arg1 string, arg2 *workflows.CloseApprovalSignal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseApprovalSignal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseApprovalSignal indicates an expected call of CloseApprovalSignal.
+func (mr *MockExecutionMockRecorder) CloseApprovalSignal(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseApprovalSignal", reflect.TypeOf((*MockExecution)(nil).CloseApprovalSignal), arg0, arg1, arg2)
+}
+
 // CloseBillUpdate mocks base method.
 func (m *MockExecution) CloseBillUpdate(arg0 context.Context, arg1 string, arg2 *workflows.CloseBillSignal) (*domain.Bill, error) {
 	m.ctrl.T.Helper()
@@ -112,21 +171,21 @@ func (mr *MockExecutionMockRecorder) GetBillQuery(arg0, arg1, arg2 any) *gomock.
 }
 
 // IsWorkflowRunning mocks base method.
-func (m *MockExecution) IsWorkflowRunning(arg0 string) error {
+func (m *MockExecution) IsWorkflowRunning(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsWorkflowRunning", arg0)
+	ret := m.ctrl.Call(m, "IsWorkflowRunning", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // IsWorkflowRunning indicates an expected call of IsWorkflowRunning.
-func (mr *MockExecutionMockRecorder) IsWorkflowRunning(arg0 any) *gomock.Call {
+func (mr *MockExecutionMockRecorder) IsWorkflowRunning(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsWorkflowRunning", reflect.TypeOf((*MockExecution)(nil).IsWorkflowRunning), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsWorkflowRunning", reflect.TypeOf((*MockExecution)(nil).IsWorkflowRunning), arg0, arg1)
 }
 
 // RemoveLineItemSignal mocks base method.
-func (m *MockExecution) RemoveLineItemSignal(arg0 context.Context, arg1 string, arg2 *domain.Item) error {
+func (m *MockExecution) RemoveLineItemSignal(arg0 context.Context, arg1 string, arg2 *workflows.RemoveItemSignal) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RemoveLineItemSignal", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -139,6 +198,21 @@ func (mr *MockExecutionMockRecorder) RemoveLineItemSignal(arg0, arg1, arg2 any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLineItemSignal", reflect.TypeOf((*MockExecution)(nil).RemoveLineItemSignal), arg0, arg1, arg2)
 }
 
+// UpdateLineItemUpdate mocks base method.
+func (m *MockExecution) UpdateLineItemUpdate(arg0 context.Context, arg1 string, arg2 *workflows.UpdateItemUpdate) (*domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLineItemUpdate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLineItemUpdate indicates an expected call of UpdateLineItemUpdate.
+func (mr *MockExecutionMockRecorder) UpdateLineItemUpdate(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLineItemUpdate", reflect.TypeOf((*MockExecution)(nil).UpdateLineItemUpdate), arg0, arg1, arg2)
+}
+
 // MockRepository is a mock of Repository interface.
 type MockRepository struct {
 	ctrl     *gomock.Controller
@@ -163,6 +237,141 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddWalletGrantToDB mocks base method.
+func (m *MockRepository) AddWalletGrantToDB(arg0 context.Context, arg1 *domain.WalletEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWalletGrantToDB", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWalletGrantToDB indicates an expected call of AddWalletGrantToDB.
+func (mr *MockRepositoryMockRecorder) AddWalletGrantToDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWalletGrantToDB", reflect.TypeOf((*MockRepository)(nil).AddWalletGrantToDB), arg0, arg1)
+}
+
+// ClaimOpenBillInDB mocks base method.
+func (m *MockRepository) ClaimOpenBillInDB(arg0 context.Context, arg1 *domain.Bill) (*domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOpenBillInDB", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOpenBillInDB indicates an expected call of ClaimOpenBillInDB.
+func (mr *MockRepositoryMockRecorder) ClaimOpenBillInDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOpenBillInDB", reflect.TypeOf((*MockRepository)(nil).ClaimOpenBillInDB), arg0, arg1)
+}
+
+// GetAccountPostingsFromDB mocks base method.
+func (m *MockRepository) GetAccountPostingsFromDB(arg0 context.Context, arg1 string, arg2 string, arg3 time.Time, arg4 time.Time) (domain.AccountTotal, []domain.StatementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountPostingsFromDB", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(domain.AccountTotal)
+	ret1, _ := ret[1].([]domain.StatementLine)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAccountPostingsFromDB indicates an expected call of GetAccountPostingsFromDB.
+func (mr *MockRepositoryMockRecorder) GetAccountPostingsFromDB(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountPostingsFromDB", reflect.TypeOf((*MockRepository)(nil).GetAccountPostingsFromDB), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetAnalyticsRefreshFromDB mocks base method.
+func (m *MockRepository) GetAnalyticsRefreshFromDB(arg0 context.Context) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAnalyticsRefreshFromDB", arg0)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAnalyticsRefreshFromDB indicates an expected call of GetAnalyticsRefreshFromDB.
+func (mr *MockRepositoryMockRecorder) GetAnalyticsRefreshFromDB(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAnalyticsRefreshFromDB", reflect.TypeOf((*MockRepository)(nil).GetAnalyticsRefreshFromDB), arg0)
+}
+
+// GetBillAmendmentsFromDB mocks base method.
+func (m *MockRepository) GetBillAmendmentsFromDB(arg0 context.Context, arg1 string) ([]domain.Amendment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillAmendmentsFromDB", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Amendment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillAmendmentsFromDB indicates an expected call of GetBillAmendmentsFromDB.
+func (mr *MockRepositoryMockRecorder) GetBillAmendmentsFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillAmendmentsFromDB", reflect.TypeOf((*MockRepository)(nil).GetBillAmendmentsFromDB), arg0, arg1)
+}
+
+// GetBillCountsFromDB mocks base method.
+func (m *MockRepository) GetBillCountsFromDB(arg0 context.Context) ([]domain.BillCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillCountsFromDB", arg0)
+	ret0, _ := ret[0].([]domain.BillCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillCountsFromDB indicates an expected call of GetBillCountsFromDB.
+func (mr *MockRepositoryMockRecorder) GetBillCountsFromDB(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillCountsFromDB", reflect.TypeOf((*MockRepository)(nil).GetBillCountsFromDB), arg0)
+}
+
+// GetBillEventsFromDB mocks base method.
+func (m *MockRepository) GetBillEventsFromDB(arg0 context.Context, arg1 string) ([]domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillEventsFromDB", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillEventsFromDB indicates an expected call of GetBillEventsFromDB.
+func (mr *MockRepositoryMockRecorder) GetBillEventsFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillEventsFromDB", reflect.TypeOf((*MockRepository)(nil).GetBillEventsFromDB), arg0, arg1)
+}
+
+// GetBillingProfile mocks base method.
+func (m *MockRepository) GetBillingProfile(arg0 context.Context, arg1 string) (*domain.BillingProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillingProfile", arg0, arg1)
+	ret0, _ := ret[0].(*domain.BillingProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillingProfile indicates an expected call of GetBillingProfile.
+func (mr *MockRepositoryMockRecorder) GetBillingProfile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillingProfile", reflect.TypeOf((*MockRepository)(nil).GetBillingProfile), arg0, arg1)
+}
+
+// GetCategoryRevenueFromDB mocks base method.
+func (m *MockRepository) GetCategoryRevenueFromDB(arg0 context.Context, arg1 time.Time, arg2 time.Time) ([]domain.ItemTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryRevenueFromDB", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.ItemTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryRevenueFromDB indicates an expected call of GetCategoryRevenueFromDB.
+func (mr *MockRepositoryMockRecorder) GetCategoryRevenueFromDB(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryRevenueFromDB", reflect.TypeOf((*MockRepository)(nil).GetCategoryRevenueFromDB), arg0, arg1, arg2)
+}
+
 // GetClosedBillFromDB mocks base method.
 func (m *MockRepository) GetClosedBillFromDB(arg0 context.Context, arg1 string) (*domain.Bill, error) {
 	m.ctrl.T.Helper()
@@ -193,6 +402,66 @@ func (mr *MockRepositoryMockRecorder) GetClosedBillItemsFromDB(arg0, arg1 any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedBillItemsFromDB", reflect.TypeOf((*MockRepository)(nil).GetClosedBillItemsFromDB), arg0, arg1)
 }
 
+// GetCreditLimit mocks base method.
+func (m *MockRepository) GetCreditLimit(arg0 context.Context, arg1 string) (*domain.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCreditLimit", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCreditLimit indicates an expected call of GetCreditLimit.
+func (mr *MockRepositoryMockRecorder) GetCreditLimit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreditLimit", reflect.TypeOf((*MockRepository)(nil).GetCreditLimit), arg0, arg1)
+}
+
+// GetDailyRevenueFromDB mocks base method.
+func (m *MockRepository) GetDailyRevenueFromDB(arg0 context.Context, arg1 time.Time, arg2 time.Time) ([]domain.DailyRevenue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailyRevenueFromDB", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.DailyRevenue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyRevenueFromDB indicates an expected call of GetDailyRevenueFromDB.
+func (mr *MockRepositoryMockRecorder) GetDailyRevenueFromDB(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyRevenueFromDB", reflect.TypeOf((*MockRepository)(nil).GetDailyRevenueFromDB), arg0, arg1, arg2)
+}
+
+// GetItemTotalsFromDB mocks base method.
+func (m *MockRepository) GetItemTotalsFromDB(arg0 context.Context, arg1 domain.ItemFilter) ([]domain.ItemTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemTotalsFromDB", arg0, arg1)
+	ret0, _ := ret[0].([]domain.ItemTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemTotalsFromDB indicates an expected call of GetItemTotalsFromDB.
+func (mr *MockRepositoryMockRecorder) GetItemTotalsFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemTotalsFromDB", reflect.TypeOf((*MockRepository)(nil).GetItemTotalsFromDB), arg0, arg1)
+}
+
+// GetOpenBillByUserFromDB mocks base method.
+func (m *MockRepository) GetOpenBillByUserFromDB(arg0 context.Context, arg1 string, arg2 string) (*domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenBillByUserFromDB", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenBillByUserFromDB indicates an expected call of GetOpenBillByUserFromDB.
+func (mr *MockRepositoryMockRecorder) GetOpenBillByUserFromDB(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenBillByUserFromDB", reflect.TypeOf((*MockRepository)(nil).GetOpenBillByUserFromDB), arg0, arg1, arg2)
+}
+
 // GetOpenBillFromDB mocks base method.
 func (m *MockRepository) GetOpenBillFromDB(arg0 context.Context, arg1 string) (*domain.Bill, error) {
 	m.ctrl.T.Helper()
@@ -207,3 +476,92 @@ func (mr *MockRepositoryMockRecorder) GetOpenBillFromDB(arg0, arg1 any) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenBillFromDB", reflect.TypeOf((*MockRepository)(nil).GetOpenBillFromDB), arg0, arg1)
 }
+
+// GetTrialBalanceFromDB mocks base method.
+func (m *MockRepository) GetTrialBalanceFromDB(arg0 context.Context, arg1 string, arg2 time.Time) ([]domain.AccountTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrialBalanceFromDB", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.AccountTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrialBalanceFromDB indicates an expected call of GetTrialBalanceFromDB.
+func (mr *MockRepositoryMockRecorder) GetTrialBalanceFromDB(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrialBalanceFromDB", reflect.TypeOf((*MockRepository)(nil).GetTrialBalanceFromDB), arg0, arg1, arg2)
+}
+
+// GetWalletFromDB mocks base method.
+func (m *MockRepository) GetWalletFromDB(arg0 context.Context, arg1 string) (*domain.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWalletFromDB", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWalletFromDB indicates an expected call of GetWalletFromDB.
+func (mr *MockRepositoryMockRecorder) GetWalletFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletFromDB", reflect.TypeOf((*MockRepository)(nil).GetWalletFromDB), arg0, arg1)
+}
+
+// ListBillsFromDB mocks base method.
+func (m *MockRepository) ListBillsFromDB(arg0 context.Context, arg1 domain.BillFilter) ([]domain.Bill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillsFromDB", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Bill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillsFromDB indicates an expected call of ListBillsFromDB.
+func (mr *MockRepositoryMockRecorder) ListBillsFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillsFromDB", reflect.TypeOf((*MockRepository)(nil).ListBillsFromDB), arg0, arg1)
+}
+
+// ListJournalEntriesFromDB mocks base method.
+func (m *MockRepository) ListJournalEntriesFromDB(arg0 context.Context, arg1 time.Time, arg2 time.Time) ([]domain.JournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJournalEntriesFromDB", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.JournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJournalEntriesFromDB indicates an expected call of ListJournalEntriesFromDB.
+func (mr *MockRepositoryMockRecorder) ListJournalEntriesFromDB(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJournalEntriesFromDB", reflect.TypeOf((*MockRepository)(nil).ListJournalEntriesFromDB), arg0, arg1, arg2)
+}
+
+// ReleaseOpenBillFromDB mocks base method.
+func (m *MockRepository) ReleaseOpenBillFromDB(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseOpenBillFromDB", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseOpenBillFromDB indicates an expected call of ReleaseOpenBillFromDB.
+func (mr *MockRepositoryMockRecorder) ReleaseOpenBillFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseOpenBillFromDB", reflect.TypeOf((*MockRepository)(nil).ReleaseOpenBillFromDB), arg0, arg1)
+}
+
+// SearchBillsFromDB mocks base method.
+func (m *MockRepository) SearchBillsFromDB(arg0 context.Context, arg1 domain.SearchFilter) ([]domain.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchBillsFromDB", arg0, arg1)
+	ret0, _ := ret[0].([]domain.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchBillsFromDB indicates an expected call of SearchBillsFromDB.
+func (mr *MockRepositoryMockRecorder) SearchBillsFromDB(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchBillsFromDB", reflect.TypeOf((*MockRepository)(nil).SearchBillsFromDB), arg0, arg1)
+}
@@ -2,70 +2,175 @@ package billing
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
 	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
 	"github.com/vvvakho/feezy/billing/workflows"
 )
 
 // CreateBill creates a new bill for a given user and currency.
 // It starts an asynchronous Temporal workflows to manage the bill lifecycle.
 // Returns the newly created bill's ID, status, and metadata.
+// If the single open bill policy is enabled and the user already has an open
+// bill in the requested currency, that bill is returned instead, with its own
+// external reference and metadata.
 //
-// encore: api private method=POST path=/bills
+//encore:api auth method=POST path=/bills
 func (s *Service) CreateBill(ctx context.Context, req *CreateBillRequest) (*CreateBillResponse, error) {
 	if err := validateCreateBillRequest(req); err != nil {
-		return nil, fmt.Errorf("Could not validate request: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Could not validate request", err)
+	}
+
+	// Users may only create bills for themselves
+	if err := authorize(ctx, req.UserID); err != nil {
+		return nil, newError(errs.PermissionDenied, ErrorDetails{}, "Access denied", err)
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{}, "Could not identify caller", err)
+	}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not resolve tenant", err)
+	}
+
+	if err := tenant.ValidateCurrency(tenantID, req.Currency); err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{Field: "currency"}, "Could not validate request", err)
+	}
+
+	// Bills can only be created for registered customers
+	if _, err := s.Repository.GetBillingProfile(ctx, req.UserID); err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.FailedPrecondition, ErrorDetails{Field: "user_id"}, "Customer is not registered", nil)
+		}
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not find customer", err)
 	}
 
 	bill, err := domain.NewBill(req.UserID, req.Currency)
 	if err != nil {
-		return nil, fmt.Errorf("Could not validate bill parameters: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Could not validate bill parameters", err)
+	}
+
+	// Apply the tenant's tax configuration to the bill
+	tenantConf, err := tenant.Lookup(tenantID)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not resolve tenant", err)
+	}
+	bill.TenantID = tenantID
+	bill.TaxRate = tenantConf.TaxRate
+	bill.ExternalRef = req.ExternalRef
+	bill.Metadata = req.Metadata
+
+	// Cap the bill at the customer's credit limit, or the tenant's if the customer has none
+	customerLimit, err := s.Repository.GetCreditLimit(ctx, req.UserID)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up credit limit", err)
+	}
+	bill.CreditLimit = creditLimit(tenantConf, customerLimit)
+	bill.PaymentTerms = paymentTerms(tenantConf)
+	bill.Record(domain.EventBillCreated, caller.UserID, "", nil, bill.Total, bill.CreatedAt)
+
+	// Claim the user's open bill in the currency before starting its workflow, so that
+	// concurrent requests settle on a single bill
+	if conf.SINGLE_OPEN_BILL_PER_CURRENCY {
+		existing, err := s.Repository.ClaimOpenBillInDB(ctx, bill)
+		if err != nil {
+			return nil, executionError(ErrorDetails{}, "Could not look up open bills", err)
+		}
+		if existing != nil {
+			return &CreateBillResponse{
+				ID:          existing.ID.String(),
+				UserID:      existing.UserID.String(),
+				Currency:    existing.Total.Currency,
+				ExternalRef: existing.ExternalRef,
+				Metadata:    existing.Metadata,
+				CreatedAt:   existing.CreatedAt,
+				Status:      string(existing.Status),
+			}, nil
+		}
 	}
 
 	// Start workflows asynchronously
 	err = s.Execution.CreateBillWorkflow(ctx, bill)
 	if err != nil {
-		return nil, fmt.Errorf("Could not create bill: %v", err)
+		if conf.SINGLE_OPEN_BILL_PER_CURRENCY {
+			if releaseErr := s.Repository.ReleaseOpenBillFromDB(ctx, bill.ID.String()); releaseErr != nil {
+				rlog.Error("could not release open bill claim", "bill_id", bill.ID, "error", releaseErr)
+			}
+		}
+		return nil, executionError(ErrorDetails{BillID: bill.ID.String()}, "Could not create bill", err)
 	}
 
 	return &CreateBillResponse{
-		ID:        bill.ID.String(),
-		UserID:    bill.UserID.String(),
-		Currency:  bill.Total.Currency,
-		CreatedAt: bill.CreatedAt,
-		Status:    string(bill.Status),
+		ID:          bill.ID.String(),
+		UserID:      bill.UserID.String(),
+		Currency:    bill.Total.Currency,
+		ExternalRef: bill.ExternalRef,
+		Metadata:    bill.Metadata,
+		CreatedAt:   bill.CreatedAt,
+		Status:      string(bill.Status),
 	}, nil
 }
 
 // GetBill retrieves the details of a specific bill by ID.
 // If the bill is active, it queries the Temporal workflows for its current state.
 // If the bill is closed, it fetches details from the database.
+// Open bills carry an ETag of their revision, which changes may send back in an If-Match header
+// to be rejected if the bill was changed since.
 //
-//encore:api private method=GET path=/bills/:id
+//encore:api auth method=GET path=/bills/:id
 func (s *Service) GetBill(ctx context.Context, id string) (*GetBillResponse, error) {
+	details := ErrorDetails{BillID: id}
+
 	// Check if bill exists in open_bills DB
-	_, err := s.Repository.GetOpenBillFromDB(ctx, id)
+	openBill, err := s.Repository.GetOpenBillFromDB(ctx, id)
 	if err == nil {
+		if err := authorize(ctx, openBill.UserID.String()); err != nil {
+			return nil, newError(errs.PermissionDenied, details, "Access denied", err)
+		}
+
 		// Check if the workflows is running (only if bill is open)
-		if err := s.Execution.IsWorkflowRunning(id); err != nil {
-			return nil, fmt.Errorf("Unexpected error fetching bill: %v", err)
+		if err := s.Execution.IsWorkflowRunning(ctx, id); err != nil {
+			if errors.Is(err, billerr.ErrBillClosing) {
+				details.Status = domain.BillClosing
+				return nil, newError(errs.FailedPrecondition, details, "Bill is being closed", nil)
+			}
+			return nil, newError(errs.Internal, details, "Unexpected error fetching bill", err)
 		} else {
 			// Query Temporal Workflow for Bill Details
 			var bill domain.Bill
 			if err := s.Execution.GetBillQuery(ctx, id, &bill); err != nil {
-				return nil, fmt.Errorf("Unable to query bill from Temporal: %v", err)
+				return nil, newError(errs.Internal, details, "Unable to query bill from Temporal", err)
 			}
 
 			return &GetBillResponse{
-				ID:        bill.ID.String(),
-				Items:     bill.Items,
-				Total:     bill.Total,
-				Status:    bill.Status,
-				UserID:    bill.UserID.String(),
-				CreatedAt: bill.CreatedAt,
-				UpdatedAt: bill.UpdatedAt,
+				ID:             bill.ID.String(),
+				Items:          bill.Items,
+				Total:          bill.Total,
+				Tax:            bill.Tax,
+				Status:         bill.Status,
+				UserID:         bill.UserID.String(),
+				BillingProfile: bill.BillingProfile,
+				Approval:       bill.Approval,
+				Version:        bill.Version,
+				Revision:       bill.Revision,
+				ExternalRef:    bill.ExternalRef,
+				Metadata:       bill.Metadata,
+				CreatedAt:      bill.CreatedAt,
+				UpdatedAt:      bill.UpdatedAt,
+				ETag:           billETag(bill.Revision),
 			}, nil
 		}
 	}
@@ -73,49 +178,126 @@ func (s *Service) GetBill(ctx context.Context, id string) (*GetBillResponse, err
 	// If bill is not in open_bills, check closed_bills DB
 	closedBill, err := s.Repository.GetClosedBillFromDB(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("Bill not found: %v", err)
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.NotFound, details, "Bill not found", nil)
+		}
+		return nil, newError(errs.Internal, details, "Could not look up bill", err)
+	}
+
+	if err := authorize(ctx, closedBill.UserID.String()); err != nil {
+		return nil, newError(errs.PermissionDenied, details, "Access denied", err)
 	}
 
 	closedBillItems, err := s.Repository.GetClosedBillItemsFromDB(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("Bill items not found: %v", err)
+		return nil, newError(errs.Internal, details, "Bill items not found", err)
 	}
 
 	return &GetBillResponse{
-		ID:        closedBill.ID.String(),
-		Items:     closedBillItems,
-		Total:     closedBill.Total,
-		Status:    closedBill.Status,
-		UserID:    closedBill.UserID.String(),
-		CreatedAt: closedBill.CreatedAt,
-		UpdatedAt: closedBill.UpdatedAt,
+		ID:             closedBill.ID.String(),
+		Items:          closedBillItems,
+		Total:          closedBill.Total,
+		Tax:            closedBill.Tax,
+		Status:         closedBill.Status,
+		UserID:         closedBill.UserID.String(),
+		BillingProfile: closedBill.BillingProfile,
+		Cancellation:   closedBill.Cancellation,
+		Version:        closedBill.Version,
+		AmendsBillID:   closedBill.AmendsBillID,
+		AmendedByID:    closedBill.AmendedByID,
+		DueAt:          closedBill.DueAt,
+		Collection:     closedBill.Collection,
+		LateFees:       closedBill.LateFees,
+		CreditApplied:  creditApplied(closedBill),
+		ExternalRef:    closedBill.ExternalRef,
+		Metadata:       closedBill.Metadata,
+		CreatedAt:      closedBill.CreatedAt,
+		UpdatedAt:      closedBill.UpdatedAt,
 	}, nil
 }
 
+// ListBills lists the open and closed bills of the tenant, newest first, optionally narrowed down by user,
+// status, external reference and metadata. Users may only list their own bills, and list them by default.
+//
+//encore:api auth method=GET path=/bills
+func (s *Service) ListBills(ctx context.Context, req *ListBillsRequest) (*ListBillsResponse, error) {
+	filter, err := validateListBillsRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if filter.UserID, err = scopeToCaller(ctx, filter.UserID); err != nil {
+		return nil, newError(errs.PermissionDenied, ErrorDetails{}, "Access denied", err)
+	}
+
+	bills, err := s.Repository.ListBillsFromDB(ctx, filter)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not list bills", err)
+	}
+
+	resp := &ListBillsResponse{Bills: []BillSummary{}}
+	for _, bill := range bills {
+		resp.Bills = append(resp.Bills, billSummary(bill))
+	}
+	return resp, nil
+}
+
+// SearchBills searches closed bills by the description, category and SKU of their items, and by their
+// external reference and metadata, best matches first, with the matching words highlighted. Bills can be
+// narrowed down by user and by the period they were closed in. Users may only search their own bills.
+//
+//encore:api auth method=GET path=/search/bills
+func (s *Service) SearchBills(ctx context.Context, req *SearchBillsRequest) (*SearchBillsResponse, error) {
+	filter, err := validateSearchBillsRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if filter.UserID, err = scopeToCaller(ctx, filter.UserID); err != nil {
+		return nil, newError(errs.PermissionDenied, ErrorDetails{}, "Access denied", err)
+	}
+
+	results, err := s.Repository.SearchBillsFromDB(ctx, filter)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not search bills", err)
+	}
+
+	resp := &SearchBillsResponse{Results: []BillSearchResult{}}
+	for _, result := range results {
+		resp.Results = append(resp.Results, BillSearchResult{
+			Bill:      billSummary(result.Bill),
+			Rank:      result.Rank,
+			Highlight: result.Highlight,
+			Items:     result.Items,
+		})
+	}
+	return resp, nil
+}
+
 // AddLineItemToBill adds a new line item to an active bill.
 // If the bill is closed, the request is rejected.
 // Sends an asynchronous signal to the Temporal workflows.
 //
-//encore:api private method=POST path=/bills/:id/items
+//encore:api auth method=POST path=/bills/:id/items
 func (s *Service) AddLineItemToBill(ctx context.Context, id string, req *AddLineItemRequest) (*AddLineItemResponse, error) {
 	if err := validateAddLineItemRequest(req); err != nil {
-		return nil, fmt.Errorf("Invalid request: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id}, "Invalid request", err)
 	}
 
-	// Check if bill exists in open_bills DB
-	_, err := s.Repository.GetOpenBillFromDB(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("Bill not found or already closed: %v", err)
+	// Check that the bill is open and still accepts changes
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
 	}
 
-	// Check if workflow is running
-	if err := s.Execution.IsWorkflowRunning(id); err != nil {
-		return nil, fmt.Errorf("Unexpected error fetching bill: %v", err)
+	// Reject changes made against an earlier revision of the bill
+	revision, err := s.checkIfMatch(ctx, id, req.IfMatch)
+	if err != nil {
+		return nil, err
 	}
 
 	itemID, err := uuid.Parse(req.ID)
 	if err != nil {
-		return nil, fmt.Errorf("Invalid ID: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id, Field: "id"}, "Invalid ID", err)
 	}
 
 	billItem := domain.Item{
@@ -123,40 +305,137 @@ func (s *Service) AddLineItemToBill(ctx context.Context, id string, req *AddLine
 		Quantity:     req.Quantity,
 		Description:  req.Description,
 		PricePerUnit: req.PricePerUnit,
+		Metadata:     req.Metadata,
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
 	}
 
-	err = s.Execution.AddLineItemSignal(ctx, id, &billItem)
+	err = s.Execution.AddLineItemSignal(ctx, id, &workflows.AddItemSignal{
+		LineItem:         billItem,
+		RequestID:        req.RequestID,
+		ActorID:          caller.UserID,
+		ExpectedRevision: revision,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Unable to add line item to bill: %v", err)
+		return nil, executionError(ErrorDetails{BillID: id}, "Unable to add line item to bill", err)
 	}
 
 	return &AddLineItemResponse{Message: "Request has been sent"}, nil
 }
 
+// AddLineItemsToBill adds a batch of line items to an active bill in a single request.
+// Every item is validated up front and the batch is delivered to the bill's workflow as a single update,
+// so either all items are added or none are. Returns the bill with the items added.
+//
+//encore:api auth method=POST path=/bills/:id/items:batch
+func (s *Service) AddLineItemsToBill(ctx context.Context, id string, req *AddLineItemsRequest) (*AddLineItemsResponse, error) {
+	items, err := validateAddLineItemsRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id, Field: "items"}, "Invalid request", err)
+	}
+
+	// Check that the bill is open and still accepts changes
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// Reject changes made against an earlier revision of the bill
+	revision, err := s.checkIfMatch(ctx, id, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
+	}
+
+	bill, err := s.Execution.AddLineItemsUpdate(ctx, id, &workflows.AddItemsUpdate{
+		LineItems:        items,
+		RequestID:        req.RequestID,
+		ActorID:          caller.UserID,
+		ExpectedRevision: revision,
+	})
+	if err != nil {
+		return nil, executionError(ErrorDetails{BillID: id}, "Unable to add line items to bill", err)
+	}
+
+	return &AddLineItemsResponse{Bill: bill, Added: len(items)}, nil
+}
+
+// UpdateLineItemOfBill changes the quantity, description or price of a line item of an active bill in place.
+// The request names the version of the item it was made against, and is rejected if the item
+// was changed since. Returns the bill and the item as updated.
+//
+//encore:api auth method=PUT path=/bills/:id/items/:itemID
+func (s *Service) UpdateLineItemOfBill(ctx context.Context, id string, itemID string, req *UpdateLineItemRequest) (*UpdateLineItemResponse, error) {
+	update, err := validateUpdateLineItemRequest(itemID, req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id}, "Invalid request", err)
+	}
+
+	// Check that the bill is open and still accepts changes
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// Reject changes made against an earlier revision of the bill
+	revision, err := s.checkIfMatch(ctx, id, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
+	}
+
+	bill, err := s.Execution.UpdateLineItemUpdate(ctx, id, &workflows.UpdateItemUpdate{
+		Update:           *update,
+		RequestID:        req.RequestID,
+		ActorID:          caller.UserID,
+		ExpectedRevision: revision,
+	})
+	if err != nil {
+		return nil, executionError(ErrorDetails{BillID: id}, "Unable to update line item", err)
+	}
+
+	resp := &UpdateLineItemResponse{Bill: bill}
+	for i := range bill.Items {
+		if bill.Items[i].ID == update.ID {
+			resp.Item = &bill.Items[i]
+		}
+	}
+	return resp, nil
+}
+
 // RemoveLineItemFromBill removes an existing line item from an active bill.
 // If the bill is closed, the request is rejected.
 // Sends an asynchronous signal to the Temporal workflows.
 //
-//encore:api private method=PATCH path=/bills/:id/items
+//encore:api auth method=PATCH path=/bills/:id/items
 func (s *Service) RemoveLineItemFromBill(ctx context.Context, id string, req *RemoveLineItemRequest) (*RemoveLineItemResponse, error) {
 	if err := validateRemoveLineItemRequest(req); err != nil {
-		return nil, fmt.Errorf("Invalid request: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id}, "Invalid request", err)
 	}
 
-	// Check if bill exists in open_bills DB
-	_, err := s.Repository.GetOpenBillFromDB(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("Bill not found or already closed: %v", err)
+	// Check that the bill is open and still accepts changes
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
 	}
 
-	// Check if workflow is running
-	if err := s.Execution.IsWorkflowRunning(id); err != nil {
-		return nil, fmt.Errorf("Unexpected error fetching bill: %v", err)
+	// Reject changes made against an earlier revision of the bill
+	revision, err := s.checkIfMatch(ctx, id, req.IfMatch)
+	if err != nil {
+		return nil, err
 	}
 
 	itemID, err := uuid.Parse(req.ID)
 	if err != nil {
-		return nil, fmt.Errorf("Invalid ID: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id, Field: "id"}, "Invalid ID", err)
 	}
 
 	billItem := domain.Item{
@@ -166,9 +445,19 @@ func (s *Service) RemoveLineItemFromBill(ctx context.Context, id string, req *Re
 		PricePerUnit: req.PricePerUnit,
 	}
 
-	err = s.Execution.RemoveLineItemSignal(ctx, id, &billItem)
+	caller, err := authn.FromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Error signaling removeLineItem task: %v", err)
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
+	}
+
+	err = s.Execution.RemoveLineItemSignal(ctx, id, &workflows.RemoveItemSignal{
+		LineItem:         billItem,
+		RequestID:        req.RequestID,
+		ActorID:          caller.UserID,
+		ExpectedRevision: revision,
+	})
+	if err != nil {
+		return nil, executionError(ErrorDetails{BillID: id}, "Error signaling removeLineItem task", err)
 	}
 
 	return &RemoveLineItemResponse{Message: "Request has been sent"}, nil
@@ -178,29 +467,618 @@ func (s *Service) RemoveLineItemFromBill(ctx context.Context, id string, req *Re
 // Sends a signal to the Temporal workflows to mark the bill as closed.
 // Closed bills are moved to the database for storage.
 //
-//encore:api private method=PATCH path=/bills/:id
+//encore:api auth method=PATCH path=/bills/:id
 func (s *Service) CloseBill(ctx context.Context, id string, req *CloseBillRequest) (*CloseBillResponse, error) {
+	return s.closeBill(ctx, id, req, false)
+}
+
+// ClosePaidBill closes an open bill whose payment was taken along with the close, marking it as paid.
+// The payment is journaled with the closed bill, and the bill is never dunned.
+// Used by the payments service once a bill was paid.
+//
+//encore:api private method=POST path=/bills/:id/paid
+func (s *Service) ClosePaidBill(ctx context.Context, id string, req *CloseBillRequest) (*CloseBillResponse, error) {
+	return s.closeBill(ctx, id, req, true)
+}
+
+// Close an open bill, as paid if its payment was taken along with the close.
+func (s *Service) closeBill(ctx context.Context, id string, req *CloseBillRequest, paid bool) (*CloseBillResponse, error) {
 	if err := validateCloseBillRequest(id, req); err != nil {
-		return nil, fmt.Errorf("Invalid request parameters: %v", err)
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id}, "Invalid request parameters", err)
 	}
 
-	// Check if bill exists in open_bills DB
-	_, err := s.Repository.GetOpenBillFromDB(ctx, id)
+	// Check that the bill is open and still accepts changes
+	openBill, err := s.getOpenBill(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject close requests made against an earlier revision of the bill
+	revision, err := s.checkIfMatch(ctx, id, req.IfMatch)
 	if err != nil {
-		return nil, fmt.Errorf("Bill not found or already closed: %v", err)
+		return nil, err
 	}
 
-	// Check if workflow is running
-	if err := s.Execution.IsWorkflowRunning(id); err != nil {
-		return nil, fmt.Errorf("Unexpected error fetching bill: %v", err)
+	// Snapshot the customer's current billing profile onto the closed bill
+	profile, err := s.Repository.GetBillingProfile(ctx, openBill.UserID.String())
+	if err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.FailedPrecondition, ErrorDetails{BillID: id}, "Customer is not registered", nil)
+		}
+		return nil, newError(errs.Internal, ErrorDetails{BillID: id}, "Could not find billing profile", err)
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
+	}
+
+	// Bills above the tenant's approval limit need a second approver before they are closed
+	policy, err := approvalPolicy(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{BillID: id}, "Could not resolve tenant", err)
 	}
 
 	// Perform a synchronous request to close bill and return its state
 	// Alternatively, we have an option to use CloseBillSignal() for asynchronicity
-	closedBill, err := s.Execution.CloseBillUpdate(ctx, id, &workflows.CloseBillSignal{RequestID: req.RequestID})
+	closedBill, err := s.Execution.CloseBillUpdate(ctx, id, &workflows.CloseBillSignal{
+		RequestID:        req.RequestID,
+		ActorID:          caller.UserID,
+		BillingProfile:   profile,
+		ApprovalPolicy:   policy,
+		ExpectedRevision: revision,
+		Paid:             paid,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Error sending CloseBill update: %v", err)
+		return nil, executionError(ErrorDetails{BillID: id}, "Error sending CloseBill update", err)
 	}
 
+	if closedBill.Status == domain.BillAwaitingApproval {
+		return &CloseBillResponse{Bill: closedBill, Status: "Bill awaiting close approval"}, nil
+	}
 	return &CloseBillResponse{Bill: closedBill, Status: "Bill successfully closed"}, nil
 }
+
+// DecideBillClose approves or rejects the pending close request of a bill above the approval limit.
+// Only admins other than the one who requested the close may decide, and approved bills are closed right away.
+// Sends an asynchronous signal to the Temporal workflows.
+//
+//encore:api auth method=POST path=/bills/:id/approval
+func (s *Service) DecideBillClose(ctx context.Context, id string, req *CloseApprovalRequest) (*CloseApprovalResponse, error) {
+	details := ErrorDetails{BillID: id}
+
+	if err := validateCloseApprovalRequest(id, req); err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid request parameters", err)
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, details, "Could not identify caller", err)
+	}
+	if !caller.IsAdmin() {
+		return nil, newError(errs.PermissionDenied, details, "Only admins may approve closing bills", nil)
+	}
+
+	// Check that the bill is open and its workflow still running
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var bill domain.Bill
+	if err := s.Execution.GetBillQuery(ctx, id, &bill); err != nil {
+		return nil, newError(errs.Internal, details, "Unable to query bill from Temporal", err)
+	}
+
+	details.Status = bill.Status
+	if bill.Status != domain.BillAwaitingApproval || bill.Approval == nil {
+		return nil, newError(errs.FailedPrecondition, details, "Bill is not awaiting close approval", nil)
+	}
+	if bill.Approval.RequestedBy == caller.UserID {
+		return nil, newError(errs.PermissionDenied, details, "Closing a bill must be approved by a second approver", nil)
+	}
+
+	err = s.Execution.CloseApprovalSignal(ctx, id, &workflows.CloseApprovalSignal{
+		RequestID:  bill.Approval.RequestID,
+		Approved:   req.Approved,
+		ApproverID: caller.UserID,
+		Comment:    req.Comment,
+	})
+	if err != nil {
+		return nil, executionError(details, "Error signaling close approval", err)
+	}
+
+	return &CloseApprovalResponse{Message: "Decision has been sent"}, nil
+}
+
+// CancelBill voids an open bill without producing a payable closed bill.
+// The cancellation reason and the caller who cancelled the bill are recorded with it.
+// Cancelled bills remain queryable with their items, but can no longer be modified or paid.
+//
+//encore:api auth method=POST path=/bills/:id/cancel
+func (s *Service) CancelBill(ctx context.Context, id string, req *CancelBillRequest) (*CancelBillResponse, error) {
+	if err := validateCancelBillRequest(id, req); err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{BillID: id}, "Invalid request parameters", err)
+	}
+
+	// Check that the bill is open and still accepts changes
+	if _, err := s.getOpenBill(ctx, id); err != nil {
+		return nil, err
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{BillID: id}, "Could not identify caller", err)
+	}
+
+	cancelledBill, err := s.Execution.CancelBillUpdate(ctx, id, &workflows.CancelBillSignal{
+		RequestID: req.RequestID,
+		Reason:    req.Reason,
+		ActorID:   caller.UserID,
+	})
+	if err != nil {
+		return nil, executionError(ErrorDetails{BillID: id}, "Error sending CancelBill update", err)
+	}
+
+	return &CancelBillResponse{Bill: cancelledBill, Status: "Bill successfully cancelled"}, nil
+}
+
+// ListBillEvents lists the history of a bill: its creation, every item added or removed,
+// and its closing, cancellation or amendment, along with who made each change.
+// The history of open bills is read from their workflow, that of closed bills from the database.
+//
+//encore:api auth method=GET path=/bills/:id/events
+func (s *Service) ListBillEvents(ctx context.Context, id string) (*ListBillEventsResponse, error) {
+	details := ErrorDetails{BillID: id}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid ID", err)
+	}
+
+	bill, err := s.getBillHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListBillEventsResponse{Events: bill.Events}, nil
+}
+
+// GetBillAsOf rebuilds a bill as it was at the given time by replaying its history.
+// Items, totals, status and revision are those of the replayed bill. Events of closing, cancelling and
+// amending a bill do not carry their details, so the billing profile, due date, cancellation and amended
+// items of a bill closed, cancelled or amended by then are those of its current state. Approvals, late
+// fees, collection and wallet credit are left out. Bills closed before their history was recorded cannot be rebuilt.
+//
+//encore:api auth method=GET path=/bills/:id/as-of
+func (s *Service) GetBillAsOf(ctx context.Context, id string, req *GetBillAsOfRequest) (*GetBillResponse, error) {
+	details := ErrorDetails{BillID: id}
+
+	at, err := validateGetBillAsOfRequest(id, req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid request parameters", err)
+	}
+
+	bill, err := s.getBillHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	projected, err := domain.Replay(bill, bill.Events, at)
+	if err != nil {
+		if errors.Is(err, domain.ErrNoHistory) {
+			return nil, newError(errs.NotFound, details, "Bill has no recorded history at the given time", nil)
+		}
+		return nil, newError(errs.Internal, details, "Could not replay bill history", err)
+	}
+
+	return &GetBillResponse{
+		ID:             projected.ID.String(),
+		Items:          projected.Items,
+		Total:          projected.Total,
+		Tax:            projected.Tax,
+		Status:         projected.Status,
+		UserID:         projected.UserID.String(),
+		BillingProfile: projected.BillingProfile,
+		Cancellation:   projected.Cancellation,
+		Version:        projected.Version,
+		Revision:       projected.Revision,
+		ExternalRef:    projected.ExternalRef,
+		Metadata:       projected.Metadata,
+		AmendsBillID:   projected.AmendsBillID,
+		DueAt:          projected.DueAt,
+		CreatedAt:      projected.CreatedAt,
+		UpdatedAt:      projected.UpdatedAt,
+	}, nil
+}
+
+// AmendBill corrects a closed bill by creating a new version of it with the given items.
+// The original version stays queryable and links to the new one, and the difference
+// between both versions is issued as a credit or debit. Only admins may amend bills.
+//
+//encore:api auth method=POST path=/bills/:id/amendments
+func (s *Service) AmendBill(ctx context.Context, id string, req *AmendBillRequest) (*AmendBillResponse, error) {
+	details := ErrorDetails{BillID: id}
+
+	if err := validateAmendBillRequest(id, req); err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid request parameters", err)
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, details, "Could not identify caller", err)
+	}
+	if !caller.IsAdmin() {
+		return nil, newError(errs.PermissionDenied, details, "Only admins may amend bills", nil)
+	}
+
+	// Only closed bills can be amended, open bills are still changed through their workflow
+	original, err := s.Repository.GetClosedBillFromDB(ctx, id)
+	if err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.NotFound, details, "Bill not found", nil)
+		}
+		return nil, newError(errs.Internal, details, "Could not look up bill", err)
+	}
+
+	original.Items, err = s.Repository.GetClosedBillItemsFromDB(ctx, id)
+	if err != nil {
+		return nil, newError(errs.Internal, details, "Bill items not found", err)
+	}
+
+	details.Status = original.Status
+	if original.Status != domain.BillClosed {
+		return nil, newError(errs.FailedPrecondition, details, fmt.Sprintf("Cannot amend a bill with status %s", original.Status), nil)
+	}
+	if original.AmendedByID != nil {
+		return nil, newError(errs.FailedPrecondition, details, "Bill was already amended, amend its latest version", nil)
+	}
+
+	bill, amendment, err := domain.NewAmendment(original, amendItems(req), req.Reason, caller.UserID, time.Now())
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid amendment", err)
+	}
+	bill.Record(domain.EventBillAmended, caller.UserID, req.RequestID, nil, original.Total, amendment.CreatedAt)
+
+	// The new version is dunned in place of the original, under the tenant's current payment terms
+	tenantConf, err := tenant.Lookup(original.TenantID)
+	if err != nil {
+		return nil, newError(errs.Internal, details, "Could not resolve tenant", err)
+	}
+	bill.PaymentTerms = paymentTerms(tenantConf)
+
+	if err := s.Execution.AmendBillWorkflow(ctx, bill, amendment, req.RequestID); err != nil {
+		return nil, executionError(details, "Error amending bill", err)
+	}
+
+	return &AmendBillResponse{Bill: bill, Amendment: amendment, Status: "Bill successfully amended"}, nil
+}
+
+// ListBillAmendments lists the amendments in the version history of a bill, oldest first.
+// Any version of the bill may be given.
+//
+//encore:api auth method=GET path=/bills/:id/amendments
+func (s *Service) ListBillAmendments(ctx context.Context, id string) (*ListBillAmendmentsResponse, error) {
+	details := ErrorDetails{BillID: id}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, newError(errs.InvalidArgument, details, "Invalid ID", err)
+	}
+
+	bill, err := s.Repository.GetClosedBillFromDB(ctx, id)
+	if err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.NotFound, details, "Bill not found", nil)
+		}
+		return nil, newError(errs.Internal, details, "Could not look up bill", err)
+	}
+
+	if err := authorize(ctx, bill.UserID.String()); err != nil {
+		return nil, newError(errs.PermissionDenied, details, "Access denied", err)
+	}
+
+	amendments, err := s.Repository.GetBillAmendmentsFromDB(ctx, id)
+	if err != nil {
+		return nil, newError(errs.Internal, details, "Could not look up amendments", err)
+	}
+
+	return &ListBillAmendmentsResponse{Amendments: amendments}, nil
+}
+
+// GrantWalletCredit adds prepaid credit to the wallet of a customer, which is drawn down
+// by the customer's bills in the same currency as they close. Only admins may grant credit.
+//
+//encore:api auth method=POST path=/wallets/:id/credits
+func (s *Service) GrantWalletCredit(ctx context.Context, id string, req *GrantWalletCreditRequest) (*GetWalletResponse, error) {
+	if err := validateGrantWalletCreditRequest(id, req); err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Unauthenticated, ErrorDetails{}, "Could not identify caller", err)
+	}
+	if !caller.IsAdmin() {
+		return nil, newError(errs.PermissionDenied, ErrorDetails{}, "Only admins may grant credit", nil)
+	}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not resolve tenant", err)
+	}
+
+	if err := tenant.ValidateCurrency(tenantID, req.Amount.Currency); err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{Field: "amount"}, "Could not validate request", err)
+	}
+
+	// Credit can only be granted to registered customers
+	if _, err := s.Repository.GetBillingProfile(ctx, id); err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.FailedPrecondition, ErrorDetails{}, "Customer is not registered", nil)
+		}
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not find customer", err)
+	}
+
+	grant, err := domain.NewWalletGrant(uuid.MustParse(id), req.Amount, req.Reason, caller.UserID, req.RequestID, time.Now())
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{Field: "amount"}, "Invalid credit", err)
+	}
+
+	if err := s.Repository.AddWalletGrantToDB(ctx, grant); err != nil {
+		if errors.Is(err, billerr.ErrDuplicateRequest) {
+			return nil, newError(errs.AlreadyExists, ErrorDetails{}, "Request ID was already used for another grant", nil)
+		}
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not grant credit", err)
+	}
+
+	return s.GetWallet(ctx, id)
+}
+
+// GetWallet returns the prepaid credit balances of a customer, one per currency,
+// along with the ledger of grants and consumptions they result from, oldest first.
+//
+//encore:api auth method=GET path=/wallets/:id
+func (s *Service) GetWallet(ctx context.Context, id string) (*GetWalletResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid ID", err)
+	}
+
+	if err := authorize(ctx, id); err != nil {
+		return nil, newError(errs.PermissionDenied, ErrorDetails{}, "Access denied", err)
+	}
+
+	wallet, err := s.Repository.GetWalletFromDB(ctx, id)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up wallet", err)
+	}
+
+	return &GetWalletResponse{
+		UserID:   id,
+		Balances: wallet.Balances,
+		Entries:  wallet.Entries,
+	}, nil
+}
+
+// GetTrialBalance lists the balance of every ledger account in a currency, up to the given time or now.
+// Every billing money movement is journaled as balanced postings, so total debits always equal total credits.
+// Only admins may read the ledger.
+//
+//encore:api auth method=GET path=/ledger/trial-balance
+func (s *Service) GetTrialBalance(ctx context.Context, req *GetTrialBalanceRequest) (*GetTrialBalanceResponse, error) {
+	asOf, err := validateGetTrialBalanceRequest(req, time.Now())
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read the ledger"); err != nil {
+		return nil, err
+	}
+
+	totals, err := s.Repository.GetTrialBalanceFromDB(ctx, req.Currency, asOf)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up ledger", err)
+	}
+
+	tb, err := domain.NewTrialBalance(req.Currency, asOf, totals)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not draw up trial balance", err)
+	}
+
+	return &GetTrialBalanceResponse{TrialBalance: tb}, nil
+}
+
+// GetAccountStatement lists the postings to a ledger account in a currency over a period,
+// with the account balance before the period and after each posting. Only admins may read the ledger.
+//
+//encore:api auth method=GET path=/ledger/accounts/:code/statement
+func (s *Service) GetAccountStatement(ctx context.Context, code string, req *GetAccountStatementRequest) (*GetAccountStatementResponse, error) {
+	account, from, to, err := validateGetAccountStatementRequest(code, req, time.Now())
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read the ledger"); err != nil {
+		return nil, err
+	}
+
+	opening, lines, err := s.Repository.GetAccountPostingsFromDB(ctx, account.Code, req.Currency, from, to)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up ledger", err)
+	}
+
+	return &GetAccountStatementResponse{
+		Statement: domain.NewAccountStatement(account, req.Currency, from, to, opening, lines),
+	}, nil
+}
+
+// ExportLedger exports the journal entries that occurred within a period, from inclusive to exclusive,
+// as CSV or as a ledger-cli journal. Closed bills, payments and every other billing money movement are
+// exported as their postings, so exports reconcile with the trial balance. Only admins may read the ledger.
+//
+//encore:api auth method=GET path=/ledger/export
+func (s *Service) ExportLedger(ctx context.Context, req *ExportLedgerRequest) (*ExportLedgerResponse, error) {
+	format, from, to, err := validateExportLedgerRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read the ledger"); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.Repository.ListJournalEntriesFromDB(ctx, from, to)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up ledger", err)
+	}
+
+	var content strings.Builder
+	if err := domain.WriteExport(&content, format, entries); err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not write export", err)
+	}
+
+	return &ExportLedgerResponse{
+		Format:      format,
+		ContentType: format.ContentType(),
+		Content:     content.String(),
+	}, nil
+}
+
+// ReportItems sums the items of the bills closed within a period, from inclusive to exclusive, by category
+// and currency. Items can be narrowed down by category, SKU, attributes and the service period they were
+// billed for. Cancelled bills and versions replaced by an amendment are left out. Only admins may read reports.
+//
+//encore:api auth method=GET path=/reports/items
+func (s *Service) ReportItems(ctx context.Context, req *ItemReportRequest) (*ItemReportResponse, error) {
+	filter, err := validateItemReportRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read reports"); err != nil {
+		return nil, err
+	}
+
+	totals, err := s.Repository.GetItemTotalsFromDB(ctx, filter)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up items", err)
+	}
+
+	return &ItemReportResponse{From: filter.From, To: filter.To, Totals: totals}, nil
+}
+
+// ReportRevenue reports the revenue billed within a period, from inclusive to exclusive, by day or month,
+// normalized to a reporting currency along with a breakdown by the currencies bills were closed in.
+// Reports are read from rollups refreshed on a schedule, and are as stale as their refresh time.
+// Only admins may read reports.
+//
+//encore:api auth method=GET path=/reports/revenue
+func (s *Service) ReportRevenue(ctx context.Context, req *RevenueReportRequest) (*RevenueReportResponse, error) {
+	from, to, interval, err := validateRevenueReportRequest(req)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read reports"); err != nil {
+		return nil, err
+	}
+
+	currency, err := reportCurrency(ctx, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.Repository.GetDailyRevenueFromDB(ctx, from, to)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up revenue", err)
+	}
+	refreshedAt, err := s.Repository.GetAnalyticsRefreshFromDB(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up revenue", err)
+	}
+
+	periods, err := domain.RevenueByPeriod(days, interval, currency)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not normalize revenue", err)
+	}
+	summary, err := domain.SumRevenue(from, days, currency)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not normalize revenue", err)
+	}
+
+	return &RevenueReportResponse{
+		From:        from,
+		To:          to,
+		Interval:    interval,
+		Currency:    currency,
+		Periods:     periods,
+		Summary:     summary,
+		RefreshedAt: refreshedAt,
+	}, nil
+}
+
+// ReportRevenueByCategory reports the revenue billed within a period, from inclusive to exclusive, by the
+// category of the items billed, normalized to a reporting currency. Only admins may read reports.
+//
+//encore:api auth method=GET path=/reports/revenue/categories
+func (s *Service) ReportRevenueByCategory(ctx context.Context, req *CategoryReportRequest) (*CategoryReportResponse, error) {
+	from, to, err := parseReportPeriod(req.From, req.To)
+	if err != nil {
+		return nil, newError(errs.InvalidArgument, ErrorDetails{}, "Invalid request parameters", err)
+	}
+
+	if err := requireAdmin(ctx, "Only admins may read reports"); err != nil {
+		return nil, err
+	}
+
+	currency, err := reportCurrency(ctx, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.Repository.GetCategoryRevenueFromDB(ctx, from, to)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up revenue", err)
+	}
+	refreshedAt, err := s.Repository.GetAnalyticsRefreshFromDB(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up revenue", err)
+	}
+
+	categories, err := domain.NormalizeItemTotals(totals, currency)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not normalize revenue", err)
+	}
+
+	return &CategoryReportResponse{From: from, To: to, Currency: currency, Categories: categories, RefreshedAt: refreshedAt}, nil
+}
+
+// ReportBillCounts reports how many bills are open, closed and cancelled, as of the last analytics refresh.
+// Amended bills count once, by their latest version. Only admins may read reports.
+//
+//encore:api auth method=GET path=/reports/bills
+func (s *Service) ReportBillCounts(ctx context.Context) (*BillCountReportResponse, error) {
+	if err := requireAdmin(ctx, "Only admins may read reports"); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.Repository.GetBillCountsFromDB(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up bill counts", err)
+	}
+	refreshedAt, err := s.Repository.GetAnalyticsRefreshFromDB(ctx)
+	if err != nil {
+		return nil, newError(errs.Internal, ErrorDetails{}, "Could not look up bill counts", err)
+	}
+
+	resp := &BillCountReportResponse{Counts: []domain.BillCount{}, RefreshedAt: refreshedAt}
+	for _, count := range counts {
+		switch count.Status {
+		case domain.BillOpen:
+			resp.Open += count.Bills
+		case domain.BillClosed:
+			resp.Closed += count.Bills
+		case domain.BillCancelled:
+			resp.Cancelled += count.Bills
+		}
+		resp.Counts = append(resp.Counts, count)
+	}
+	return resp, nil
+}
@@ -4,20 +4,34 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"encore.dev/beta/errs"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	mock_billing "github.com/vvvakho/feezy/billing/mocks"
 	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
+	"github.com/vvvakho/feezy/billing/workflows"
 	"go.uber.org/mock/gomock"
 )
 
+// Request context of an authenticated caller within the default tenant.
+func callerContext(userID string, role authn.Role) context.Context {
+	ctx := tenant.NewContext(context.Background(), conf.DEFAULT_TENANT)
+	return authn.NewContext(ctx, &authn.Data{UserID: userID, TenantID: conf.DEFAULT_TENANT, Role: role})
+}
+
 func TestCreateBill(t *testing.T) {
 	tests := []struct {
 		name                string
 		userID              string
 		currency            string
 		mockError           error
+		customerError       error
 		expectError         bool
 		shouldCallExecution bool
 	}{
@@ -37,6 +51,14 @@ func TestCreateBill(t *testing.T) {
 			expectError:         true,
 			shouldCallExecution: true,
 		},
+		{
+			name:                "Failure Case - Unknown Customer",
+			userID:              uuid.New().String(),
+			currency:            "USD",
+			customerError:       assert.AnError,
+			expectError:         true,
+			shouldCallExecution: false,
+		},
 		{
 			name:                "Failure Case - Invalid UserID",
 			userID:              "invalid-uuid",
@@ -90,16 +112,28 @@ func TestCreateBill(t *testing.T) {
 				Currency: tt.currency,
 			}
 
+			// Valid requests look up the customer before creating the bill
+			if tt.shouldCallExecution || tt.customerError != nil {
+				mockRepository.EXPECT().
+					GetBillingProfile(gomock.Any(), tt.userID).
+					Return(&domain.BillingProfile{}, tt.customerError).
+					Times(1)
+			}
+
 			// Set expectations using GoMock only if execution should be called
+			// The bill's history starts with its creation by the caller
 			if tt.shouldCallExecution {
+				mockRepository.EXPECT().GetCreditLimit(gomock.Any(), tt.userID).Return(nil, nil)
 				mockExecution.EXPECT().
-					CreateBillWorkflow(gomock.Any(), gomock.Any()).
+					CreateBillWorkflow(gomock.Any(), gomock.Cond(func(b *domain.Bill) bool {
+						return len(b.Events) == 1 && b.Events[0].Type == domain.EventBillCreated && b.Events[0].ActorID == tt.userID
+					})).
 					Return(tt.mockError).
 					Times(1)
 			}
 
 			fmt.Println("Running test case:", tt.name)
-			resp, err := s.CreateBill(context.Background(), req)
+			resp, err := s.CreateBill(callerContext(tt.userID, authn.RoleUser), req)
 
 			// Assertions
 			if tt.expectError {
@@ -113,6 +147,281 @@ func TestCreateBill(t *testing.T) {
 	}
 }
 
+func TestCreateBillSingleOpenBill(t *testing.T) {
+	conf.SINGLE_OPEN_BILL_PER_CURRENCY = true
+	defer func() { conf.SINGLE_OPEN_BILL_PER_CURRENCY = false }()
+
+	existing, _ := domain.NewBill(uuid.NewString(), "USD")
+
+	tests := []struct {
+		name           string
+		existingBill   *domain.Bill
+		claimError     error
+		executionError error
+		expectError    bool
+		shouldStart    bool
+		shouldRelease  bool
+	}{
+		{
+			name:         "Success - Returns Existing Open Bill",
+			existingBill: existing,
+		},
+		{
+			name:        "Success - Creates Bill When None Open",
+			shouldStart: true,
+		},
+		{
+			name:        "Failure - Claim Error",
+			claimError:  assert.AnError,
+			expectError: true,
+		},
+		{
+			name:           "Failure - Workflow Not Started Releases Claim",
+			executionError: assert.AnError,
+			expectError:    true,
+			shouldStart:    true,
+			shouldRelease:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+			req := &CreateBillRequest{
+				UserID:   existing.UserID.String(),
+				Currency: "USD",
+			}
+
+			var claimed *domain.Bill
+			mockRepository.EXPECT().GetBillingProfile(ctx, req.UserID).Return(&domain.BillingProfile{}, nil)
+			mockRepository.EXPECT().GetCreditLimit(ctx, req.UserID).Return(nil, nil)
+			mockRepository.EXPECT().ClaimOpenBillInDB(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, bill *domain.Bill) (*domain.Bill, error) {
+					claimed = bill
+					return tt.existingBill, tt.claimError
+				})
+
+			if tt.shouldStart {
+				mockExecution.EXPECT().CreateBillWorkflow(ctx, gomock.Any()).Return(tt.executionError)
+			}
+			if tt.shouldRelease {
+				mockRepository.EXPECT().ReleaseOpenBillFromDB(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, billID string) error {
+						assert.Equal(t, claimed.ID.String(), billID)
+						return nil
+					})
+			}
+
+			resp, err := s.CreateBill(ctx, req)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+			if tt.existingBill != nil {
+				assert.Equal(t, tt.existingBill.ID.String(), resp.ID)
+			} else {
+				assert.Equal(t, claimed.ID.String(), resp.ID)
+			}
+		})
+	}
+}
+
+func TestCreateBillTenantConfig(t *testing.T) {
+	conf.TENANTS["acme"] = conf.TenantConfig{Currencies: []string{"USD"}, TaxRate: 1800, PaymentTerms: 30 * 24 * time.Hour}
+	defer delete(conf.TENANTS, "acme")
+
+	tests := []struct {
+		name                string
+		tenantID            string
+		currency            string
+		expectError         bool
+		shouldCallExecution bool
+	}{
+		{
+			name:                "Success - Bill Scoped To Tenant",
+			tenantID:            "acme",
+			currency:            "USD",
+			expectError:         false,
+			shouldCallExecution: true,
+		},
+		{
+			name:                "Failure - Currency Not Enabled For Tenant",
+			tenantID:            "acme",
+			currency:            "GEL",
+			expectError:         true,
+			shouldCallExecution: false,
+		},
+		{
+			name:                "Failure - Missing Tenant",
+			tenantID:            "",
+			currency:            "USD",
+			expectError:         true,
+			shouldCallExecution: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			req := &CreateBillRequest{UserID: uuid.NewString(), Currency: tt.currency}
+			ctx := authn.NewContext(context.Background(), &authn.Data{UserID: req.UserID, TenantID: tt.tenantID, Role: authn.RoleUser})
+			if tt.tenantID != "" {
+				ctx = tenant.NewContext(ctx, tt.tenantID)
+			}
+
+			if tt.shouldCallExecution {
+				mockRepository.EXPECT().GetBillingProfile(ctx, req.UserID).Return(&domain.BillingProfile{}, nil)
+				mockRepository.EXPECT().GetCreditLimit(ctx, req.UserID).Return(nil, nil)
+				// The bill carries the tenant, its tax rate and payment terms into the workflow
+				mockExecution.EXPECT().
+					CreateBillWorkflow(ctx, gomock.Cond(func(b *domain.Bill) bool {
+						return b.TenantID == tt.tenantID && b.TaxRate == 1800 &&
+							b.PaymentTerms.DueIn == 30*24*time.Hour &&
+							len(b.PaymentTerms.Dunning) == len(conf.DUNNING_SCHEDULE)
+					})).
+					Return(nil)
+			}
+
+			resp, err := s.CreateBill(ctx, req)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			}
+		})
+	}
+}
+
+func TestCreateBillCreditLimit(t *testing.T) {
+	conf.TENANTS["acme"] = conf.TenantConfig{Currencies: []string{"USD", "GEL"}, CreditLimit: 100000, CreditCurrency: "USD", CreditWarnAt: []int{80}}
+	defer delete(conf.TENANTS, "acme")
+
+	tests := []struct {
+		name          string
+		tenantID      string
+		customerLimit *domain.Money
+		expected      *domain.CreditLimit
+	}{
+		{
+			name:     "No Credit Limit Configured",
+			tenantID: conf.DEFAULT_TENANT,
+			expected: nil,
+		},
+		{
+			name:     "Tenant Credit Limit",
+			tenantID: "acme",
+			expected: &domain.CreditLimit{Limit: domain.Money{Amount: 100000, Currency: "USD"}, WarnAt: []int{80}},
+		},
+		{
+			name:          "Customer Credit Limit Overrides Tenant",
+			tenantID:      "acme",
+			customerLimit: &domain.Money{Amount: 275000, Currency: "GEL"},
+			expected:      &domain.CreditLimit{Limit: domain.Money{Amount: 275000, Currency: "GEL"}, WarnAt: []int{80}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			req := &CreateBillRequest{UserID: uuid.NewString(), Currency: "USD"}
+			ctx := tenant.NewContext(context.Background(), tt.tenantID)
+			ctx = authn.NewContext(ctx, &authn.Data{UserID: req.UserID, TenantID: tt.tenantID, Role: authn.RoleUser})
+
+			mockRepository.EXPECT().GetBillingProfile(ctx, req.UserID).Return(&domain.BillingProfile{}, nil)
+			mockRepository.EXPECT().GetCreditLimit(ctx, req.UserID).Return(tt.customerLimit, nil)
+			// The resolved credit limit travels with the bill into the workflow
+			mockExecution.EXPECT().
+				CreateBillWorkflow(ctx, gomock.Cond(func(b *domain.Bill) bool {
+					return assert.ObjectsAreEqual(tt.expected, b.CreditLimit)
+				})).
+				Return(nil)
+
+			resp, err := s.CreateBill(ctx, req)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+		})
+	}
+}
+
+func TestCreateBillMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExecution := mock_billing.NewMockExecution(ctrl)
+	mockRepository := mock_billing.NewMockRepository(ctrl)
+	s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+	req := &CreateBillRequest{
+		UserID:      uuid.NewString(),
+		Currency:    "USD",
+		ExternalRef: "PO-1001",
+		Metadata:    map[string]string{"cost_center": "CC-42"},
+	}
+	ctx := callerContext(req.UserID, authn.RoleUser)
+
+	mockRepository.EXPECT().GetBillingProfile(ctx, req.UserID).Return(&domain.BillingProfile{}, nil)
+	mockRepository.EXPECT().GetCreditLimit(ctx, req.UserID).Return(nil, nil)
+	// The reference and metadata travel with the bill into the workflow
+	mockExecution.EXPECT().
+		CreateBillWorkflow(ctx, gomock.Cond(func(b *domain.Bill) bool {
+			return b.ExternalRef == req.ExternalRef && assert.ObjectsAreEqual(req.Metadata, b.Metadata)
+		})).
+		Return(nil)
+
+	resp, err := s.CreateBill(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, req.ExternalRef, resp.ExternalRef)
+	assert.Equal(t, req.Metadata, resp.Metadata)
+
+	// Metadata over its limits is rejected before the bill is created
+	req.Metadata = map[string]string{"": "CC-42"}
+	_, err = s.CreateBill(ctx, req)
+	var apiErr *errs.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, errs.InvalidArgument, apiErr.Code)
+	}
+}
+
 func TestGetBill(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -171,12 +480,12 @@ func TestGetBill(t *testing.T) {
 				Repository: mockRepository,
 			}
 
-			ctx := context.Background()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
 
 			// Mock open bill retrieval
 			if tt.openBillExists {
 				mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(&domain.Bill{}, nil)
-				mockExecution.EXPECT().IsWorkflowRunning(tt.billID).Return(nil)
+				mockExecution.EXPECT().IsWorkflowRunning(ctx, tt.billID).Return(nil)
 
 				if tt.workflowRunning {
 					mockExecution.EXPECT().GetBillQuery(ctx, tt.billID, gomock.Any()).Return(tt.queryError)
@@ -202,6 +511,13 @@ func TestGetBill(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, resp)
 			}
+
+			// Only open bills accept conditional changes, so only they carry an ETag
+			if !tt.expectError && tt.openBillExists {
+				assert.Equal(t, `"0"`, resp.ETag)
+			} else if !tt.expectError {
+				assert.Empty(t, resp.ETag)
+			}
 		})
 	}
 }
@@ -301,14 +617,14 @@ func TestAddLineItemToBill(t *testing.T) {
 				Repository: mockRepository,
 			}
 
-			ctx := context.Background()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
 
 			// Skip mock expectations if validation fails
 			if tt.shouldValidate {
 				// Mock bill retrieval
 				if tt.openBillExists {
 					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(&domain.Bill{}, nil)
-					mockExecution.EXPECT().IsWorkflowRunning(tt.billID).Return(nil)
+					mockExecution.EXPECT().IsWorkflowRunning(ctx, tt.billID).Return(nil)
 				} else {
 					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(nil, assert.AnError)
 				}
@@ -332,6 +648,196 @@ func TestAddLineItemToBill(t *testing.T) {
 	}
 }
 
+func TestAddLineItemsToBill(t *testing.T) {
+	item := BatchLineItem{ID: uuid.NewString(), Quantity: 2, Description: "Seat", PricePerUnit: domain.Money{Amount: 10, Currency: "USD"}}
+
+	tests := []struct {
+		name           string
+		request        *AddLineItemsRequest
+		shouldValidate bool
+		mockError      error
+		expectedCode   errs.ErrCode
+	}{
+		{
+			name:           "Success - Items Added",
+			request:        &AddLineItemsRequest{RequestID: uuid.NewString(), Items: []BatchLineItem{item, item}},
+			shouldValidate: true,
+		},
+		{
+			name:         "Failure - Invalid Item",
+			request:      &AddLineItemsRequest{Items: []BatchLineItem{item, {ID: "invalid-uuid", Quantity: 1, PricePerUnit: item.PricePerUnit}}},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:         "Failure - Empty Batch",
+			request:      &AddLineItemsRequest{},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:           "Failure - Items Rejected By Workflow",
+			request:        &AddLineItemsRequest{Items: []BatchLineItem{item}},
+			shouldValidate: true,
+			mockError:      billerr.New(billerr.ErrUserInput, "Items rejected", domain.ErrCreditLimitExceeded),
+			expectedCode:   errs.InvalidArgument,
+		},
+		{
+			name:           "Failure - Bill Closing",
+			request:        &AddLineItemsRequest{Items: []BatchLineItem{item}},
+			shouldValidate: true,
+			mockError:      billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil),
+			expectedCode:   errs.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			if tt.shouldValidate {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+				mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+
+				// The whole batch is delivered in a single update
+				mockExecution.EXPECT().AddLineItemsUpdate(ctx, billID, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, update *workflows.AddItemsUpdate) (*domain.Bill, error) {
+						assert.Len(t, update.LineItems, len(tt.request.Items))
+						assert.NotEmpty(t, update.RequestID)
+						if tt.mockError != nil {
+							return &domain.Bill{}, tt.mockError
+						}
+						return &domain.Bill{Items: update.LineItems}, nil
+					})
+			}
+
+			resp, err := s.AddLineItemsToBill(ctx, billID, tt.request)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, len(tt.request.Items), resp.Added)
+				assert.Len(t, resp.Bill.Items, len(tt.request.Items))
+			}
+		})
+	}
+}
+
+func TestUpdateLineItemOfBill(t *testing.T) {
+	itemID := uuid.New()
+	quantity := int64(4)
+
+	tests := []struct {
+		name           string
+		itemID         string
+		request        *UpdateLineItemRequest
+		shouldValidate bool
+		mockError      error
+		expectedCode   errs.ErrCode
+	}{
+		{
+			name:           "Success - Item Updated",
+			itemID:         itemID.String(),
+			request:        &UpdateLineItemRequest{Version: 1, Quantity: &quantity},
+			shouldValidate: true,
+		},
+		{
+			name:         "Failure - Invalid Item ID",
+			itemID:       "invalid-uuid",
+			request:      &UpdateLineItemRequest{Version: 1, Quantity: &quantity},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:         "Failure - Nothing To Update",
+			itemID:       itemID.String(),
+			request:      &UpdateLineItemRequest{Version: 1},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:           "Failure - Stale Version",
+			itemID:         itemID.String(),
+			request:        &UpdateLineItemRequest{Version: 1, Quantity: &quantity},
+			shouldValidate: true,
+			mockError:      billerr.New(billerr.ErrVersionConflict, "Item was changed by another request", domain.ErrItemVersionConflict),
+			expectedCode:   errs.Aborted,
+		},
+		{
+			name:           "Failure - Item Not Found",
+			itemID:         itemID.String(),
+			request:        &UpdateLineItemRequest{Version: 1, Quantity: &quantity},
+			shouldValidate: true,
+			mockError:      billerr.New(billerr.ErrNotFound, "Item not found in bill", domain.ErrItemNotFound),
+			expectedCode:   errs.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			if tt.shouldValidate {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+				mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+
+				mockExecution.EXPECT().UpdateLineItemUpdate(ctx, billID, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, update *workflows.UpdateItemUpdate) (*domain.Bill, error) {
+						assert.Equal(t, itemID, update.Update.ID)
+						assert.Equal(t, tt.request.Version, update.Update.Version)
+						assert.NotEmpty(t, update.RequestID)
+						if tt.mockError != nil {
+							return &domain.Bill{}, tt.mockError
+						}
+						item := domain.Item{ID: itemID, Quantity: *update.Update.Quantity, Version: update.Update.Version + 1}
+						return &domain.Bill{Items: []domain.Item{item}}, nil
+					})
+			}
+
+			resp, err := s.UpdateLineItemOfBill(ctx, billID, tt.itemID, tt.request)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				if assert.NotNil(t, resp.Item) {
+					assert.Equal(t, quantity, resp.Item.Quantity)
+					assert.Equal(t, 2, resp.Item.Version)
+				}
+			}
+		})
+	}
+}
+
 func TestRemoveLineItemFromBill(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -425,13 +931,13 @@ func TestRemoveLineItemFromBill(t *testing.T) {
 				Repository: mockRepository,
 			}
 
-			ctx := context.Background()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
 
 			if !tt.skipMockCalls {
 				// Mock bill retrieval
 				if tt.openBillExists {
 					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(&domain.Bill{}, nil)
-					mockExecution.EXPECT().IsWorkflowRunning(tt.billID).Return(nil)
+					mockExecution.EXPECT().IsWorkflowRunning(ctx, tt.billID).Return(nil)
 				} else {
 					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(nil, assert.AnError)
 				}
@@ -462,6 +968,7 @@ func TestCloseBill(t *testing.T) {
 		request         *CloseBillRequest
 		openBillExists  bool
 		workflowRunning bool
+		profileError    error
 		mockError       error
 		expectError     bool
 		skipMockCalls   bool
@@ -507,6 +1014,17 @@ func TestCloseBill(t *testing.T) {
 			mockError:       assert.AnError,
 			expectError:     true,
 		},
+		{
+			name:   "Failure - Billing Profile Not Found",
+			billID: uuid.New().String(),
+			request: &CloseBillRequest{
+				RequestID: uuid.New().String(),
+			},
+			openBillExists:  true,
+			workflowRunning: true,
+			profileError:    assert.AnError,
+			expectError:     true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -522,14 +1040,24 @@ func TestCloseBill(t *testing.T) {
 				Repository: mockRepository,
 			}
 
-			ctx := context.Background()
+			callerID := uuid.NewString()
+			ctx := callerContext(callerID, authn.RoleAdmin)
 
 			if !tt.skipMockCalls {
 				// Mock bill retrieval
 				if tt.openBillExists {
-					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(&domain.Bill{}, nil)
-					mockExecution.EXPECT().IsWorkflowRunning(tt.billID).Return(nil)
-					mockExecution.EXPECT().CloseBillUpdate(ctx, tt.billID, gomock.Any()).Return(&domain.Bill{}, tt.mockError)
+					userID := uuid.New()
+					profile := &domain.BillingProfile{Name: "Acme LLC"}
+					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(&domain.Bill{UserID: userID}, nil)
+					mockExecution.EXPECT().IsWorkflowRunning(ctx, tt.billID).Return(nil)
+					mockRepository.EXPECT().GetBillingProfile(ctx, userID.String()).Return(profile, tt.profileError)
+					if tt.profileError == nil {
+						// The profile snapshot, the caller closing the bill and the tenant's approval policy are forwarded to the workflow
+						policy := &workflows.ApprovalPolicy{Timeout: conf.CLOSE_APPROVAL_TIMEOUT}
+						mockExecution.EXPECT().
+							CloseBillUpdate(ctx, tt.billID, &workflows.CloseBillSignal{RequestID: tt.request.RequestID, ActorID: callerID, BillingProfile: profile, ApprovalPolicy: policy}).
+							Return(&domain.Bill{}, tt.mockError)
+					}
 				} else {
 					mockRepository.EXPECT().GetOpenBillFromDB(ctx, tt.billID).Return(nil, assert.AnError)
 				}
@@ -547,3 +1075,2022 @@ func TestCloseBill(t *testing.T) {
 		})
 	}
 }
+
+func TestCloseBillAwaitingApproval(t *testing.T) {
+	conf.TENANTS["acme"] = conf.TenantConfig{Currencies: []string{"USD"}, ApprovalLimit: 100000, ApprovalCurrency: "USD"}
+	defer delete(conf.TENANTS, "acme")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExecution := mock_billing.NewMockExecution(ctrl)
+	mockRepository := mock_billing.NewMockRepository(ctrl)
+
+	s := &Service{
+		Execution:  mockExecution,
+		Repository: mockRepository,
+	}
+
+	billID := uuid.NewString()
+	userID := uuid.New()
+	callerID := uuid.NewString()
+	ctx := tenant.NewContext(context.Background(), "acme")
+	ctx = authn.NewContext(ctx, &authn.Data{UserID: callerID, TenantID: "acme", Role: authn.RoleAdmin})
+	req := &CloseBillRequest{RequestID: uuid.NewString()}
+
+	mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: userID}, nil)
+	mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+	mockRepository.EXPECT().GetBillingProfile(ctx, userID.String()).Return(&domain.BillingProfile{}, nil)
+	// The tenant's limit is forwarded, and the workflow decides whether the bill needs approval
+	mockExecution.EXPECT().
+		CloseBillUpdate(ctx, billID, gomock.Cond(func(sig *workflows.CloseBillSignal) bool {
+			return sig.ApprovalPolicy.Limit == domain.Money{Amount: 100000, Currency: "USD"} &&
+				sig.ApprovalPolicy.Timeout == conf.CLOSE_APPROVAL_TIMEOUT
+		})).
+		Return(&domain.Bill{Status: domain.BillAwaitingApproval}, nil)
+
+	resp, err := s.CloseBill(ctx, billID, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BillAwaitingApproval, resp.Bill.Status)
+	assert.Equal(t, "Bill awaiting close approval", resp.Status)
+}
+
+func TestClosePaidBill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExecution := mock_billing.NewMockExecution(ctrl)
+	mockRepository := mock_billing.NewMockRepository(ctrl)
+	s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+	billID := uuid.NewString()
+	userID := uuid.New()
+	ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+	mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: userID}, nil)
+	mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+	mockRepository.EXPECT().GetBillingProfile(ctx, userID.String()).Return(&domain.BillingProfile{}, nil)
+	// The workflow is told the bill was paid, so it is closed as paid
+	mockExecution.EXPECT().
+		CloseBillUpdate(ctx, billID, gomock.Cond(func(sig *workflows.CloseBillSignal) bool { return sig.Paid })).
+		Return(&domain.Bill{Status: domain.BillClosed, Collection: domain.CollectionPaid}, nil)
+
+	resp, err := s.ClosePaidBill(ctx, billID, &CloseBillRequest{RequestID: uuid.NewString()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionPaid, resp.Bill.Collection)
+	assert.Equal(t, "Bill successfully closed", resp.Status)
+}
+
+func TestConditionalBillChanges(t *testing.T) {
+	item := domain.Money{Amount: 10, Currency: "USD"}
+
+	// Query the bill of the workflow at revision 3
+	expectRevision := func(mockExecution *mock_billing.MockExecution, ctx context.Context, billID string) {
+		mockExecution.EXPECT().GetBillQuery(ctx, billID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, bill *domain.Bill) error {
+				bill.Revision = 3
+				return nil
+			})
+	}
+
+	tests := []struct {
+		name         string
+		ifMatch      string
+		queried      bool
+		expectedCode errs.ErrCode
+	}{
+		{name: "Unconditional", ifMatch: ""},
+		{name: "Any Revision", ifMatch: "*"},
+		{name: "Current Revision", ifMatch: `"3"`, queried: true},
+		{name: "One Of Several Revisions", ifMatch: `"2", "3"`, queried: true},
+		{name: "Stale Revision", ifMatch: `"2"`, queried: true, expectedCode: errs.FailedPrecondition},
+		{name: "Weak ETag", ifMatch: `W/"3"`, expectedCode: errs.InvalidArgument},
+		{name: "Malformed ETag", ifMatch: "3", expectedCode: errs.InvalidArgument},
+	}
+
+	assertCode := func(t *testing.T, expected errs.ErrCode, err error) {
+		if expected == errs.OK {
+			assert.NoError(t, err)
+			return
+		}
+		var apiErr *errs.Error
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, expected, apiErr.Code)
+			assert.Equal(t, "If-Match", apiErr.Details.(ErrorDetails).Field)
+		}
+	}
+
+	for _, tt := range tests {
+		// The revision the request was checked against is forwarded, so the workflow checks it again
+		expected := 0
+		if tt.queried && tt.expectedCode == errs.OK {
+			expected = 3
+		}
+
+		t.Run("Add "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+			mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+			if tt.queried {
+				expectRevision(mockExecution, ctx, billID)
+			}
+			if tt.expectedCode == errs.OK {
+				mockExecution.EXPECT().AddLineItemSignal(ctx, billID, gomock.Cond(func(sig *workflows.AddItemSignal) bool {
+					return sig.ExpectedRevision == expected
+				})).Return(nil)
+			}
+
+			_, err := s.AddLineItemToBill(ctx, billID, &AddLineItemRequest{IfMatch: tt.ifMatch, ID: uuid.NewString(), Quantity: 1, PricePerUnit: item})
+			assertCode(t, tt.expectedCode, err)
+		})
+
+		t.Run("Add Batch "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+			mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+			if tt.queried {
+				expectRevision(mockExecution, ctx, billID)
+			}
+			if tt.expectedCode == errs.OK {
+				mockExecution.EXPECT().AddLineItemsUpdate(ctx, billID, gomock.Cond(func(update *workflows.AddItemsUpdate) bool {
+					return update.ExpectedRevision == expected
+				})).Return(&domain.Bill{}, nil)
+			}
+
+			_, err := s.AddLineItemsToBill(ctx, billID, &AddLineItemsRequest{
+				IfMatch: tt.ifMatch,
+				Items:   []BatchLineItem{{ID: uuid.NewString(), Quantity: 1, PricePerUnit: item}},
+			})
+			assertCode(t, tt.expectedCode, err)
+		})
+
+		t.Run("Update "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+			mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+			if tt.queried {
+				expectRevision(mockExecution, ctx, billID)
+			}
+			if tt.expectedCode == errs.OK {
+				mockExecution.EXPECT().UpdateLineItemUpdate(ctx, billID, gomock.Cond(func(update *workflows.UpdateItemUpdate) bool {
+					return update.ExpectedRevision == expected
+				})).Return(&domain.Bill{}, nil)
+			}
+
+			quantity := int64(2)
+			_, err := s.UpdateLineItemOfBill(ctx, billID, uuid.NewString(), &UpdateLineItemRequest{IfMatch: tt.ifMatch, Version: 1, Quantity: &quantity})
+			assertCode(t, tt.expectedCode, err)
+		})
+
+		t.Run("Remove "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+			billID := uuid.NewString()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{}, nil)
+			mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+			if tt.queried {
+				expectRevision(mockExecution, ctx, billID)
+			}
+			if tt.expectedCode == errs.OK {
+				mockExecution.EXPECT().RemoveLineItemSignal(ctx, billID, gomock.Cond(func(sig *workflows.RemoveItemSignal) bool {
+					return sig.ExpectedRevision == expected
+				})).Return(nil)
+			}
+
+			_, err := s.RemoveLineItemFromBill(ctx, billID, &RemoveLineItemRequest{IfMatch: tt.ifMatch, ID: uuid.NewString(), Quantity: 1, PricePerUnit: item})
+			assertCode(t, tt.expectedCode, err)
+		})
+
+		t.Run("Close "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+			billID := uuid.NewString()
+			userID := uuid.New()
+			ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+			mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: userID}, nil)
+			mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+			if tt.queried {
+				expectRevision(mockExecution, ctx, billID)
+			}
+			if tt.expectedCode == errs.OK {
+				mockRepository.EXPECT().GetBillingProfile(ctx, userID.String()).Return(&domain.BillingProfile{}, nil)
+				mockExecution.EXPECT().CloseBillUpdate(ctx, billID, gomock.Cond(func(sig *workflows.CloseBillSignal) bool {
+					return sig.ExpectedRevision == expected
+				})).Return(&domain.Bill{Status: domain.BillClosed}, nil)
+			}
+
+			_, err := s.CloseBill(ctx, billID, &CloseBillRequest{IfMatch: tt.ifMatch})
+			assertCode(t, tt.expectedCode, err)
+		})
+	}
+
+	t.Run("Stale Close In Workflow", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecution := mock_billing.NewMockExecution(ctrl)
+		mockRepository := mock_billing.NewMockRepository(ctrl)
+		s := &Service{Execution: mockExecution, Repository: mockRepository}
+
+		billID := uuid.NewString()
+		userID := uuid.New()
+		ctx := callerContext(uuid.NewString(), authn.RoleAdmin)
+
+		// The bill changed between the check of the API and the workflow applying the close
+		mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: userID}, nil)
+		mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+		expectRevision(mockExecution, ctx, billID)
+		mockRepository.EXPECT().GetBillingProfile(ctx, userID.String()).Return(&domain.BillingProfile{}, nil)
+		mockExecution.EXPECT().CloseBillUpdate(ctx, billID, gomock.Any()).
+			Return(&domain.Bill{}, billerr.New(billerr.ErrStaleRevision, "Bill was changed since it was read", domain.ErrStaleRevision))
+
+		_, err := s.CloseBill(ctx, billID, &CloseBillRequest{IfMatch: `"3"`})
+		var apiErr *errs.Error
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, errs.FailedPrecondition, apiErr.Code)
+		}
+	})
+}
+
+func TestDecideBillClose(t *testing.T) {
+	requester := uuid.NewString()
+	approvalRequestID := uuid.NewString()
+
+	awaiting := domain.Bill{
+		Status:   domain.BillAwaitingApproval,
+		Approval: &domain.CloseApproval{RequestID: approvalRequestID, RequestedBy: requester},
+	}
+
+	tests := []struct {
+		name          string
+		request       *CloseApprovalRequest
+		callerID      string
+		role          authn.Role
+		bill          domain.Bill
+		mockError     error
+		skipMockCalls bool
+		expectSignal  bool
+		expectedCode  errs.ErrCode
+	}{
+		{
+			name:         "Success - Close Approved By Second Admin",
+			request:      &CloseApprovalRequest{Approved: true, Comment: "Checked with sales"},
+			callerID:     uuid.NewString(),
+			role:         authn.RoleAdmin,
+			bill:         awaiting,
+			expectSignal: true,
+			expectedCode: errs.OK,
+		},
+		{
+			name:         "Success - Close Rejected",
+			request:      &CloseApprovalRequest{Approved: false, Comment: "Discount missing"},
+			callerID:     uuid.NewString(),
+			role:         authn.RoleAdmin,
+			bill:         awaiting,
+			expectSignal: true,
+			expectedCode: errs.OK,
+		},
+		{
+			name:          "Failure - Caller Is Not Admin",
+			request:       &CloseApprovalRequest{Approved: true},
+			callerID:      uuid.NewString(),
+			role:          authn.RoleUser,
+			skipMockCalls: true,
+			expectedCode:  errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Requester Approves Own Close",
+			request:      &CloseApprovalRequest{Approved: true},
+			callerID:     requester,
+			role:         authn.RoleAdmin,
+			bill:         awaiting,
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Bill Not Awaiting Approval",
+			request:      &CloseApprovalRequest{Approved: true},
+			callerID:     uuid.NewString(),
+			role:         authn.RoleAdmin,
+			bill:         domain.Bill{Status: domain.BillOpen},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name:         "Failure - Bill Closed Meanwhile",
+			request:      &CloseApprovalRequest{Approved: true},
+			callerID:     uuid.NewString(),
+			role:         authn.RoleAdmin,
+			bill:         awaiting,
+			expectSignal: true,
+			mockError:    billerr.New(billerr.ErrBillClosed, "Bill already closed", nil),
+			expectedCode: errs.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.NewString()
+			ctx := callerContext(tt.callerID, tt.role)
+
+			if !tt.skipMockCalls {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: uuid.New()}, nil)
+				mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+				mockExecution.EXPECT().GetBillQuery(ctx, billID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, b *domain.Bill) error {
+						*b = tt.bill
+						return nil
+					})
+			}
+			if tt.expectSignal {
+				// The decision answers the pending close request and names the approver
+				mockExecution.EXPECT().
+					CloseApprovalSignal(ctx, billID, &workflows.CloseApprovalSignal{
+						RequestID:  approvalRequestID,
+						Approved:   tt.request.Approved,
+						ApproverID: tt.callerID,
+						Comment:    tt.request.Comment,
+					}).
+					Return(tt.mockError)
+			}
+
+			resp, err := s.DecideBillClose(ctx, billID, tt.request)
+
+			if tt.expectedCode == errs.OK {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			} else {
+				assert.Nil(t, resp)
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelBill(t *testing.T) {
+	owner := uuid.New()
+
+	tests := []struct {
+		name            string
+		request         *CancelBillRequest
+		callerID        string
+		openBillExists  bool
+		closedBill      bool
+		workflowRunning bool
+		mockError       error
+		expectError     bool
+		skipMockCalls   bool
+	}{
+		{
+			name:            "Success - Bill Cancelled By Owner",
+			request:         &CancelBillRequest{RequestID: uuid.NewString(), Reason: "Created by mistake"},
+			callerID:        owner.String(),
+			openBillExists:  true,
+			workflowRunning: true,
+			expectError:     false,
+		},
+		{
+			name:          "Failure - Missing Reason",
+			request:       &CancelBillRequest{RequestID: uuid.NewString(), Reason: "  "},
+			callerID:      owner.String(),
+			expectError:   true,
+			skipMockCalls: true,
+		},
+		{
+			name:           "Failure - Bill Already Closed",
+			request:        &CancelBillRequest{RequestID: uuid.NewString(), Reason: "Created by mistake"},
+			callerID:       owner.String(),
+			openBillExists: false,
+			closedBill:     true,
+			expectError:    true,
+		},
+		{
+			name:           "Failure - Bill Of Another User",
+			request:        &CancelBillRequest{RequestID: uuid.NewString(), Reason: "Created by mistake"},
+			callerID:       uuid.NewString(),
+			openBillExists: true,
+			expectError:    true,
+		},
+		{
+			name:            "Failure - Workflow Error",
+			request:         &CancelBillRequest{RequestID: uuid.NewString(), Reason: "Created by mistake"},
+			callerID:        owner.String(),
+			openBillExists:  true,
+			workflowRunning: true,
+			mockError:       billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil),
+			expectError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.NewString()
+			ctx := callerContext(tt.callerID, authn.RoleUser)
+
+			if !tt.skipMockCalls {
+				if tt.openBillExists {
+					mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: owner, Status: domain.BillOpen}, nil)
+				} else {
+					mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(nil, fmt.Errorf("bill not found: %w", billerr.ErrNotFound))
+				}
+
+				if tt.closedBill {
+					mockRepository.EXPECT().GetClosedBillFromDB(ctx, billID).Return(&domain.Bill{UserID: owner, Status: domain.BillClosed}, nil)
+				}
+
+				if tt.workflowRunning {
+					mockExecution.EXPECT().IsWorkflowRunning(ctx, billID).Return(nil)
+					// The caller is recorded as the actor of the cancellation
+					mockExecution.EXPECT().
+						CancelBillUpdate(ctx, billID, &workflows.CancelBillSignal{RequestID: tt.request.RequestID, Reason: tt.request.Reason, ActorID: tt.callerID}).
+						Return(&domain.Bill{Status: domain.BillCancelled}, tt.mockError)
+				}
+			}
+
+			resp, err := s.CancelBill(ctx, billID, tt.request)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, domain.BillCancelled, resp.Bill.Status)
+			}
+		})
+	}
+}
+
+func TestListBillEvents(t *testing.T) {
+	owner := uuid.New()
+	openEvents := []domain.Event{{Sequence: 1, Type: domain.EventBillCreated, ActorID: owner.String()}}
+	closedEvents := []domain.Event{
+		{Sequence: 1, Type: domain.EventBillCreated, ActorID: owner.String()},
+		{Sequence: 2, Type: domain.EventBillClosed, ActorID: owner.String()},
+	}
+
+	tests := []struct {
+		name           string
+		callerID       string
+		openBillExists bool
+		expectedEvents []domain.Event
+		expectedCode   errs.ErrCode
+	}{
+		{
+			name:           "Success - Open Bill History From Workflow",
+			callerID:       owner.String(),
+			openBillExists: true,
+			expectedEvents: openEvents,
+		},
+		{
+			name:           "Success - Closed Bill History From Database",
+			callerID:       owner.String(),
+			expectedEvents: closedEvents,
+		},
+		{
+			name:           "Failure - Open Bill Of Another User",
+			callerID:       uuid.NewString(),
+			openBillExists: true,
+			expectedCode:   errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Closed Bill Of Another User",
+			callerID:     uuid.NewString(),
+			expectedCode: errs.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.NewString()
+			ctx := callerContext(tt.callerID, authn.RoleUser)
+
+			if tt.openBillExists {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(&domain.Bill{UserID: owner, Status: domain.BillOpen}, nil)
+				if tt.expectedCode == errs.OK {
+					mockExecution.EXPECT().GetBillQuery(ctx, billID, gomock.Any()).
+						DoAndReturn(func(_ context.Context, _ string, bill *domain.Bill) error {
+							bill.Events = openEvents
+							return nil
+						})
+				}
+			} else {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID).Return(nil, fmt.Errorf("bill not found: %w", billerr.ErrNotFound))
+				mockRepository.EXPECT().GetClosedBillFromDB(ctx, billID).Return(&domain.Bill{UserID: owner, Status: domain.BillClosed}, nil)
+				if tt.expectedCode == errs.OK {
+					mockRepository.EXPECT().GetClosedBillItemsFromDB(ctx, billID).Return([]domain.Item{}, nil)
+					mockRepository.EXPECT().GetBillEventsFromDB(ctx, billID).Return(closedEvents, nil)
+				}
+			}
+
+			resp, err := s.ListBillEvents(ctx, billID)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedEvents, resp.Events)
+			}
+		})
+	}
+}
+
+func TestGetBillAsOf(t *testing.T) {
+	owner := uuid.New()
+	billID := uuid.New()
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	item := domain.Item{ID: uuid.New(), Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}}
+
+	// A closed bill whose item was added an hour after it was created
+	dueAt := created.Add(30 * 24 * time.Hour)
+	closedBill := &domain.Bill{
+		ID: billID, UserID: owner, Status: domain.BillClosed, Total: domain.Money{Amount: 200, Currency: "USD"},
+		ExternalRef: "PO-1001", Metadata: map[string]string{"cost_center": "CC-42"}, DueAt: &dueAt,
+	}
+	events := []domain.Event{
+		{Sequence: 1, Type: domain.EventBillCreated, OccurredAt: created},
+		{Sequence: 2, Type: domain.EventItemAdded, Item: &item, OccurredAt: created.Add(time.Hour)},
+		{Sequence: 3, Type: domain.EventBillClosed, OccurredAt: created.Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name           string
+		at             string
+		expectedStatus domain.Status
+		expectedTotal  domain.MinorUnit
+		expectedCode   errs.ErrCode
+		skipMockCalls  bool
+	}{
+		{
+			name:           "Success - Before Item Was Added",
+			at:             created.Add(30 * time.Minute).Format(time.RFC3339),
+			expectedStatus: domain.BillOpen,
+			expectedTotal:  0,
+		},
+		{
+			name:           "Success - After Item Was Added",
+			at:             created.Add(90 * time.Minute).Format(time.RFC3339),
+			expectedStatus: domain.BillOpen,
+			expectedTotal:  200,
+		},
+		{
+			name:           "Success - After Close",
+			at:             created.Add(3 * time.Hour).Format(time.RFC3339),
+			expectedStatus: domain.BillClosed,
+			expectedTotal:  200,
+		},
+		{
+			name:         "Failure - Before Creation",
+			at:           created.Add(-time.Hour).Format(time.RFC3339),
+			expectedCode: errs.NotFound,
+		},
+		{
+			name:          "Failure - Invalid Time",
+			at:            "yesterday",
+			expectedCode:  errs.InvalidArgument,
+			skipMockCalls: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(owner.String(), authn.RoleUser)
+			if !tt.skipMockCalls {
+				mockRepository.EXPECT().GetOpenBillFromDB(ctx, billID.String()).Return(nil, fmt.Errorf("bill not found: %w", billerr.ErrNotFound))
+				mockRepository.EXPECT().GetClosedBillFromDB(ctx, billID.String()).Return(closedBill, nil)
+				mockRepository.EXPECT().GetClosedBillItemsFromDB(ctx, billID.String()).Return([]domain.Item{item}, nil)
+				mockRepository.EXPECT().GetBillEventsFromDB(ctx, billID.String()).Return(events, nil)
+			}
+
+			resp, err := s.GetBillAsOf(ctx, billID.String(), &GetBillAsOfRequest{At: tt.at})
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, resp.Status)
+				assert.Equal(t, tt.expectedTotal, resp.Total.Amount)
+				assert.Equal(t, "PO-1001", resp.ExternalRef)
+				assert.Equal(t, closedBill.Metadata, resp.Metadata)
+				assert.Equal(t, tt.expectedStatus == domain.BillClosed, resp.DueAt != nil)
+			}
+		})
+	}
+}
+
+func TestAmendBill(t *testing.T) {
+	owner := uuid.New()
+	admin := uuid.NewString()
+	itemID := uuid.New()
+	items := []domain.Item{{ID: itemID, Description: "Consulting", PricePerUnit: domain.Money{Amount: 20, Currency: "USD"}, Quantity: 1}}
+	amended := []AmendItem{{ID: itemID.String(), Description: "Consulting", PricePerUnit: domain.Money{Amount: 20, Currency: "USD"}, Quantity: 3}}
+
+	tests := []struct {
+		name          string
+		request       *AmendBillRequest
+		role          authn.Role
+		bill          *domain.Bill
+		lookupError   error
+		mockError     error
+		skipMockCalls bool
+		expectedCode  errs.ErrCode
+	}{
+		{
+			name:    "Success - Bill Amended By Admin",
+			request: &AmendBillRequest{RequestID: uuid.NewString(), Reason: "Undercounted hours", Items: amended},
+			role:    authn.RoleAdmin,
+			bill:    &domain.Bill{UserID: owner, Status: domain.BillClosed, Version: 1, Total: domain.Money{Amount: 20, Currency: "USD"}},
+		},
+		{
+			name:          "Failure - Missing Reason",
+			request:       &AmendBillRequest{Reason: " ", Items: amended},
+			role:          authn.RoleAdmin,
+			skipMockCalls: true,
+			expectedCode:  errs.InvalidArgument,
+		},
+		{
+			name:          "Failure - Not An Admin",
+			request:       &AmendBillRequest{Reason: "Undercounted hours", Items: amended},
+			role:          authn.RoleUser,
+			skipMockCalls: true,
+			expectedCode:  errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Bill Not Closed",
+			request:      &AmendBillRequest{Reason: "Undercounted hours", Items: amended},
+			role:         authn.RoleAdmin,
+			lookupError:  fmt.Errorf("bill not found: %w", billerr.ErrNotFound),
+			expectedCode: errs.NotFound,
+		},
+		{
+			name:         "Failure - Bill Cancelled",
+			request:      &AmendBillRequest{Reason: "Undercounted hours", Items: amended},
+			role:         authn.RoleAdmin,
+			bill:         &domain.Bill{UserID: owner, Status: domain.BillCancelled},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name:         "Failure - Version Already Amended",
+			request:      &AmendBillRequest{Reason: "Undercounted hours", Items: amended},
+			role:         authn.RoleAdmin,
+			bill:         &domain.Bill{UserID: owner, Status: domain.BillClosed, AmendedByID: &itemID},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name:         "Failure - No Changes",
+			request:      &AmendBillRequest{Reason: "Undercounted hours", Items: []AmendItem{{ID: itemID.String(), Description: "Consulting", PricePerUnit: domain.Money{Amount: 20, Currency: "USD"}, Quantity: 1}}},
+			role:         authn.RoleAdmin,
+			bill:         &domain.Bill{UserID: owner, Status: domain.BillClosed, Total: domain.Money{Amount: 20, Currency: "USD"}},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:         "Failure - Amended Concurrently",
+			request:      &AmendBillRequest{Reason: "Undercounted hours", Items: amended},
+			role:         authn.RoleAdmin,
+			bill:         &domain.Bill{UserID: owner, Status: domain.BillClosed, Total: domain.Money{Amount: 20, Currency: "USD"}},
+			mockError:    fmt.Errorf("Error amending bill: %w", billerr.New(billerr.ErrBillAmended, "bill was already amended", nil)),
+			expectedCode: errs.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			billID := uuid.New()
+			ctx := callerContext(admin, tt.role)
+
+			if !tt.skipMockCalls {
+				if tt.bill != nil {
+					tt.bill.ID = billID
+					tt.bill.TenantID = conf.DEFAULT_TENANT
+				}
+				mockRepository.EXPECT().GetClosedBillFromDB(ctx, billID.String()).Return(tt.bill, tt.lookupError)
+				if tt.lookupError == nil {
+					mockRepository.EXPECT().GetClosedBillItemsFromDB(ctx, billID.String()).Return(items, nil)
+				}
+
+				if tt.expectedCode == errs.OK || tt.mockError != nil {
+					// The new version links back to the amended one and records the admin as actor
+					mockExecution.EXPECT().
+						AmendBillWorkflow(ctx, gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, bill *domain.Bill, amendment *domain.Amendment, requestID string) error {
+							assert.Equal(t, billID, *bill.AmendsBillID)
+							assert.Equal(t, admin, amendment.ActorID)
+							assert.Equal(t, tt.request.RequestID, requestID)
+							return tt.mockError
+						})
+				}
+			}
+
+			resp, err := s.AmendBill(ctx, billID.String(), tt.request)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, resp.Bill.Version)
+				assert.Equal(t, domain.AdjustmentDebit, resp.Amendment.Adjustment.Type)
+				assert.Equal(t, domain.MinorUnit(40), resp.Amendment.Adjustment.Amount.Amount)
+			}
+		})
+	}
+}
+
+func TestListBillAmendments(t *testing.T) {
+	owner := uuid.New()
+	billID := uuid.New()
+	amendments := []domain.Amendment{{OriginalBillID: billID, AmendedBillID: uuid.New(), Version: 2}}
+
+	tests := []struct {
+		name         string
+		callerID     string
+		expectedCode errs.ErrCode
+	}{
+		{
+			name:     "Success - Amendments Listed For Owner",
+			callerID: owner.String(),
+		},
+		{
+			name:         "Failure - Bill Of Another User",
+			callerID:     uuid.NewString(),
+			expectedCode: errs.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(tt.callerID, authn.RoleUser)
+			mockRepository.EXPECT().GetClosedBillFromDB(ctx, billID.String()).Return(&domain.Bill{ID: billID, UserID: owner, Status: domain.BillClosed}, nil)
+			if tt.expectedCode == errs.OK {
+				mockRepository.EXPECT().GetBillAmendmentsFromDB(ctx, billID.String()).Return(amendments, nil)
+			}
+
+			resp, err := s.ListBillAmendments(ctx, billID.String())
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, amendments, resp.Amendments)
+			}
+		})
+	}
+}
+
+func TestBillOwnership(t *testing.T) {
+	owner := uuid.New()
+	openBill := &domain.Bill{ID: uuid.New(), UserID: owner, Status: domain.BillOpen}
+	closedBill := &domain.Bill{ID: uuid.New(), UserID: owner, Status: domain.BillClosed}
+
+	item := domain.Money{Amount: 10, Currency: "USD"}
+
+	tests := []struct {
+		name        string
+		callerID    string
+		role        authn.Role
+		call        func(s *Service, ctx context.Context) error
+		setup       func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution)
+		expectError bool
+	}{
+		{
+			name:     "Owner Can Get Open Bill",
+			callerID: owner.String(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(nil)
+				exec.EXPECT().GetBillQuery(gomock.Any(), openBill.ID.String(), gomock.Any()).Return(nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.GetBill(ctx, openBill.ID.String())
+				return err
+			},
+			expectError: false,
+		},
+		{
+			name:     "Other User Cannot Get Open Bill",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.GetBill(ctx, openBill.ID.String())
+				return err
+			},
+			expectError: true,
+		},
+		{
+			name:     "Other User Cannot Get Closed Bill",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), closedBill.ID.String()).Return(nil, assert.AnError)
+				repo.EXPECT().GetClosedBillFromDB(gomock.Any(), closedBill.ID.String()).Return(closedBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.GetBill(ctx, closedBill.ID.String())
+				return err
+			},
+			expectError: true,
+		},
+		{
+			name:     "Other User Cannot Add Line Item",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.AddLineItemToBill(ctx, openBill.ID.String(), &AddLineItemRequest{ID: uuid.NewString(), Quantity: 1, PricePerUnit: item})
+				return err
+			},
+			expectError: true,
+		},
+		{
+			name:     "Other User Cannot Remove Line Item",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.RemoveLineItemFromBill(ctx, openBill.ID.String(), &RemoveLineItemRequest{ID: uuid.NewString(), Quantity: 1, PricePerUnit: item})
+				return err
+			},
+			expectError: true,
+		},
+		{
+			name:     "Other User Cannot Close Bill",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectError: true,
+		},
+		{
+			name:     "Admin Can Close Any Bill",
+			callerID: uuid.NewString(),
+			role:     authn.RoleAdmin,
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(nil)
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(&domain.BillingProfile{}, nil)
+				exec.EXPECT().CloseBillUpdate(gomock.Any(), openBill.ID.String(), gomock.Any()).Return(closedBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectError: false,
+		},
+		{
+			name:     "User Cannot Create Bill For Another User",
+			callerID: uuid.NewString(),
+			role:     authn.RoleUser,
+			setup:    func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CreateBill(ctx, &CreateBillRequest{UserID: owner.String(), Currency: "USD"})
+				return err
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			tt.setup(mockRepository, mockExecution)
+
+			err := tt.call(s, callerContext(tt.callerID, tt.role))
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	owner := uuid.New()
+	openBill := &domain.Bill{ID: uuid.New(), UserID: owner, Status: domain.BillOpen}
+	closedBill := &domain.Bill{ID: uuid.New(), UserID: owner, Status: domain.BillClosed}
+	notFound := fmt.Errorf("bill not found: %w", billerr.ErrNotFound)
+
+	addItem := &AddLineItemRequest{ID: uuid.NewString(), Quantity: 1, PricePerUnit: domain.Money{Amount: 10, Currency: "USD"}}
+
+	tests := []struct {
+		name         string
+		call         func(s *Service, ctx context.Context) error
+		setup        func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution)
+		expectedCode errs.ErrCode
+	}{
+		{
+			name:  "Invalid Currency",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CreateBill(ctx, &CreateBillRequest{UserID: owner.String(), Currency: "EUR"})
+				return err
+			},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name: "Unregistered Customer",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(nil, notFound)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CreateBill(ctx, &CreateBillRequest{UserID: owner.String(), Currency: "USD"})
+				return err
+			},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name: "Bill Already Exists",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(&domain.BillingProfile{}, nil)
+				repo.EXPECT().GetCreditLimit(gomock.Any(), owner.String()).Return(nil, nil)
+				exec.EXPECT().CreateBillWorkflow(gomock.Any(), gomock.Any()).Return(fmt.Errorf("start: %w", billerr.ErrWorkflowExists))
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CreateBill(ctx, &CreateBillRequest{UserID: owner.String(), Currency: "USD"})
+				return err
+			},
+			expectedCode: errs.AlreadyExists,
+		},
+		{
+			name: "Get Unknown Bill",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(nil, notFound)
+				repo.EXPECT().GetClosedBillFromDB(gomock.Any(), openBill.ID.String()).Return(nil, notFound)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.GetBill(ctx, openBill.ID.String())
+				return err
+			},
+			expectedCode: errs.NotFound,
+		},
+		{
+			name: "Add Item To Unknown Bill",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(nil, notFound)
+				repo.EXPECT().GetClosedBillFromDB(gomock.Any(), openBill.ID.String()).Return(nil, notFound)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.AddLineItemToBill(ctx, openBill.ID.String(), addItem)
+				return err
+			},
+			expectedCode: errs.NotFound,
+		},
+		{
+			name: "Add Item To Closed Bill",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), closedBill.ID.String()).Return(nil, notFound)
+				repo.EXPECT().GetClosedBillFromDB(gomock.Any(), closedBill.ID.String()).Return(closedBill, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.AddLineItemToBill(ctx, closedBill.ID.String(), addItem)
+				return err
+			},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name: "Add Item To Closing Bill",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(billerr.ErrBillClosing)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.AddLineItemToBill(ctx, openBill.ID.String(), addItem)
+				return err
+			},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name: "Duplicate Close Request",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(nil)
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(&domain.BillingProfile{}, nil)
+				exec.EXPECT().CloseBillUpdate(gomock.Any(), openBill.ID.String(), gomock.Any()).
+					Return(nil, fmt.Errorf("update: %w", billerr.New(billerr.ErrDuplicateRequest, "duplicate close request ignored", nil)))
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectedCode: errs.AlreadyExists,
+		},
+		{
+			name: "Close Bill Concurrently Closed",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(nil)
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(&domain.BillingProfile{}, nil)
+				exec.EXPECT().CloseBillUpdate(gomock.Any(), openBill.ID.String(), gomock.Any()).
+					Return(nil, billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil))
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name: "Close Bill Database Unavailable",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(openBill, nil)
+				exec.EXPECT().IsWorkflowRunning(gomock.Any(), openBill.ID.String()).Return(nil)
+				repo.EXPECT().GetBillingProfile(gomock.Any(), owner.String()).Return(&domain.BillingProfile{}, nil)
+				exec.EXPECT().CloseBillUpdate(gomock.Any(), openBill.ID.String(), gomock.Any()).
+					Return(nil, billerr.New(billerr.ErrTransient, "Error inserting/updating db", nil))
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectedCode: errs.Unavailable,
+		},
+		{
+			name: "Close Bill Of Another User",
+			setup: func(repo *mock_billing.MockRepository, exec *mock_billing.MockExecution) {
+				other := &domain.Bill{ID: openBill.ID, UserID: uuid.New(), Status: domain.BillOpen}
+				repo.EXPECT().GetOpenBillFromDB(gomock.Any(), openBill.ID.String()).Return(other, nil)
+			},
+			call: func(s *Service, ctx context.Context) error {
+				_, err := s.CloseBill(ctx, openBill.ID.String(), &CloseBillRequest{})
+				return err
+			},
+			expectedCode: errs.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExecution := mock_billing.NewMockExecution(ctrl)
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+
+			s := &Service{
+				Execution:  mockExecution,
+				Repository: mockRepository,
+			}
+
+			tt.setup(mockRepository, mockExecution)
+
+			err := tt.call(s, callerContext(owner.String(), authn.RoleUser))
+
+			var apiErr *errs.Error
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, tt.expectedCode, apiErr.Code)
+			}
+		})
+	}
+}
+
+func TestGrantWalletCredit(t *testing.T) {
+	customer := uuid.New()
+	credit := domain.Money{Amount: 5000, Currency: "USD"}
+	notFound := fmt.Errorf("customer not found: %w", billerr.ErrNotFound)
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		amount       domain.Money
+		profileErr   error
+		grantErr     error
+		expectedCode errs.ErrCode
+	}{
+		{
+			name:   "Success - Credit Granted By Admin",
+			role:   authn.RoleAdmin,
+			amount: credit,
+		},
+		{
+			name:         "Failure - Not An Admin",
+			role:         authn.RoleUser,
+			amount:       credit,
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Currency Not Enabled For Tenant",
+			role:         authn.RoleAdmin,
+			amount:       domain.Money{Amount: 5000, Currency: "EUR"},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:         "Failure - Unregistered Customer",
+			role:         authn.RoleAdmin,
+			amount:       credit,
+			profileErr:   notFound,
+			expectedCode: errs.FailedPrecondition,
+		},
+		{
+			name:         "Failure - Request ID Used For Another Grant",
+			role:         authn.RoleAdmin,
+			amount:       credit,
+			grantErr:     fmt.Errorf("request ID reused: %w", billerr.ErrDuplicateRequest),
+			expectedCode: errs.AlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			adminID := uuid.NewString()
+			ctx := callerContext(adminID, tt.role)
+			req := &GrantWalletCreditRequest{Amount: tt.amount, Reason: "Prepayment"}
+
+			if tt.role == authn.RoleAdmin && tt.amount.Currency == "USD" {
+				mockRepository.EXPECT().GetBillingProfile(ctx, customer.String()).Return(&domain.BillingProfile{}, tt.profileErr)
+			}
+			if tt.role == authn.RoleAdmin && tt.amount.Currency == "USD" && tt.profileErr == nil {
+				// The grant is recorded with the admin who made it
+				mockRepository.EXPECT().
+					AddWalletGrantToDB(ctx, gomock.Cond(func(e *domain.WalletEntry) bool {
+						return e.UserID == customer && e.Type == domain.WalletGrant && e.Amount == credit &&
+							e.ActorID == adminID && e.RequestID != ""
+					})).
+					Return(tt.grantErr)
+			}
+			if tt.expectedCode == errs.OK {
+				mockRepository.EXPECT().GetWalletFromDB(ctx, customer.String()).Return(&domain.Wallet{
+					UserID:   customer,
+					Balances: []domain.Money{credit},
+				}, nil)
+			}
+
+			resp, err := s.GrantWalletCredit(ctx, customer.String(), req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []domain.Money{credit}, resp.Balances)
+			}
+		})
+	}
+}
+
+func TestGetWallet(t *testing.T) {
+	customer := uuid.New()
+	wallet := &domain.Wallet{
+		UserID:   customer,
+		Balances: []domain.Money{{Amount: 1000, Currency: "USD"}},
+		Entries:  []domain.WalletEntry{{UserID: customer, Type: domain.WalletGrant, Amount: domain.Money{Amount: 1000, Currency: "USD"}}},
+	}
+
+	tests := []struct {
+		name         string
+		callerID     string
+		role         authn.Role
+		expectedCode errs.ErrCode
+	}{
+		{
+			name:     "Success - Own Wallet",
+			callerID: customer.String(),
+			role:     authn.RoleUser,
+		},
+		{
+			name:     "Success - Admin Reads Any Wallet",
+			callerID: uuid.NewString(),
+			role:     authn.RoleAdmin,
+		},
+		{
+			name:         "Failure - Wallet Of Another User",
+			callerID:     uuid.NewString(),
+			role:         authn.RoleUser,
+			expectedCode: errs.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(tt.callerID, tt.role)
+			if tt.expectedCode == errs.OK {
+				mockRepository.EXPECT().GetWalletFromDB(ctx, customer.String()).Return(wallet, nil)
+			}
+
+			resp, err := s.GetWallet(ctx, customer.String())
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, wallet.Balances, resp.Balances)
+				assert.Equal(t, wallet.Entries, resp.Entries)
+			}
+		})
+	}
+}
+
+func TestGetTrialBalance(t *testing.T) {
+	totals := []domain.AccountTotal{
+		{AccountCode: domain.AccountReceivable.Code, Debit: 1100, Credit: 1100},
+		{AccountCode: domain.AccountCash.Code, Debit: 1100},
+		{AccountCode: domain.AccountSales.Code, Credit: 1000},
+		{AccountCode: domain.AccountTaxPayable.Code, Credit: 100},
+	}
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		req          GetTrialBalanceRequest
+		expectedCode errs.ErrCode
+	}{
+		{
+			name: "Success",
+			role: authn.RoleAdmin,
+			req:  GetTrialBalanceRequest{Currency: "USD", AsOf: "2025-01-01T12:00:00Z"},
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			req:          GetTrialBalanceRequest{Currency: "USD"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Invalid Currency",
+			role:         authn.RoleAdmin,
+			req:          GetTrialBalanceRequest{Currency: "XYZ"},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				asOf := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+				mockRepository.EXPECT().GetTrialBalanceFromDB(ctx, "USD", asOf).Return(totals, nil)
+			}
+
+			resp, err := s.GetTrialBalance(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, resp.TrialBalance.Balanced)
+				assert.Equal(t, domain.MinorUnit(2200), resp.TrialBalance.TotalDebit)
+				assert.Len(t, resp.TrialBalance.Lines, len(totals))
+			}
+		})
+	}
+}
+
+func TestGetAccountStatement(t *testing.T) {
+	opening := domain.AccountTotal{AccountCode: domain.AccountReceivable.Code, Debit: 500}
+	lines := []domain.StatementLine{
+		{EntryID: uuid.New(), Source: domain.JournalBillClosed, Debit: 1100},
+		{EntryID: uuid.New(), Source: domain.JournalPayment, Credit: 1100},
+	}
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		code         string
+		req          GetAccountStatementRequest
+		expectedCode errs.ErrCode
+	}{
+		{
+			name: "Success",
+			role: authn.RoleAdmin,
+			code: domain.AccountReceivable.Code,
+			req:  GetAccountStatementRequest{Currency: "USD", From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			code:         domain.AccountReceivable.Code,
+			req:          GetAccountStatementRequest{Currency: "USD"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Unknown Account",
+			role:         authn.RoleAdmin,
+			code:         "9999",
+			req:          GetAccountStatementRequest{Currency: "USD"},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				mockRepository.EXPECT().GetAccountPostingsFromDB(ctx, tt.code, "USD", from, to).Return(opening, lines, nil)
+			}
+
+			resp, err := s.GetAccountStatement(ctx, tt.code, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, domain.MinorUnit(500), resp.Statement.OpeningBalance)
+				assert.Equal(t, domain.MinorUnit(1600), resp.Statement.Lines[0].Balance)
+				assert.Equal(t, domain.MinorUnit(500), resp.Statement.ClosingBalance)
+			}
+		})
+	}
+}
+
+func TestExportLedger(t *testing.T) {
+	billID := uuid.New()
+	entry := domain.NewJournalEntry("default", domain.JournalPayment, billID.String(), &billID, "Payment received", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	entry.Post(domain.AccountCash, domain.Money{Amount: 1100, Currency: "USD"})
+	entry.Post(domain.AccountReceivable, domain.Money{Amount: -1100, Currency: "USD"})
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		req          ExportLedgerRequest
+		expectedCode errs.ErrCode
+		contentType  string
+	}{
+		{
+			name:        "Success - CSV By Default",
+			role:        authn.RoleAdmin,
+			req:         ExportLedgerRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			contentType: "text/csv",
+		},
+		{
+			name:        "Success - Ledger",
+			role:        authn.RoleAdmin,
+			req:         ExportLedgerRequest{Format: "ledger", From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			contentType: "text/plain",
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			req:          ExportLedgerRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Unknown Format",
+			role:         authn.RoleAdmin,
+			req:          ExportLedgerRequest{Format: "xlsx", From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				mockRepository.EXPECT().ListJournalEntriesFromDB(ctx, from, to).Return([]domain.JournalEntry{*entry}, nil)
+			}
+
+			resp, err := s.ExportLedger(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.contentType, resp.ContentType)
+				assert.Contains(t, resp.Content, entry.SourceKey)
+				assert.Contains(t, resp.Content, "11.00")
+			}
+		})
+	}
+}
+
+func TestReportItems(t *testing.T) {
+	totals := []domain.ItemTotal{
+		{Category: "usage", Currency: "USD", Lines: 2, Quantity: 30, Amount: 1500},
+		{Category: "", Currency: "GEL", Lines: 1, Quantity: 1, Amount: 2750},
+	}
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		req          ItemReportRequest
+		expectedCode errs.ErrCode
+	}{
+		{
+			name: "Success",
+			role: authn.RoleAdmin,
+			req:  ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Category: "usage", Attributes: []string{"region=eu"}},
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			req:          ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Invalid Attribute",
+			role:         authn.RoleAdmin,
+			req:          ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Attributes: []string{"region"}},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				filter := domain.ItemFilter{
+					From:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:         time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+					Category:   "usage",
+					Attributes: map[string]string{"region": "eu"},
+				}
+				mockRepository.EXPECT().GetItemTotalsFromDB(ctx, filter).Return(totals, nil)
+			}
+
+			resp, err := s.ReportItems(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, totals, resp.Totals)
+			}
+		})
+	}
+}
+
+func TestListBills(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	closedAt := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	bills := []domain.Bill{
+		{ID: uuid.New(), UserID: userID, Status: domain.BillOpen, Total: domain.Money{Currency: "USD"}, Version: 1, ExternalRef: "PO-1002"},
+		{
+			ID: uuid.New(), UserID: userID, Status: domain.BillClosed, Version: 1, ExternalRef: "PO-1001",
+			Total: domain.Money{Amount: 1000, Currency: "USD"}, Tax: domain.Money{Amount: 100, Currency: "USD"},
+			Metadata: map[string]string{"cost_center": "CC-42"}, ClosedAt: closedAt,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		callerID       uuid.UUID
+		role           authn.Role
+		req            ListBillsRequest
+		expectedFilter *domain.BillFilter
+		expectedCode   errs.ErrCode
+	}{
+		{
+			name:           "Success - Users List Their Own Bills",
+			callerID:       userID,
+			role:           authn.RoleUser,
+			req:            ListBillsRequest{Metadata: []string{"cost_center=CC-42"}},
+			expectedFilter: &domain.BillFilter{UserID: &userID, Metadata: map[string]string{"cost_center": "CC-42"}, Limit: defaultListLimit},
+		},
+		{
+			name:           "Success - Admins List Every Bill",
+			callerID:       otherID,
+			role:           authn.RoleAdmin,
+			req:            ListBillsRequest{ExternalRef: "PO-1001", Status: "BillClosed", Limit: 10},
+			expectedFilter: &domain.BillFilter{ExternalRef: "PO-1001", Status: domain.BillClosed, Limit: 10},
+		},
+		{
+			name:         "Failure - Bills Of Another User",
+			callerID:     otherID,
+			role:         authn.RoleUser,
+			req:          ListBillsRequest{UserID: userID.String()},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Invalid Metadata",
+			callerID:     userID,
+			role:         authn.RoleUser,
+			req:          ListBillsRequest{Metadata: []string{"cost_center"}},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(tt.callerID.String(), tt.role)
+			if tt.expectedFilter != nil {
+				mockRepository.EXPECT().ListBillsFromDB(ctx, *tt.expectedFilter).Return(bills, nil)
+			}
+
+			resp, err := s.ListBills(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.Len(t, resp.Bills, 2) {
+				// Open bills are listed without a total, which their workflow keeps until they close
+				assert.Equal(t, "PO-1002", resp.Bills[0].ExternalRef)
+				assert.Nil(t, resp.Bills[0].Total)
+				assert.Nil(t, resp.Bills[0].ClosedAt)
+				assert.Equal(t, &bills[1].Total, resp.Bills[1].Total)
+				assert.Equal(t, &closedAt, resp.Bills[1].ClosedAt)
+				assert.Equal(t, bills[1].Metadata, resp.Bills[1].Metadata)
+			}
+		})
+	}
+}
+
+func TestSearchBills(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	itemID := uuid.New()
+	results := []domain.SearchResult{
+		{
+			Bill: domain.Bill{
+				ID: uuid.New(), UserID: userID, Status: domain.BillClosed, Version: 1,
+				Total: domain.Money{Amount: 1000, Currency: "USD"}, ExternalRef: "PO-1001",
+			},
+			Rank: 0.6,
+			Items: []domain.ItemMatch{
+				{ItemID: itemID, Description: "Annual support", Highlight: "Annual <b>support</b>", Rank: 0.6},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		callerID       uuid.UUID
+		role           authn.Role
+		req            SearchBillsRequest
+		expectedFilter *domain.SearchFilter
+		expectedCode   errs.ErrCode
+	}{
+		{
+			name:           "Success - Users Search Their Own Bills",
+			callerID:       userID,
+			role:           authn.RoleUser,
+			req:            SearchBillsRequest{Query: " support ", From: "2025-01-01T00:00:00Z"},
+			expectedFilter: &domain.SearchFilter{Query: "support", UserID: &userID, From: &from, Limit: defaultListLimit},
+		},
+		{
+			name:           "Success - Admins Search Every Bill",
+			callerID:       otherID,
+			role:           authn.RoleAdmin,
+			req:            SearchBillsRequest{Query: "support", Limit: 10, Offset: 10},
+			expectedFilter: &domain.SearchFilter{Query: "support", Limit: 10, Offset: 10},
+		},
+		{
+			name:         "Failure - Bills Of Another User",
+			callerID:     otherID,
+			role:         authn.RoleUser,
+			req:          SearchBillsRequest{Query: "support", UserID: userID.String()},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Empty Query",
+			callerID:     userID,
+			role:         authn.RoleUser,
+			req:          SearchBillsRequest{},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(tt.callerID.String(), tt.role)
+			if tt.expectedFilter != nil {
+				mockRepository.EXPECT().SearchBillsFromDB(ctx, *tt.expectedFilter).Return(results, nil)
+			}
+
+			resp, err := s.SearchBills(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.Len(t, resp.Results, 1) {
+				assert.Equal(t, "PO-1001", resp.Results[0].Bill.ExternalRef)
+				assert.Equal(t, &results[0].Bill.Total, resp.Results[0].Bill.Total)
+				assert.Equal(t, 0.6, resp.Results[0].Rank)
+				assert.Equal(t, results[0].Items, resp.Results[0].Items)
+			}
+		})
+	}
+}
+
+func TestReportRevenue(t *testing.T) {
+	refreshedAt := time.Date(2025, 2, 1, 0, 15, 0, 0, time.UTC)
+	days := []domain.DailyRevenue{
+		{Day: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Currency: "USD", Bills: 2, Subtotal: 1000, Tax: 100},
+		{Day: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Currency: "GEL", Bills: 1, Subtotal: 2750, Tax: 275},
+	}
+
+	tests := []struct {
+		name             string
+		role             authn.Role
+		req              RevenueReportRequest
+		expectedCurrency string
+		expectedPeriods  int
+		expectedCode     errs.ErrCode
+	}{
+		{
+			name:             "Success - Tenant Reporting Currency",
+			role:             authn.RoleAdmin,
+			req:              RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCurrency: "USD",
+			expectedPeriods:  2,
+		},
+		{
+			name:             "Success - Monthly In GEL",
+			role:             authn.RoleAdmin,
+			req:              RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Interval: "month", Currency: "GEL"},
+			expectedCurrency: "GEL",
+			expectedPeriods:  1,
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			req:          RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Invalid Currency",
+			role:         authn.RoleAdmin,
+			req:          RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Currency: "EUR"},
+			expectedCode: errs.InvalidArgument,
+		},
+		{
+			name:         "Failure - Invalid Interval",
+			role:         authn.RoleAdmin,
+			req:          RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Interval: "week"},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				mockRepository.EXPECT().GetDailyRevenueFromDB(ctx, from, to).Return(days, nil)
+				mockRepository.EXPECT().GetAnalyticsRefreshFromDB(ctx).Return(&refreshedAt, nil)
+			}
+
+			resp, err := s.ReportRevenue(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCurrency, resp.Currency)
+				assert.Len(t, resp.Periods, tt.expectedPeriods)
+				assert.Equal(t, 3, resp.Summary.Bills)
+				assert.Len(t, resp.Summary.ByCurrency, 2)
+				assert.Equal(t, &refreshedAt, resp.RefreshedAt)
+			}
+		})
+	}
+}
+
+func TestReportRevenueByCategory(t *testing.T) {
+	totals := []domain.ItemTotal{
+		{Category: "usage", Currency: "USD", Lines: 2, Quantity: 30, Amount: 1500},
+		{Category: "usage", Currency: "GEL", Lines: 1, Quantity: 10, Amount: 2750},
+		{Category: "", Currency: "USD", Lines: 1, Quantity: 1, Amount: 500},
+	}
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		req          CategoryReportRequest
+		expectedCode errs.ErrCode
+	}{
+		{
+			name: "Success",
+			role: authn.RoleAdmin,
+			req:  CategoryReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+		},
+		{
+			name:         "Failure - Not Admin",
+			role:         authn.RoleUser,
+			req:          CategoryReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"},
+			expectedCode: errs.PermissionDenied,
+		},
+		{
+			name:         "Failure - Empty Period",
+			role:         authn.RoleAdmin,
+			req:          CategoryReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-01-01T00:00:00Z"},
+			expectedCode: errs.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.expectedCode == errs.OK {
+				from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				mockRepository.EXPECT().GetCategoryRevenueFromDB(ctx, from, to).Return(totals, nil)
+				mockRepository.EXPECT().GetAnalyticsRefreshFromDB(ctx).Return(nil, nil)
+			}
+
+			resp, err := s.ReportRevenueByCategory(ctx, &tt.req)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "USD", resp.Currency)
+				if assert.Len(t, resp.Categories, 2) {
+					assert.Equal(t, domain.ItemTotal{Category: "usage", Currency: "USD", Lines: 3, Quantity: 40, Amount: 2500}, resp.Categories[0])
+					assert.Equal(t, "", resp.Categories[1].Category)
+				}
+				assert.Nil(t, resp.RefreshedAt)
+			}
+		})
+	}
+}
+
+func TestReportBillCounts(t *testing.T) {
+	counts := []domain.BillCount{
+		{Status: domain.BillOpen, Currency: "USD", Bills: 4},
+		{Status: domain.BillClosed, Currency: "USD", Bills: 10},
+		{Status: domain.BillClosed, Currency: "GEL", Bills: 3},
+		{Status: domain.BillCancelled, Currency: "GEL", Bills: 1},
+	}
+
+	tests := []struct {
+		name         string
+		role         authn.Role
+		mockError    error
+		expectedCode errs.ErrCode
+	}{
+		{name: "Success", role: authn.RoleAdmin},
+		{name: "Failure - Not Admin", role: authn.RoleUser, expectedCode: errs.PermissionDenied},
+		{name: "Failure - DB Error", role: authn.RoleAdmin, mockError: assert.AnError, expectedCode: errs.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepository := mock_billing.NewMockRepository(ctrl)
+			s := &Service{Repository: mockRepository}
+
+			ctx := callerContext(uuid.NewString(), tt.role)
+			if tt.role == authn.RoleAdmin {
+				mockRepository.EXPECT().GetBillCountsFromDB(ctx).Return(counts, tt.mockError)
+			}
+			if tt.expectedCode == errs.OK {
+				mockRepository.EXPECT().GetAnalyticsRefreshFromDB(ctx).Return(nil, nil)
+			}
+
+			resp, err := s.ReportBillCounts(ctx)
+
+			if tt.expectedCode != errs.OK {
+				var apiErr *errs.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, tt.expectedCode, apiErr.Code)
+				}
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 4, resp.Open)
+				assert.Equal(t, 13, resp.Closed)
+				assert.Equal(t, 1, resp.Cancelled)
+				assert.Equal(t, counts, resp.Counts)
+			}
+		})
+	}
+}
@@ -3,10 +3,19 @@ package billing
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"encore.dev/beta/errs"
 	"encore.dev/storage/sqldb"
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/billerr"
 	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
+	"github.com/vvvakho/feezy/billing/workflows"
+	"github.com/vvvakho/feezy/customers"
 )
 
 var BillsDB = sqldb.NewDatabase("bills", sqldb.DatabaseConfig{
@@ -22,6 +31,11 @@ func NewRepo() (*Repo, error) {
 }
 
 func (r *Repo) GetOpenBillFromDB(ctx context.Context, id string) (*domain.Bill, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Start a new transaction
 	tx, err := r.DB.Begin(ctx)
 	if err != nil {
@@ -36,18 +50,19 @@ func (r *Repo) GetOpenBillFromDB(ctx context.Context, id string) (*domain.Bill,
 	}
 
 	query := `
-		SELECT id, user_id, currency, status, created_at, updated_at
+		SELECT id, tenant_id, user_id, currency, status, created_at, updated_at
 		FROM open_bills
-		WHERE id = $1;
+		WHERE id = $1 AND tenant_id = $2;
 	`
 	var bill domain.Bill
-	row := tx.QueryRow(ctx, query, id)
+	row := tx.QueryRow(ctx, query, id, tenantID)
 
 	err = row.Scan(
 		&bill.ID,
+		&bill.TenantID,
 		&bill.UserID,
-		&bill.Status,
 		&bill.Total.Currency,
+		&bill.Status,
 		&bill.CreatedAt,
 		&bill.UpdatedAt,
 	)
@@ -55,7 +70,7 @@ func (r *Repo) GetOpenBillFromDB(ctx context.Context, id string) (*domain.Bill,
 	// In case of errors the deferred rollback is activated
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("bill with ID %s not found in open_bills", id)
+			return nil, fmt.Errorf("bill with ID %s not found in open_bills: %w", id, billerr.ErrNotFound)
 		}
 		return nil, fmt.Errorf("error querying open_bills: %v", err)
 	}
@@ -68,7 +83,59 @@ func (r *Repo) GetOpenBillFromDB(ctx context.Context, id string) (*domain.Bill,
 	return &bill, nil
 }
 
+// GetOpenBillByUserFromDB looks up the open bill held by a user in the given currency.
+// Returns a nil bill without an error if the user has no such open bill.
+func (r *Repo) GetOpenBillByUserFromDB(ctx context.Context, userID string, currency string) (*domain.Bill, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, tenant_id, user_id, currency, status, created_at, updated_at, external_ref, metadata
+		FROM open_bills
+		WHERE user_id = $1 AND currency = $2 AND tenant_id = $3
+		ORDER BY created_at
+		LIMIT 1;
+	`
+
+	var bill domain.Bill
+	var externalRef sql.NullString
+	var metadata []byte
+	err = r.DB.QueryRow(ctx, query, userID, currency, tenantID).Scan(
+		&bill.ID,
+		&bill.TenantID,
+		&bill.UserID,
+		&bill.Total.Currency,
+		&bill.Status,
+		&bill.CreatedAt,
+		&bill.UpdatedAt,
+		&externalRef,
+		&metadata,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying open_bills: %v", err)
+	}
+
+	bill.ExternalRef = externalRef.String
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &bill.Metadata); err != nil {
+			return nil, fmt.Errorf("error decoding bill metadata: %v", err)
+		}
+	}
+
+	return &bill, nil
+}
+
 func (r *Repo) GetClosedBillFromDB(ctx context.Context, id string) (*domain.Bill, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Start a new transaction
 	tx, err := r.DB.Begin(ctx)
 	if err != nil {
@@ -83,30 +150,101 @@ func (r *Repo) GetClosedBillFromDB(ctx context.Context, id string) (*domain.Bill
 	}
 
 	query := `
-		SELECT id, user_id, status, total_amount, currency, created_at, updated_at, closed_at
+		SELECT id, tenant_id, user_id, status, total_amount, currency, tax_rate, tax_amount,
+			created_at, updated_at, closed_at, billing_profile, cancel_reason, cancelled_by, cancelled_at,
+			version, amends_bill_id,
+			(SELECT nb.id FROM closed_bills nb WHERE nb.amends_bill_id = closed_bills.id) AS amended_by_id,
+			due_at, collection_status, credit_applied, external_ref, metadata
 		FROM closed_bills
-		WHERE id = $1;
+		WHERE id = $1 AND tenant_id = $2;
 	`
 
 	var bill domain.Bill
-	row := tx.QueryRow(ctx, query, id)
+	var billingProfile []byte
+	var cancelReason, cancelledBy sql.NullString
+	var cancelledAt sql.NullTime
+	var amendsBillID, amendedByID uuid.NullUUID
+	var dueAt sql.NullTime
+	var collectionStatus sql.NullString
+	var externalRef sql.NullString
+	var metadata []byte
+	row := tx.QueryRow(ctx, query, id, tenantID)
 
 	err = row.Scan(
 		&bill.ID,
+		&bill.TenantID,
 		&bill.UserID,
 		&bill.Status,
 		&bill.Total.Amount,
 		&bill.Total.Currency,
+		&bill.TaxRate,
+		&bill.Tax.Amount,
 		&bill.CreatedAt,
 		&bill.UpdatedAt,
 		&bill.ClosedAt,
+		&billingProfile,
+		&cancelReason,
+		&cancelledBy,
+		&cancelledAt,
+		&bill.Version,
+		&amendsBillID,
+		&amendedByID,
+		&dueAt,
+		&collectionStatus,
+		&bill.CreditApplied.Amount,
+		&externalRef,
+		&metadata,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("bill with ID %s not found in closed_bills", id)
+			return nil, fmt.Errorf("bill with ID %s not found in closed_bills: %w", id, billerr.ErrNotFound)
 		}
 		return nil, fmt.Errorf("error querying closed_bills: %v", err)
 	}
+	bill.Tax.Currency = bill.Total.Currency
+	bill.CreditApplied.Currency = bill.Total.Currency
+
+	bill.ExternalRef = externalRef.String
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &bill.Metadata); err != nil {
+			return nil, fmt.Errorf("error decoding bill metadata: %v", err)
+		}
+	}
+
+	// Bills closed before billing profiles were introduced carry no snapshot
+	if len(billingProfile) > 0 {
+		if err := json.Unmarshal(billingProfile, &bill.BillingProfile); err != nil {
+			return nil, fmt.Errorf("error decoding billing profile: %v", err)
+		}
+	}
+
+	// Only cancelled bills carry a cancellation record
+	if cancelReason.Valid {
+		bill.Cancellation = &domain.Cancellation{
+			Reason:      cancelReason.String,
+			ActorID:     cancelledBy.String,
+			CancelledAt: cancelledAt.Time,
+		}
+	}
+
+	// Link the bill to the versions it replaces and is replaced by, if it was amended
+	if amendsBillID.Valid {
+		bill.AmendsBillID = &amendsBillID.UUID
+	}
+	if amendedByID.Valid {
+		bill.AmendedByID = &amendedByID.UUID
+	}
+
+	// Only bills closed with payment terms are due
+	if dueAt.Valid {
+		bill.DueAt = &dueAt.Time
+		bill.Collection = domain.CollectionStatus(collectionStatus.String)
+
+		bill.LateFees, err = getLateFees(ctx, tx, bill.ID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// In the absence of errors, commit the transaction
 	if err := tx.Commit(); err != nil {
@@ -116,12 +254,66 @@ func (r *Repo) GetClosedBillFromDB(ctx context.Context, id string) (*domain.Bill
 	return &bill, nil
 }
 
+// GetBillingProfile reads the current billing profile of a customer from the customers service,
+// which owns the customers schema. Returns an error if no customer exists for the given user ID.
+func (r *Repo) GetBillingProfile(ctx context.Context, userID string) (*domain.BillingProfile, error) {
+	customer, err := getCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.BillingProfile{
+		Name: customer.BillingName,
+		Address: domain.Address{
+			Line1:      customer.Address.Line1,
+			Line2:      customer.Address.Line2,
+			City:       customer.Address.City,
+			PostalCode: customer.Address.PostalCode,
+			Country:    customer.Address.Country,
+		},
+		TaxID:             customer.TaxID,
+		PreferredCurrency: customer.PreferredCurrency,
+		Locale:            customer.Locale,
+	}, nil
+}
+
+// Look up the customer's own credit limit from the customers service, kept in their preferred currency.
+// Returns nil if the customer has none.
+func (r *Repo) GetCreditLimit(ctx context.Context, userID string) (*domain.Money, error) {
+	customer, err := getCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.CreditLimit <= 0 {
+		return nil, nil
+	}
+	return &domain.Money{Amount: domain.MinorUnit(customer.CreditLimit), Currency: customer.PreferredCurrency}, nil
+}
+
+// Fetch a customer through the customers API, under the caller and tenant of the request.
+func getCustomer(ctx context.Context, userID string) (*customers.Customer, error) {
+	customer, err := customers.GetCustomer(ctx, userID)
+	if err != nil {
+		if errs.Code(err) == errs.NotFound {
+			return nil, fmt.Errorf("customer with ID %s not found: %w", userID, billerr.ErrNotFound)
+		}
+		return nil, fmt.Errorf("error querying customers: %v", err)
+	}
+	return customer, nil
+}
+
 func (r *Repo) GetClosedBillItemsFromDB(ctx context.Context, billID string) ([]domain.Item, error) {
 	// Validate the billID
 	if billID == "" {
 		return nil, fmt.Errorf("billID cannot be empty")
 	}
 
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Start a new transaction
 	tx, err := r.DB.Begin(ctx)
 	if err != nil {
@@ -141,10 +333,10 @@ func (r *Repo) GetClosedBillItemsFromDB(ctx context.Context, billID string) ([]d
 
 	// Query the database for items associated with the given billID
 	rows, err := tx.Query(ctx, `
-		SELECT item_id, description, quantity, unit_price, currency
+		SELECT item_id, description, quantity, unit_price, currency, metadata
 		FROM closed_bills_items
-		WHERE bill_id = $1
-	`, billID)
+		WHERE bill_id = $1 AND tenant_id = $2
+	`, billID, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying closed_bills_items: %v", err)
 	}
@@ -155,6 +347,7 @@ func (r *Repo) GetClosedBillItemsFromDB(ctx context.Context, billID string) ([]d
 	for rows.Next() {
 		var item domain.Item
 		var pricePerUnit domain.Money
+		var metadata []byte
 
 		err := rows.Scan(
 			&item.ID,
@@ -162,11 +355,19 @@ func (r *Repo) GetClosedBillItemsFromDB(ctx context.Context, billID string) ([]d
 			&item.Quantity,
 			&pricePerUnit.Amount,
 			&pricePerUnit.Currency,
+			&metadata,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %v", err)
 		}
 
+		// Items closed before metadata was introduced have none
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &item.Metadata); err != nil {
+				return nil, fmt.Errorf("error decoding item metadata: %v", err)
+			}
+		}
+
 		// Assign the pricePerUnit to the item
 		item.PricePerUnit = pricePerUnit
 
@@ -186,3 +387,937 @@ func (r *Repo) GetClosedBillItemsFromDB(ctx context.Context, billID string) ([]d
 
 	return items, nil
 }
+
+// GetBillAmendmentsFromDB lists the amendments in the version history of a bill, oldest first.
+// The given bill may be any version, original or amended.
+func (r *Repo) GetBillAmendmentsFromDB(ctx context.Context, billID string) ([]domain.Amendment, error) {
+	if billID == "" {
+		return nil, fmt.Errorf("billID cannot be empty")
+	}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the version chain both ways from the given bill
+	rows, err := r.DB.Query(ctx, `
+		WITH RECURSIVE
+			older(id, amends_bill_id) AS (
+				SELECT id, amends_bill_id FROM closed_bills WHERE id = $1 AND tenant_id = $2
+				UNION
+				SELECT cb.id, cb.amends_bill_id FROM closed_bills cb JOIN older o ON cb.id = o.amends_bill_id
+			),
+			newer(id) AS (
+				SELECT id FROM closed_bills WHERE id = $1 AND tenant_id = $2
+				UNION
+				SELECT cb.id FROM closed_bills cb JOIN newer n ON cb.amends_bill_id = n.id
+			)
+		SELECT id, tenant_id, original_bill_id, amended_bill_id, version, changes,
+			adjustment_type, adjustment_amount, currency, reason, actor_id, created_at
+		FROM bill_amendments
+		WHERE tenant_id = $2
+			AND (amended_bill_id IN (SELECT id FROM older) OR amended_bill_id IN (SELECT id FROM newer))
+		ORDER BY version;
+	`, billID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bill_amendments: %v", err)
+	}
+	defer rows.Close()
+
+	var amendments []domain.Amendment
+	for rows.Next() {
+		var amendment domain.Amendment
+		var changes []byte
+
+		err := rows.Scan(
+			&amendment.ID,
+			&amendment.TenantID,
+			&amendment.OriginalBillID,
+			&amendment.AmendedBillID,
+			&amendment.Version,
+			&changes,
+			&amendment.Adjustment.Type,
+			&amendment.Adjustment.Amount.Amount,
+			&amendment.Adjustment.Amount.Currency,
+			&amendment.Reason,
+			&amendment.ActorID,
+			&amendment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+
+		if err := json.Unmarshal(changes, &amendment.Changes); err != nil {
+			return nil, fmt.Errorf("error decoding item changes: %v", err)
+		}
+
+		amendments = append(amendments, amendment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return amendments, nil
+}
+
+// GetBillEventsFromDB lists the persisted history of a closed bill, in the order the events happened.
+func (r *Repo) GetBillEventsFromDB(ctx context.Context, billID string) ([]domain.Event, error) {
+	if billID == "" {
+		return nil, fmt.Errorf("billID cannot be empty")
+	}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT sequence, type, actor_id, request_id, item, total_before, total_after, currency, threshold, occurred_at
+		FROM bill_events
+		WHERE bill_id = $1 AND tenant_id = $2
+		ORDER BY sequence;
+	`, billID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bill_events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var event domain.Event
+		var item []byte
+
+		err := rows.Scan(
+			&event.Sequence,
+			&event.Type,
+			&event.ActorID,
+			&event.RequestID,
+			&item,
+			&event.TotalBefore.Amount,
+			&event.TotalAfter.Amount,
+			&event.TotalAfter.Currency,
+			&event.Threshold,
+			&event.OccurredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		event.TotalBefore.Currency = event.TotalAfter.Currency
+
+		// Only item events carry the item they changed
+		if len(item) > 0 {
+			if err := json.Unmarshal(item, &event.Item); err != nil {
+				return nil, fmt.Errorf("error decoding event item: %v", err)
+			}
+		}
+
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return events, nil
+}
+
+// Read the late fees charged on an overdue bill, in the order they were charged.
+func getLateFees(ctx context.Context, tx *sqldb.Tx, billID uuid.UUID, tenantID string) ([]domain.LateFee, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, fee_type, description, amount, currency, accrued_through, created_at
+		FROM bill_late_fees
+		WHERE bill_id = $1 AND tenant_id = $2
+		ORDER BY created_at;
+	`, billID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bill_late_fees: %v", err)
+	}
+	defer rows.Close()
+
+	var fees []domain.LateFee
+	for rows.Next() {
+		fee := domain.LateFee{BillID: billID}
+		var accruedThrough sql.NullTime
+
+		err := rows.Scan(
+			&fee.ID,
+			&fee.Type,
+			&fee.Description,
+			&fee.Amount.Amount,
+			&fee.Amount.Currency,
+			&accruedThrough,
+			&fee.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+
+		// Fees charged before accrual was tracked cover the bill up to when they were charged
+		fee.AccruedThrough = fee.CreatedAt
+		if accruedThrough.Valid {
+			fee.AccruedThrough = accruedThrough.Time
+		}
+
+		fees = append(fees, fee)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return fees, nil
+}
+
+// GetWalletFromDB reads the prepaid credit balances of a customer and the ledger of grants and consumptions
+// they result from. Customers who were never granted credit have an empty wallet.
+func (r *Repo) GetWalletFromDB(ctx context.Context, userID string) (*domain.Wallet, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := &domain.Wallet{
+		UserID:   uuid.MustParse(userID),
+		Balances: []domain.Money{},
+		Entries:  []domain.WalletEntry{},
+	}
+
+	// Read balances and entries from the same snapshot, so they always agree
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	if err != nil {
+		return nil, fmt.Errorf("error setting transaction isolation level: %v", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT balance, currency FROM wallets
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY currency;
+	`, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying wallets: %v", err)
+	}
+	for rows.Next() {
+		var balance domain.Money
+		if err := rows.Scan(&balance.Amount, &balance.Currency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		wallet.Balances = append(wallet.Balances, balance)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT id, type, amount, currency, bill_id, reason, actor_id, request_id, created_at
+		FROM wallet_entries
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY created_at;
+	`, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying wallet_entries: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := domain.WalletEntry{UserID: wallet.UserID}
+		var billID uuid.NullUUID
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Type,
+			&entry.Amount.Amount,
+			&entry.Amount.Currency,
+			&billID,
+			&entry.Reason,
+			&entry.ActorID,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+
+		// Only consumptions reference the bill the credit was applied to
+		if billID.Valid {
+			entry.BillID = &billID.UUID
+		}
+
+		wallet.Entries = append(wallet.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return wallet, nil
+}
+
+// ClaimOpenBillInDB records a new bill as the open bill of its user and currency before its workflow starts,
+// so concurrent creations under the single open bill policy cannot both succeed. Returns the bill already
+// open instead, without recording the new one, if the user holds one in the same currency.
+// The bill workflow later finds its row already in place.
+func (r *Repo) ClaimOpenBillInDB(ctx context.Context, bill *domain.Bill) (*domain.Bill, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bill.TenantID != tenantID {
+		return nil, fmt.Errorf("bill belongs to tenant %s, not %s", bill.TenantID, tenantID)
+	}
+
+	externalRef := sql.NullString{String: bill.ExternalRef, Valid: bill.ExternalRef != ""}
+	var metadata []byte
+	if len(bill.Metadata) > 0 {
+		metadata, err = json.Marshal(bill.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding bill metadata: %v", err)
+		}
+	}
+
+	// The unique index on flagged open bills settles concurrent claims, the existence check covers
+	// bills opened before the policy was enabled
+	res, err := r.DB.Exec(ctx, `
+		INSERT INTO open_bills (
+			id, user_id, status, currency, created_at, updated_at, single_open, tenant_id, external_ref, metadata
+		)
+		SELECT $1, $2, $3, $4, $5, $5, TRUE, $6, $7, $8
+		WHERE NOT EXISTS (
+			SELECT 1 FROM open_bills WHERE tenant_id = $6 AND user_id = $2 AND currency = $4
+		)
+		ON CONFLICT DO NOTHING;
+	`,
+		bill.ID,
+		bill.UserID,
+		domain.BillOpen,
+		bill.Total.Currency,
+		bill.CreatedAt,
+		tenantID,
+		externalRef,
+		metadata,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting open_bills: %v", err)
+	}
+	if res.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	existing, err := r.GetOpenBillByUserFromDB(ctx, bill.UserID.String(), bill.Total.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// The conflicting bill was closed or released in the meantime
+		return nil, fmt.Errorf("open bill of user %s in %s changed concurrently: %w", bill.UserID, bill.Total.Currency, billerr.ErrTransient)
+	}
+	return existing, nil
+}
+
+// ReleaseOpenBillFromDB removes a bill claimed by ClaimOpenBillInDB whose workflow could not be started,
+// so the user may create another.
+func (r *Repo) ReleaseOpenBillFromDB(ctx context.Context, billID string) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(ctx, `
+		DELETE FROM open_bills WHERE id = $1 AND tenant_id = $2 AND status = $3;
+	`, billID, tenantID, domain.BillOpen)
+	if err != nil {
+		return fmt.Errorf("error deleting open_bills: %v", err)
+	}
+	return nil
+}
+
+// AddWalletGrantToDB adds prepaid credit to a customer's wallet and records the grant in its ledger.
+// Retried grants are applied once, while a request ID reused for another customer or amount is rejected.
+func (r *Repo) AddWalletGrantToDB(ctx context.Context, grant *domain.WalletEntry) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(ctx, `
+		INSERT INTO wallet_entries (id, tenant_id, user_id, type, amount, currency, reason, actor_id, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, request_id) DO NOTHING;
+	`,
+		grant.ID,
+		tenantID,
+		grant.UserID,
+		grant.Type,
+		grant.Amount.Amount,
+		grant.Amount.Currency,
+		grant.Reason,
+		grant.ActorID,
+		grant.RequestID,
+		grant.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting wallet_entries: %v", err)
+	}
+
+	// The request was already processed, check that it was the same grant
+	if res.RowsAffected() == 0 {
+		var existing domain.WalletEntry
+		err := tx.QueryRow(ctx, `
+			SELECT user_id, type, amount, currency FROM wallet_entries
+			WHERE request_id = $1 AND tenant_id = $2;
+		`, grant.RequestID, tenantID).Scan(&existing.UserID, &existing.Type, &existing.Amount.Amount, &existing.Amount.Currency)
+		if err != nil {
+			return fmt.Errorf("error checking existing request_id: %v", err)
+		}
+
+		if existing.UserID != grant.UserID || existing.Type != grant.Type || existing.Amount != grant.Amount {
+			return fmt.Errorf("request ID %s was used for another grant: %w", grant.RequestID, billerr.ErrDuplicateRequest)
+		}
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO wallets (tenant_id, user_id, currency, balance, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, user_id, currency)
+		DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at;
+	`, tenantID, grant.UserID, grant.Amount.Currency, grant.Amount.Amount, grant.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error updating wallets: %v", err)
+	}
+
+	if err := workflows.InsertJournalEntry(ctx, journalTx{tx}, domain.CreditGrantedEntry(tenantID, grant)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Adapts a transaction of the billing database to the one journal entries are recorded in by the workers.
+type journalTx struct {
+	*sqldb.Tx
+}
+
+func (tx journalTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	res, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.RowsAffected()), nil
+}
+
+// GetTrialBalanceFromDB sums the debits and credits posted to each account in a currency, up to the given time.
+func (r *Repo) GetTrialBalanceFromDB(ctx context.Context, currency string, asOf time.Time) ([]domain.AccountTotal, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.account_code, SUM(p.debit), SUM(p.credit)
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.tenant_id = $1 AND p.currency = $2 AND e.occurred_at <= $3
+		GROUP BY p.account_code
+		ORDER BY p.account_code;
+	`, tenantID, currency, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("error querying journal_postings: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.AccountTotal
+	for rows.Next() {
+		var total domain.AccountTotal
+		if err := rows.Scan(&total.AccountCode, &total.Debit, &total.Credit); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return totals, nil
+}
+
+// GetAccountPostingsFromDB reads the postings to an account in a currency over a period, in the order they
+// occurred, along with the debits and credits posted to the account before the period.
+func (r *Repo) GetAccountPostingsFromDB(ctx context.Context, code string, currency string, from time.Time, to time.Time) (domain.AccountTotal, []domain.StatementLine, error) {
+	opening := domain.AccountTotal{AccountCode: code}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return opening, nil, err
+	}
+
+	// Read the opening totals and postings from the same snapshot, so they always agree
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return opening, nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	if err != nil {
+		return opening, nil, fmt.Errorf("error setting transaction isolation level: %v", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(p.debit), 0), COALESCE(SUM(p.credit), 0)
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.tenant_id = $1 AND p.account_code = $2 AND p.currency = $3 AND e.occurred_at < $4;
+	`, tenantID, code, currency, from).Scan(&opening.Debit, &opening.Credit)
+	if err != nil {
+		return opening, nil, fmt.Errorf("error querying opening balance: %v", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT e.id, e.source, e.bill_id, e.description, p.debit, p.credit, e.occurred_at
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.tenant_id = $1 AND p.account_code = $2 AND p.currency = $3
+			AND e.occurred_at >= $4 AND e.occurred_at <= $5
+		ORDER BY e.occurred_at, p.id;
+	`, tenantID, code, currency, from, to)
+	if err != nil {
+		return opening, nil, fmt.Errorf("error querying journal_postings: %v", err)
+	}
+	defer rows.Close()
+
+	var lines []domain.StatementLine
+	for rows.Next() {
+		var line domain.StatementLine
+		var billID uuid.NullUUID
+
+		err := rows.Scan(&line.EntryID, &line.Source, &billID, &line.Description, &line.Debit, &line.Credit, &line.OccurredAt)
+		if err != nil {
+			return opening, nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		if billID.Valid {
+			line.BillID = &billID.UUID
+		}
+
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return opening, nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return opening, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return opening, lines, nil
+}
+
+// ListJournalEntriesFromDB reads the journal entries that occurred within [from, to), with their postings,
+// in the order they occurred.
+func (r *Repo) ListJournalEntriesFromDB(ctx context.Context, from time.Time, to time.Time) ([]domain.JournalEntry, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT e.id, e.source, e.source_key, e.bill_id, e.description, e.occurred_at,
+			p.account_code, p.debit, p.credit, p.currency
+		FROM journal_entries e
+		JOIN journal_postings p ON p.entry_id = e.id
+		WHERE e.tenant_id = $1 AND e.occurred_at >= $2 AND e.occurred_at < $3
+		ORDER BY e.occurred_at, e.source_key, p.id;
+	`, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying journal_entries: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	for rows.Next() {
+		var entry domain.JournalEntry
+		var posting domain.Posting
+		var billID uuid.NullUUID
+
+		err := rows.Scan(&entry.ID, &entry.Source, &entry.SourceKey, &billID, &entry.Description, &entry.OccurredAt,
+			&posting.AccountCode, &posting.Debit, &posting.Credit, &posting.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+
+		// Rows of an entry are adjacent, one per posting
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Postings = append(entries[n-1].Postings, posting)
+			continue
+		}
+		entry.TenantID = tenantID
+		if billID.Valid {
+			entry.BillID = &billID.UUID
+		}
+		entry.Postings = []domain.Posting{posting}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return entries, nil
+}
+
+// GetItemTotalsFromDB sums the items of the bills closed within [from, to) that match the filter,
+// by category and currency. Cancelled bills and versions replaced by an amendment are left out.
+func (r *Repo) GetItemTotalsFromDB(ctx context.Context, filter domain.ItemFilter) ([]domain.ItemTotal, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Items match the attributes they contain, through the GIN index on their metadata
+	var attributes []byte
+	if len(filter.Attributes) > 0 {
+		attributes, err = json.Marshal(map[string]map[string]string{"Attributes": filter.Attributes})
+		if err != nil {
+			return nil, fmt.Errorf("error encoding attribute filter: %v", err)
+		}
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT COALESCE(i.metadata->>'Category', ''), i.currency, COUNT(*),
+			SUM(i.quantity)::BIGINT, SUM(i.quantity * i.unit_price)::BIGINT
+		FROM closed_bills_items i
+		JOIN closed_bills b ON b.id = i.bill_id AND b.tenant_id = i.tenant_id
+		WHERE i.tenant_id = $1 AND b.status = $2 AND b.closed_at >= $3 AND b.closed_at < $4
+			AND NOT EXISTS (SELECT 1 FROM closed_bills n WHERE n.amends_bill_id = b.id AND n.tenant_id = b.tenant_id)
+			AND ($5 = '' OR i.metadata->>'Category' = $5)
+			AND ($6 = '' OR i.metadata->>'SKU' = $6)
+			AND ($7::JSONB IS NULL OR i.metadata @> $7::JSONB)
+			AND ($8::TIMESTAMPTZ IS NULL OR (i.metadata->>'PeriodEnd')::TIMESTAMPTZ > $8)
+			AND ($9::TIMESTAMPTZ IS NULL OR (i.metadata->>'PeriodStart')::TIMESTAMPTZ < $9)
+		GROUP BY 1, 2
+		ORDER BY 1, 2;
+	`, tenantID, domain.BillClosed, filter.From, filter.To, filter.Category, filter.SKU, attributes, filter.PeriodFrom, filter.PeriodTo)
+	if err != nil {
+		return nil, fmt.Errorf("error querying closed_bills_items: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.ItemTotal
+	for rows.Next() {
+		var total domain.ItemTotal
+		if err := rows.Scan(&total.Category, &total.Currency, &total.Lines, &total.Quantity, &total.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return totals, nil
+}
+
+// ListBillsFromDB lists the open and closed bills matching the filter, newest first.
+// Open bills are listed as stored when they were created, without their items and total,
+// which are kept by their workflow.
+func (r *Repo) ListBillsFromDB(ctx context.Context, filter domain.BillFilter) ([]domain.Bill, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bills match the metadata they contain, through the GIN indexes on their metadata
+	var metadata []byte
+	if len(filter.Metadata) > 0 {
+		metadata, err = json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding metadata filter: %v", err)
+		}
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, user_id, status, currency, total_amount, tax_amount, version, external_ref, metadata,
+			created_at, updated_at, closed_at
+		FROM (
+			SELECT id, tenant_id, user_id, status, currency, 0 AS total_amount, 0 AS tax_amount, 1 AS version,
+				external_ref, metadata, created_at, updated_at, NULL::TIMESTAMP AS closed_at
+			FROM open_bills
+			UNION ALL
+			SELECT id, tenant_id, user_id, status, currency, total_amount, tax_amount, version,
+				external_ref, metadata, created_at, updated_at, closed_at
+			FROM closed_bills
+		) bills
+		WHERE tenant_id = $1
+			AND ($2::UUID IS NULL OR user_id = $2)
+			AND ($3 = '' OR status = $3)
+			AND ($4 = '' OR external_ref = $4)
+			AND ($5::JSONB IS NULL OR metadata @> $5::JSONB)
+		ORDER BY created_at DESC, id
+		LIMIT $6 OFFSET $7;
+	`, tenantID, filter.UserID, filter.Status, filter.ExternalRef, metadata, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bills: %v", err)
+	}
+	defer rows.Close()
+
+	var bills []domain.Bill
+	for rows.Next() {
+		var bill domain.Bill
+		var externalRef sql.NullString
+		var metadata []byte
+		var closedAt sql.NullTime
+		if err := rows.Scan(
+			&bill.ID,
+			&bill.UserID,
+			&bill.Status,
+			&bill.Total.Currency,
+			&bill.Total.Amount,
+			&bill.Tax.Amount,
+			&bill.Version,
+			&externalRef,
+			&metadata,
+			&bill.CreatedAt,
+			&bill.UpdatedAt,
+			&closedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		bill.TenantID = tenantID
+		bill.Tax.Currency = bill.Total.Currency
+		bill.ExternalRef = externalRef.String
+		bill.ClosedAt = closedAt.Time
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &bill.Metadata); err != nil {
+				return nil, fmt.Errorf("error decoding bill metadata: %v", err)
+			}
+		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return bills, nil
+}
+
+// SearchBillsFromDB searches the closed bills matching the filter, best matches first.
+// Bills rank by how well their reference and metadata match, plus how well their best matching item does.
+// Matching words are marked with <b> tags in the highlights.
+func (r *Repo) SearchBillsFromDB(ctx context.Context, filter domain.SearchFilter) ([]domain.SearchResult, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT b.id, b.user_id, b.status, b.currency, b.total_amount, b.tax_amount, b.version,
+			b.external_ref, b.metadata, b.created_at, b.updated_at, b.closed_at,
+			ts_rank(b.search_vector, query) + COALESCE(MAX(ts_rank(i.search_vector, query)), 0) AS rank,
+			CASE WHEN b.search_vector @@ query THEN ts_headline('english',
+				concat_ws(' ', b.external_ref, (SELECT string_agg(value, ' ') FROM jsonb_each_text(b.metadata))), query)
+			END,
+			COALESCE(json_agg(json_build_object(
+				'ItemID', i.item_id,
+				'Description', i.description,
+				'Highlight', ts_headline('english', i.description, query),
+				'Rank', ts_rank(i.search_vector, query)
+			) ORDER BY ts_rank(i.search_vector, query) DESC, i.item_id) FILTER (WHERE i.id IS NOT NULL), '[]')
+		FROM closed_bills b
+		CROSS JOIN websearch_to_tsquery('english', $2) query
+		LEFT JOIN closed_bills_items i ON i.bill_id = b.id AND i.tenant_id = b.tenant_id AND i.search_vector @@ query
+		WHERE b.tenant_id = $1
+			AND ($3::UUID IS NULL OR b.user_id = $3)
+			AND ($4::TIMESTAMP IS NULL OR b.closed_at >= $4)
+			AND ($5::TIMESTAMP IS NULL OR b.closed_at < $5)
+		GROUP BY b.id, query
+		HAVING b.search_vector @@ query OR COUNT(i.id) > 0
+		ORDER BY rank DESC, b.closed_at DESC, b.id
+		LIMIT $6 OFFSET $7;
+	`, tenantID, filter.Query, filter.UserID, filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("error searching closed_bills: %v", err)
+	}
+	defer rows.Close()
+
+	var results []domain.SearchResult
+	for rows.Next() {
+		var result domain.SearchResult
+		var externalRef, highlight sql.NullString
+		var metadata, items []byte
+		if err := rows.Scan(
+			&result.Bill.ID,
+			&result.Bill.UserID,
+			&result.Bill.Status,
+			&result.Bill.Total.Currency,
+			&result.Bill.Total.Amount,
+			&result.Bill.Tax.Amount,
+			&result.Bill.Version,
+			&externalRef,
+			&metadata,
+			&result.Bill.CreatedAt,
+			&result.Bill.UpdatedAt,
+			&result.Bill.ClosedAt,
+			&result.Rank,
+			&highlight,
+			&items,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		result.Bill.TenantID = tenantID
+		result.Bill.Tax.Currency = result.Bill.Total.Currency
+		result.Bill.ExternalRef = externalRef.String
+		result.Highlight = highlight.String
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &result.Bill.Metadata); err != nil {
+				return nil, fmt.Errorf("error decoding bill metadata: %v", err)
+			}
+		}
+		if err := json.Unmarshal(items, &result.Items); err != nil {
+			return nil, fmt.Errorf("error decoding matching items: %v", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return results, nil
+}
+
+// GetDailyRevenueFromDB reads the revenue rolled up for the days within [from, to), by day and currency.
+func (r *Repo) GetDailyRevenueFromDB(ctx context.Context, from time.Time, to time.Time) ([]domain.DailyRevenue, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT day, currency, bills, subtotal, tax
+		FROM revenue_daily
+		WHERE tenant_id = $1 AND day >= $2 AND day < $3
+		ORDER BY day, currency;
+	`, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying revenue_daily: %v", err)
+	}
+	defer rows.Close()
+
+	var days []domain.DailyRevenue
+	for rows.Next() {
+		var day domain.DailyRevenue
+		if err := rows.Scan(&day.Day, &day.Currency, &day.Bills, &day.Subtotal, &day.Tax); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return days, nil
+}
+
+// GetCategoryRevenueFromDB sums the item revenue rolled up for the days within [from, to), by category and currency.
+func (r *Repo) GetCategoryRevenueFromDB(ctx context.Context, from time.Time, to time.Time) ([]domain.ItemTotal, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT category, currency, SUM(lines)::INT, SUM(quantity)::BIGINT, SUM(amount)::BIGINT
+		FROM revenue_daily_categories
+		WHERE tenant_id = $1 AND day >= $2 AND day < $3
+		GROUP BY 1, 2
+		ORDER BY 1, 2;
+	`, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying revenue_daily_categories: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []domain.ItemTotal
+	for rows.Next() {
+		var total domain.ItemTotal
+		if err := rows.Scan(&total.Category, &total.Currency, &total.Lines, &total.Quantity, &total.Amount); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return totals, nil
+}
+
+// GetBillCountsFromDB reads the bills counted by status and currency at the last analytics refresh.
+func (r *Repo) GetBillCountsFromDB(ctx context.Context) ([]domain.BillCount, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT status, currency, bills
+		FROM bill_counts
+		WHERE tenant_id = $1
+		ORDER BY status, currency;
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bill_counts: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []domain.BillCount
+	for rows.Next() {
+		var count domain.BillCount
+		if err := rows.Scan(&count.Status, &count.Currency, &count.Bills); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetAnalyticsRefreshFromDB reads when the analytics rollups were last refreshed.
+// Returns a nil time without an error if they were never refreshed.
+func (r *Repo) GetAnalyticsRefreshFromDB(ctx context.Context) (*time.Time, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var refreshedAt time.Time
+	err = r.DB.QueryRow(ctx, `SELECT refreshed_at FROM analytics_refreshes WHERE tenant_id = $1`, tenantID).Scan(&refreshedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying analytics_refreshes: %v", err)
+	}
+
+	return &refreshedAt, nil
+}
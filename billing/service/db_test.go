@@ -8,10 +8,13 @@ import (
 	"encore.dev/et"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/vvvakho/feezy/billing/conf"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
 )
 
 func TestGetOpenBillFromDB(t *testing.T) {
-	ctx := context.Background()
+	ctx := tenant.NewContext(context.Background(), conf.DEFAULT_TENANT)
 
 	// Create a new test database
 	testDB, err := et.NewTestDatabase(ctx, "bills")
@@ -41,3 +44,129 @@ func TestGetOpenBillFromDB(t *testing.T) {
 	assert.NotNil(t, bill)
 	assert.Equal(t, billID, bill.ID.String())
 }
+
+func TestGetOpenBillByUserFromDB(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), conf.DEFAULT_TENANT)
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB}
+
+	billID := uuid.New().String()
+	userID := uuid.New().String()
+
+	_, err = testDB.Exec(ctx, `
+        INSERT INTO open_bills (id, user_id, currency, status, created_at, updated_at, single_open)
+        VALUES ($1, $2, $3, $4, $5, $6, $7);
+    `, billID, userID, "USD", "BillOpen", time.Now(), time.Now(), true)
+
+	if err != nil {
+		t.Fatalf("failed to insert test data: %v", err)
+	}
+
+	// The open bill is found for its own currency
+	bill, err := repo.GetOpenBillByUserFromDB(ctx, userID, "USD")
+	assert.NoError(t, err)
+	assert.NotNil(t, bill)
+	assert.Equal(t, billID, bill.ID.String())
+	assert.Equal(t, "USD", bill.Total.Currency)
+
+	// No bill is returned for a different currency
+	bill, err = repo.GetOpenBillByUserFromDB(ctx, userID, "GEL")
+	assert.NoError(t, err)
+	assert.Nil(t, bill)
+
+	// A second flagged open bill in the same currency violates the unique index
+	_, err = testDB.Exec(ctx, `
+        INSERT INTO open_bills (id, user_id, currency, status, created_at, updated_at, single_open)
+        VALUES ($1, $2, $3, $4, $5, $6, $7);
+    `, uuid.New().String(), userID, "USD", "BillOpen", time.Now(), time.Now(), true)
+	assert.Error(t, err)
+}
+
+func TestClaimOpenBillInDB(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), conf.DEFAULT_TENANT)
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB}
+
+	userID := uuid.New().String()
+	first, _ := domain.NewBill(userID, "USD")
+	first.TenantID = conf.DEFAULT_TENANT
+	second, _ := domain.NewBill(userID, "USD")
+	second.TenantID = conf.DEFAULT_TENANT
+
+	// The first claim records the bill
+	existing, err := repo.ClaimOpenBillInDB(ctx, first)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// A second claim in the same currency returns the first bill
+	existing, err = repo.ClaimOpenBillInDB(ctx, second)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, first.ID, existing.ID)
+	}
+
+	// Once released, the currency may be claimed again
+	assert.NoError(t, repo.ReleaseOpenBillFromDB(ctx, first.ID.String()))
+	existing, err = repo.ClaimOpenBillInDB(ctx, second)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestGetOpenBillFromDBTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB}
+
+	billID := uuid.New().String()
+
+	_, err = testDB.Exec(ctx, `
+        INSERT INTO open_bills (id, user_id, currency, status, created_at, updated_at, tenant_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7);
+    `, billID, uuid.New().String(), "USD", "BillOpen", time.Now(), time.Now(), "acme")
+
+	if err != nil {
+		t.Fatalf("failed to insert test data: %v", err)
+	}
+
+	// The owning tenant can read the bill
+	bill, err := repo.GetOpenBillFromDB(tenant.NewContext(ctx, "acme"), billID)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", bill.TenantID)
+
+	// Another tenant cannot
+	_, err = repo.GetOpenBillFromDB(tenant.NewContext(ctx, "globex"), billID)
+	assert.Error(t, err)
+
+	// Unscoped queries are rejected
+	_, err = repo.GetOpenBillFromDB(ctx, billID)
+	assert.Error(t, err)
+
+	// IDs are keyed within their tenant, so another tenant may hold a bill under the same ID
+	_, err = testDB.Exec(ctx, `
+        INSERT INTO open_bills (id, user_id, currency, status, created_at, updated_at, tenant_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7);
+    `, billID, uuid.New().String(), "GEL", "BillOpen", time.Now(), time.Now(), "globex")
+	assert.NoError(t, err)
+
+	bill, err = repo.GetOpenBillFromDB(tenant.NewContext(ctx, "globex"), billID)
+	assert.NoError(t, err)
+	assert.Equal(t, "globex", bill.TenantID)
+}
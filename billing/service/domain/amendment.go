@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Amendment records the correction of a closed bill by a new version of it,
+// along with the item changes and the credit or debit issued for the difference.
+type Amendment struct {
+	ID             uuid.UUID
+	TenantID       string
+	OriginalBillID uuid.UUID
+	AmendedBillID  uuid.UUID
+	Version        int
+	Changes        []ItemChange
+	Adjustment     Adjustment
+	Reason         string
+	ActorID        string
+	CreatedAt      time.Time
+}
+
+// ItemChange describes how the quantity of an item differs between two versions of a bill.
+// Items missing from a version have a quantity of zero in it.
+type ItemChange struct {
+	ItemID         uuid.UUID
+	Description    string
+	PricePerUnit   Money
+	QuantityBefore int64
+	QuantityAfter  int64
+}
+
+type AdjustmentType string
+
+var AdjustmentCredit AdjustmentType = "credit" // owed to the customer
+var AdjustmentDebit AdjustmentType = "debit"   // owed by the customer
+var AdjustmentNone AdjustmentType = "none"
+
+// Adjustment is the credit or debit note settling the difference between two versions of a bill, tax included.
+type Adjustment struct {
+	Type   AdjustmentType
+	Amount Money
+}
+
+// NewAmendment creates the next version of a closed bill with the given items.
+// The new version keeps the customer, tenant, tax rate and billing profile of the original.
+func NewAmendment(original *Bill, items []Item, reason string, actorID string, at time.Time) (*Bill, *Amendment, error) {
+	if original.Status != BillClosed {
+		return nil, nil, fmt.Errorf("cannot amend a bill with status %s", original.Status)
+	}
+	if original.AmendedByID != nil {
+		return nil, nil, errors.New("only the latest version of a bill can be amended")
+	}
+	if reason == "" {
+		return nil, nil, errors.New("amendment reason cannot be empty")
+	}
+
+	// Items keep the metadata of the item they replace, unless the amendment gives them their own
+	items = append([]Item{}, items...)
+	for i := range items {
+		if items[i].Metadata != nil {
+			continue
+		}
+		for _, originalItem := range original.Items {
+			if originalItem.ID == items[i].ID {
+				items[i].Metadata = originalItem.Metadata
+			}
+		}
+	}
+
+	changes, err := DiffItems(original.Items, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil, errors.New("amendment does not change any items")
+	}
+
+	billID, err := uuid.NewV7()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to initialize bill ID: %v", err)
+	}
+
+	// Bills closed before versioning was introduced are their first version
+	version := max(original.Version, 1) + 1
+	originalID := original.ID
+
+	amended := &Bill{
+		ID:             billID,
+		TenantID:       original.TenantID,
+		Items:          items,
+		Total:          Money{Currency: original.Total.Currency},
+		TaxRate:        original.TaxRate,
+		Status:         BillClosed,
+		UserID:         original.UserID,
+		BillingProfile: original.BillingProfile,
+		ExternalRef:    original.ExternalRef,
+		Metadata:       original.Metadata,
+		Version:        version,
+		AmendsBillID:   &originalID,
+		CreatedAt:      at,
+		UpdatedAt:      at,
+		ClosedAt:       at,
+		// The new version is collected in place of the original, by the same due date
+		DueAt:         original.DueAt,
+		Collection:    original.Collection,
+		CreditApplied: original.CreditApplied,
+	}
+	if err := amended.CalculateTotal(); err != nil {
+		return nil, nil, err
+	}
+
+	amendmentID, err := uuid.NewV7()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to initialize amendment ID: %v", err)
+	}
+
+	return amended, &Amendment{
+		ID:             amendmentID,
+		TenantID:       original.TenantID,
+		OriginalBillID: original.ID,
+		AmendedBillID:  amended.ID,
+		Version:        version,
+		Changes:        changes,
+		Adjustment:     NewAdjustment(original, amended),
+		Reason:         reason,
+		ActorID:        actorID,
+		CreatedAt:      at,
+	}, nil
+}
+
+// NewAdjustment returns the credit or debit settling the difference between the amounts due on two versions of a bill.
+func NewAdjustment(original *Bill, amended *Bill) Adjustment {
+	delta := (amended.Total.Amount + amended.Tax.Amount) - (original.Total.Amount + original.Tax.Amount)
+
+	switch {
+	case delta > 0:
+		return Adjustment{Type: AdjustmentDebit, Amount: Money{Amount: delta, Currency: amended.Total.Currency}}
+	case delta < 0:
+		return Adjustment{Type: AdjustmentCredit, Amount: Money{Amount: -delta, Currency: amended.Total.Currency}}
+	}
+	return Adjustment{Type: AdjustmentNone, Amount: Money{Currency: amended.Total.Currency}}
+}
+
+// DiffItems lists the items whose quantity differs between two versions of a bill, in order of appearance.
+// An item keeps its price across versions, changing it requires a new item ID.
+func DiffItems(before []Item, after []Item) ([]ItemChange, error) {
+	afterByID := make(map[uuid.UUID]Item, len(after))
+	for _, item := range after {
+		if _, ok := afterByID[item.ID]; ok {
+			return nil, fmt.Errorf("item %s is listed more than once", item.ID)
+		}
+		afterByID[item.ID] = item
+	}
+
+	changes := []ItemChange{}
+	beforeByID := make(map[uuid.UUID]Item, len(before))
+	for _, item := range before {
+		beforeByID[item.ID] = item
+
+		next, ok := afterByID[item.ID]
+		if ok && next.PricePerUnit != item.PricePerUnit {
+			return nil, errors.New("Price of item has changed, please use new UUID")
+		}
+		if next.Quantity != item.Quantity {
+			changes = append(changes, ItemChange{
+				ItemID:         item.ID,
+				Description:    item.Description,
+				PricePerUnit:   item.PricePerUnit,
+				QuantityBefore: item.Quantity,
+				QuantityAfter:  next.Quantity,
+			})
+		}
+	}
+
+	for _, item := range after {
+		if _, ok := beforeByID[item.ID]; !ok {
+			changes = append(changes, ItemChange{
+				ItemID:        item.ID,
+				Description:   item.Description,
+				PricePerUnit:  item.PricePerUnit,
+				QuantityAfter: item.Quantity,
+			})
+		}
+	}
+
+	return changes, nil
+}
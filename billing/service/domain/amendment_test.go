@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func closedBill(items ...Item) *Bill {
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	bill.TenantID = "default"
+	bill.TaxRate = 1000
+	bill.ExternalRef = "PO-1001"
+	bill.Metadata = map[string]string{"cost_center": "CC-42"}
+	for _, item := range items {
+		bill.AddLineItem(item)
+	}
+	bill.Status = BillClosed
+	return bill
+}
+
+func TestDiffItems(t *testing.T) {
+	kept := Item{ID: uuid.New(), Quantity: 1, Description: "Kept", PricePerUnit: Money{Amount: 100, Currency: "USD"}}
+	changed := Item{ID: uuid.New(), Quantity: 2, Description: "Changed", PricePerUnit: Money{Amount: 50, Currency: "USD"}}
+	removed := Item{ID: uuid.New(), Quantity: 1, Description: "Removed", PricePerUnit: Money{Amount: 10, Currency: "USD"}}
+	added := Item{ID: uuid.New(), Quantity: 3, Description: "Added", PricePerUnit: Money{Amount: 5, Currency: "USD"}}
+
+	before := []Item{kept, changed, removed}
+	changedAfter := changed
+	changedAfter.Quantity = 1
+
+	changes, err := DiffItems(before, []Item{kept, changedAfter, added})
+	assert.NoError(t, err)
+	assert.Equal(t, []ItemChange{
+		{ItemID: changed.ID, Description: "Changed", PricePerUnit: changed.PricePerUnit, QuantityBefore: 2, QuantityAfter: 1},
+		{ItemID: removed.ID, Description: "Removed", PricePerUnit: removed.PricePerUnit, QuantityBefore: 1, QuantityAfter: 0},
+		{ItemID: added.ID, Description: "Added", PricePerUnit: added.PricePerUnit, QuantityBefore: 0, QuantityAfter: 3},
+	}, changes)
+
+	// Identical items produce no changes
+	changes, err = DiffItems(before, before)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// Prices of existing items cannot change
+	repriced := kept
+	repriced.PricePerUnit.Amount = 200
+	_, err = DiffItems(before, []Item{repriced})
+	assert.Error(t, err)
+
+	// Items cannot be listed twice
+	_, err = DiffItems(before, []Item{added, added})
+	assert.Error(t, err)
+}
+
+func TestNewAmendment(t *testing.T) {
+	now := time.Now()
+	actorID := uuid.New().String()
+	item := Item{ID: uuid.New(), Quantity: 2, Description: "Item 1", PricePerUnit: Money{Amount: 500, Currency: "USD"}}
+
+	tests := []struct {
+		name           string
+		items          []Item
+		expectedType   AdjustmentType
+		expectedAmount MinorUnit
+	}{
+		{"Debit For Added Quantity", []Item{{ID: item.ID, Quantity: 3, PricePerUnit: item.PricePerUnit}}, AdjustmentDebit, 550},
+		{"Credit For Removed Quantity", []Item{{ID: item.ID, Quantity: 1, PricePerUnit: item.PricePerUnit}}, AdjustmentCredit, 550},
+		{"Credit For Removed Item", []Item{}, AdjustmentCredit, 1100},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			original := closedBill(item)
+
+			amended, amendment, err := NewAmendment(original, tc.items, "Wrong quantity", actorID, now)
+			assert.NoError(t, err)
+
+			// The new version is linked to the original and keeps its customer and tax rate
+			assert.NotEqual(t, original.ID, amended.ID)
+			assert.Equal(t, &original.ID, amended.AmendsBillID)
+			assert.Equal(t, 2, amended.Version)
+			assert.Equal(t, BillClosed, amended.Status)
+			assert.Equal(t, original.UserID, amended.UserID)
+			assert.Equal(t, original.TaxRate, amended.TaxRate)
+			assert.Equal(t, original.ExternalRef, amended.ExternalRef)
+			assert.Equal(t, original.Metadata, amended.Metadata)
+
+			assert.Equal(t, original.ID, amendment.OriginalBillID)
+			assert.Equal(t, amended.ID, amendment.AmendedBillID)
+			assert.Equal(t, Adjustment{Type: tc.expectedType, Amount: Money{Amount: tc.expectedAmount, Currency: "USD"}}, amendment.Adjustment)
+		})
+	}
+
+	// Only the latest version of a closed bill can be amended
+	original := closedBill(item)
+	_, _, err := NewAmendment(original, []Item{item}, "No change", actorID, now)
+	assert.Error(t, err)
+
+	_, _, err = NewAmendment(original, []Item{}, "", actorID, now)
+	assert.Error(t, err)
+
+	nextID := uuid.New()
+	original.AmendedByID = &nextID
+	_, _, err = NewAmendment(original, []Item{}, "Wrong quantity", actorID, now)
+	assert.Error(t, err)
+
+	cancelled := closedBill(item)
+	cancelled.Status = BillCancelled
+	_, _, err = NewAmendment(cancelled, []Item{}, "Wrong quantity", actorID, now)
+	assert.Error(t, err)
+}
+
+func TestNewAmendmentKeepsMetadata(t *testing.T) {
+	now := time.Now()
+	actorID := uuid.New().String()
+	metadata := &ItemMetadata{Category: "usage", SKU: "API-CALLS"}
+	item := Item{ID: uuid.New(), Quantity: 2, Description: "Item 1", PricePerUnit: Money{Amount: 500, Currency: "USD"}, Metadata: metadata}
+	original := closedBill(item)
+
+	// An item without metadata keeps the metadata of the item it replaces
+	items := []Item{{ID: item.ID, Quantity: 3, PricePerUnit: item.PricePerUnit}}
+	amended, _, err := NewAmendment(original, items, "Wrong quantity", actorID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, amended.Items[0].Metadata)
+	assert.Nil(t, items[0].Metadata)
+
+	// Metadata given in the amendment replaces it
+	relabelled := &ItemMetadata{Category: "support"}
+	items = []Item{{ID: item.ID, Quantity: 3, PricePerUnit: item.PricePerUnit, Metadata: relabelled}}
+	amended, _, err = NewAmendment(closedBill(item), items, "Wrong category", actorID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, relabelled, amended.Items[0].Metadata)
+}
+
+func TestNewAmendmentKeepsCollection(t *testing.T) {
+	now := time.Now()
+	item := Item{ID: uuid.New(), Quantity: 2, Description: "Item 1", PricePerUnit: Money{Amount: 500, Currency: "USD"}}
+	original := closedBill(item)
+	original.PaymentTerms = &PaymentTerms{DueIn: 30 * 24 * time.Hour}
+	original.SetDueDate(now.Add(-time.Hour))
+	original.CreditApplied = Money{Amount: 200, Currency: "USD"}
+
+	// The new version is collected in place of the original, by the same due date
+	items := []Item{{ID: item.ID, Quantity: 1, PricePerUnit: item.PricePerUnit}}
+	amended, _, err := NewAmendment(original, items, "Wrong quantity", uuid.New().String(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, original.DueAt, amended.DueAt)
+	assert.Equal(t, CollectionUnpaid, amended.Collection)
+	assert.Equal(t, original.CreditApplied, amended.CreditApplied)
+	assert.Equal(t, MinorUnit(350), amended.AmountDue().Amount)
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReportInterval is the length of the periods a revenue report is broken down into.
+type ReportInterval string
+
+const (
+	IntervalDay   ReportInterval = "day"
+	IntervalMonth ReportInterval = "month"
+)
+
+// DailyRevenue is the revenue billed on a day in one currency, as rolled up from the closed bills.
+// Subtotals exclude tax.
+type DailyRevenue struct {
+	Day      time.Time
+	Currency string
+	Bills    int
+	Subtotal MinorUnit
+	Tax      MinorUnit
+}
+
+// CurrencyRevenue is the revenue billed within a period in one currency, before it is normalized.
+type CurrencyRevenue struct {
+	Currency string
+	Bills    int
+	Subtotal MinorUnit
+	Tax      MinorUnit
+}
+
+// RevenuePeriod is the revenue billed within a period, normalized to the reporting currency.
+// The average bill is the total, tax included, divided by the bills closed within the period.
+type RevenuePeriod struct {
+	Start       time.Time
+	Currency    string
+	Bills       int
+	Subtotal    MinorUnit
+	Tax         MinorUnit
+	Total       MinorUnit
+	AverageBill MinorUnit
+	ByCurrency  []CurrencyRevenue
+}
+
+// BillCount is the number of bills of a tenant with a status, in one currency.
+type BillCount struct {
+	Status   Status
+	Currency string
+	Bills    int
+}
+
+// Start of the period of the given interval a day falls in.
+func periodStart(day time.Time, interval ReportInterval) (time.Time, error) {
+	day = day.UTC()
+	switch interval {
+	case IntervalDay:
+		return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC), nil
+	case IntervalMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown report interval %s", interval)
+	}
+}
+
+// RevenueByPeriod breaks daily revenue down into periods of the given interval, normalized to the given currency.
+// Periods without revenue are left out. Amounts are summed per currency before they are converted,
+// so rounding happens once per currency and period.
+func RevenueByPeriod(days []DailyRevenue, interval ReportInterval, currency string) ([]RevenuePeriod, error) {
+	var starts []time.Time
+	byPeriod := map[time.Time][]DailyRevenue{}
+	for _, day := range days {
+		start, err := periodStart(day.Day, interval)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byPeriod[start]; !ok {
+			starts = append(starts, start)
+		}
+		byPeriod[start] = append(byPeriod[start], day)
+	}
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+
+	periods := make([]RevenuePeriod, 0, len(starts))
+	for _, start := range starts {
+		period, err := SumRevenue(start, byPeriod[start], currency)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+// SumRevenue sums daily revenue into a single period starting at the given time, normalized to the given currency.
+func SumRevenue(start time.Time, days []DailyRevenue, currency string) (RevenuePeriod, error) {
+	period := RevenuePeriod{Start: start, Currency: currency, ByCurrency: []CurrencyRevenue{}}
+
+	for _, day := range days {
+		i := slices.IndexFunc(period.ByCurrency, func(c CurrencyRevenue) bool { return c.Currency == day.Currency })
+		if i < 0 {
+			period.ByCurrency = append(period.ByCurrency, CurrencyRevenue{Currency: day.Currency})
+			i = len(period.ByCurrency) - 1
+		}
+		period.ByCurrency[i].Bills += day.Bills
+		period.ByCurrency[i].Subtotal += day.Subtotal
+		period.ByCurrency[i].Tax += day.Tax
+	}
+	slices.SortFunc(period.ByCurrency, func(a, b CurrencyRevenue) int { return strings.Compare(a.Currency, b.Currency) })
+
+	for _, revenue := range period.ByCurrency {
+		subtotal, err := Convert(currency, revenue.Currency, revenue.Subtotal)
+		if err != nil {
+			return RevenuePeriod{}, err
+		}
+		tax, err := Convert(currency, revenue.Currency, revenue.Tax)
+		if err != nil {
+			return RevenuePeriod{}, err
+		}
+		period.Bills += revenue.Bills
+		period.Subtotal += subtotal
+		period.Tax += tax
+	}
+	period.Total = period.Subtotal + period.Tax
+	if period.Bills > 0 {
+		period.AverageBill = period.Total / MinorUnit(period.Bills)
+	}
+	return period, nil
+}
+
+// NormalizeItemTotals merges the item totals of every category across currencies, normalized to the given
+// currency, largest amount first.
+func NormalizeItemTotals(totals []ItemTotal, currency string) ([]ItemTotal, error) {
+	normalized := []ItemTotal{}
+	for _, total := range totals {
+		amount, err := Convert(currency, total.Currency, total.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		i := slices.IndexFunc(normalized, func(t ItemTotal) bool { return t.Category == total.Category })
+		if i < 0 {
+			normalized = append(normalized, ItemTotal{Category: total.Category, Currency: currency})
+			i = len(normalized) - 1
+		}
+		normalized[i].Lines += total.Lines
+		normalized[i].Quantity += total.Quantity
+		normalized[i].Amount += amount
+	}
+
+	slices.SortFunc(normalized, func(a, b ItemTotal) int {
+		return cmp.Or(cmp.Compare(b.Amount, a.Amount), strings.Compare(a.Category, b.Category))
+	})
+	return normalized, nil
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevenueByPeriod(t *testing.T) {
+	jan1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	feb1 := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	days := []DailyRevenue{
+		{Day: jan1, Currency: "USD", Bills: 2, Subtotal: 1000, Tax: 100},
+		{Day: jan1, Currency: "GEL", Bills: 1, Subtotal: 2750, Tax: 275},
+		{Day: jan2, Currency: "USD", Bills: 1, Subtotal: 500, Tax: 50},
+		{Day: feb1, Currency: "USD", Bills: 1, Subtotal: 300, Tax: 0},
+	}
+
+	// Days are kept apart, with other currencies converted into the reporting currency
+	periods, err := RevenueByPeriod(days, IntervalDay, "USD")
+	assert.NoError(t, err)
+	if assert.Len(t, periods, 3) {
+		assert.Equal(t, jan1, periods[0].Start)
+		assert.Equal(t, 3, periods[0].Bills)
+		assert.Equal(t, MinorUnit(2000), periods[0].Subtotal)
+		assert.Equal(t, MinorUnit(200), periods[0].Tax)
+		assert.Equal(t, MinorUnit(2200), periods[0].Total)
+		assert.Equal(t, MinorUnit(733), periods[0].AverageBill)
+		assert.Equal(t, []CurrencyRevenue{
+			{Currency: "GEL", Bills: 1, Subtotal: 2750, Tax: 275},
+			{Currency: "USD", Bills: 2, Subtotal: 1000, Tax: 100},
+		}, periods[0].ByCurrency)
+	}
+
+	// Months sum their days
+	periods, err = RevenueByPeriod(days, IntervalMonth, "GEL")
+	assert.NoError(t, err)
+	if assert.Len(t, periods, 2) {
+		assert.Equal(t, jan1, periods[0].Start)
+		assert.Equal(t, "GEL", periods[0].Currency)
+		assert.Equal(t, 4, periods[0].Bills)
+		assert.Equal(t, MinorUnit(6875), periods[0].Subtotal)
+		assert.Equal(t, feb1, periods[1].Start)
+		assert.Equal(t, MinorUnit(825), periods[1].AverageBill)
+	}
+
+	_, err = RevenueByPeriod(days, "week", "USD")
+	assert.Error(t, err)
+
+	_, err = RevenueByPeriod(days, IntervalDay, "EUR")
+	assert.Error(t, err)
+
+	periods, err = RevenueByPeriod(nil, IntervalDay, "USD")
+	assert.NoError(t, err)
+	assert.Empty(t, periods)
+}
+
+func TestSumRevenue(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A period without bills has no average bill
+	summary, err := SumRevenue(from, nil, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, RevenuePeriod{Start: from, Currency: "USD", ByCurrency: []CurrencyRevenue{}}, summary)
+}
+
+func TestNormalizeItemTotals(t *testing.T) {
+	totals := []ItemTotal{
+		{Category: "seats", Currency: "USD", Lines: 2, Quantity: 4, Amount: 400},
+		{Category: "seats", Currency: "GEL", Lines: 1, Quantity: 1, Amount: 275},
+		{Category: "", Currency: "USD", Lines: 1, Quantity: 1, Amount: 50},
+		{Category: "support", Currency: "USD", Lines: 1, Quantity: 1, Amount: 1000},
+	}
+
+	normalized, err := NormalizeItemTotals(totals, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, []ItemTotal{
+		{Category: "support", Currency: "USD", Lines: 1, Quantity: 1, Amount: 1000},
+		{Category: "seats", Currency: "USD", Lines: 3, Quantity: 5, Amount: 500},
+		{Category: "", Currency: "USD", Lines: 1, Quantity: 1, Amount: 50},
+	}, normalized)
+
+	_, err = NormalizeItemTotals(totals, "EUR")
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+)
+
+// CollectionStatus tracks the payment of a closed bill.
+type CollectionStatus string
+
+var CollectionUnpaid CollectionStatus = "unpaid"
+var CollectionPaid CollectionStatus = "paid"
+var CollectionUncollectible CollectionStatus = "uncollectible"
+
+// CollectionSuperseded marks a bill replaced by an amended version, which is collected in its place.
+var CollectionSuperseded CollectionStatus = "superseded"
+
+// DunningAction is a step taken to collect an unpaid bill.
+type DunningAction string
+
+var DunningRemind DunningAction = "remind"
+var DunningRetryPayment DunningAction = "retry_payment"
+var DunningUncollectible DunningAction = "uncollectible"
+
+// DunningStep is an action taken on a bill that is still unpaid, at an offset from its due date.
+type DunningStep struct {
+	Offset time.Duration
+	Action DunningAction
+}
+
+// PaymentTerms set when a closed bill falls due, and how its payment is followed up while it remains unpaid.
+type PaymentTerms struct {
+	DueIn   time.Duration
+	Dunning []DunningStep
+}
+
+// SetDueDate marks a bill that is being closed as unpaid, due after its payment terms.
+// Bills without payment terms are never due.
+func (b *Bill) SetDueDate(closedAt time.Time) {
+	b.ClosedAt = closedAt
+	if b.PaymentTerms == nil {
+		return
+	}
+
+	dueAt := closedAt.Add(b.PaymentTerms.DueIn)
+	b.DueAt = &dueAt
+	b.Collection = CollectionUnpaid
+}
+
+// MarkPaid marks a bill that is being closed as paid, for bills whose payment was taken along with the close.
+// Paid bills are never dunned, whether or not they have payment terms.
+func (b *Bill) MarkPaid() {
+	b.Collection = CollectionPaid
+}
+
+// ClearDueDate reverts SetDueDate, for closes that were rolled back.
+func (b *Bill) ClearDueDate() {
+	b.ClosedAt = time.Time{}
+	b.DueAt = nil
+	b.Collection = ""
+}
+
+// AmountDue returns the amount the customer owes for a bill, tax included and prepaid credit deducted.
+func (b *Bill) AmountDue() Money {
+	return Money{Amount: b.Total.Amount + b.Tax.Amount - b.CreditApplied.Amount, Currency: b.Total.Currency}
+}
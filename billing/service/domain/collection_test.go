@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDueDate(t *testing.T) {
+	closedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Bills with payment terms fall due after them, unpaid
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	bill.PaymentTerms = &PaymentTerms{DueIn: 30 * 24 * time.Hour}
+	bill.SetDueDate(closedAt)
+	assert.Equal(t, closedAt, bill.ClosedAt)
+	if assert.NotNil(t, bill.DueAt) {
+		assert.Equal(t, closedAt.Add(30*24*time.Hour), *bill.DueAt)
+	}
+	assert.Equal(t, CollectionUnpaid, bill.Collection)
+
+	bill.ClearDueDate()
+	assert.Nil(t, bill.DueAt)
+	assert.Empty(t, bill.Collection)
+
+	// Bills without payment terms are never due
+	bill, _ = NewBill(uuid.New().String(), "USD")
+	bill.SetDueDate(closedAt)
+	assert.Nil(t, bill.DueAt)
+	assert.Empty(t, bill.Collection)
+
+	// Bills paid on close are settled, due date or not
+	bill.MarkPaid()
+	assert.Equal(t, CollectionPaid, bill.Collection)
+	bill.ClearDueDate()
+	assert.Empty(t, bill.Collection)
+}
+
+func TestAmountDue(t *testing.T) {
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	bill.TaxRate = 1800
+	assert.NoError(t, bill.AddLineItem(Item{ID: uuid.New(), Quantity: 2, PricePerUnit: Money{Amount: 500, Currency: "USD"}}))
+
+	assert.Equal(t, Money{Amount: 1180, Currency: "USD"}, bill.AmountDue())
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreditLimit caps the total an open bill may grow to, converted to the currency of the limit.
+// A warning is recorded once the total reaches each of the WarnAt percentages of the limit.
+type CreditLimit struct {
+	Limit  Money
+	WarnAt []int
+}
+
+// ErrCreditLimitExceeded is returned when adding an item would take a bill over its credit limit.
+var ErrCreditLimitExceeded = errors.New("credit limit exceeded")
+
+// Check that adding an item keeps the bill total within its credit limit, if it has one.
+func (b *Bill) checkCreditLimit(item Item) error {
+	if b.CreditLimit == nil || b.CreditLimit.Limit.Amount <= 0 {
+		return nil
+	}
+
+	unitPrice, err := Convert(b.Total.Currency, item.PricePerUnit.Currency, item.PricePerUnit.Amount)
+	if err != nil {
+		return err
+	}
+
+	return b.checkCreditLimitTotal(b.Total.Amount + unitPrice*MinorUnit(item.Quantity))
+}
+
+// Check that a total in the bill currency is within the bill's credit limit.
+func (b *Bill) checkCreditLimitTotal(amount MinorUnit) error {
+	total, err := Convert(b.CreditLimit.Limit.Currency, b.Total.Currency, amount)
+	if err != nil {
+		return err
+	}
+
+	if total > b.CreditLimit.Limit.Amount {
+		return fmt.Errorf("%w: total of %d %s would exceed the limit of %d %s", ErrCreditLimitExceeded,
+			total, b.CreditLimit.Limit.Currency, b.CreditLimit.Limit.Amount, b.CreditLimit.Limit.Currency)
+	}
+	return nil
+}
+
+// RecordCreditWarnings records a warning for every threshold of the credit limit
+// the bill total reached with its latest change, having been below it at totalBefore.
+func (b *Bill) RecordCreditWarnings(actorID string, requestID string, totalBefore Money, at time.Time) error {
+	if b.CreditLimit == nil || b.CreditLimit.Limit.Amount <= 0 {
+		return nil
+	}
+
+	limit := b.CreditLimit.Limit
+	before, err := Convert(limit.Currency, totalBefore.Currency, totalBefore.Amount)
+	if err != nil {
+		return err
+	}
+	after, err := Convert(limit.Currency, b.Total.Currency, b.Total.Amount)
+	if err != nil {
+		return err
+	}
+
+	for _, percent := range b.CreditLimit.WarnAt {
+		threshold := limit.Amount * MinorUnit(percent) / 100
+		if before < threshold && after >= threshold {
+			b.Record(EventCreditLimitWarning, actorID, requestID, nil, totalBefore, at)
+			b.Events[len(b.Events)-1].Threshold = percent
+		}
+	}
+	return nil
+}
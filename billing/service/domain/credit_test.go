@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddLineItemCreditLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     *CreditLimit
+		item      Item
+		expectErr bool
+	}{
+		{"No Credit Limit", nil, Item{Quantity: 100, PricePerUnit: Money{Amount: 1000, Currency: "USD"}}, false},
+		{"Within Limit", &CreditLimit{Limit: Money{Amount: 1000, Currency: "USD"}}, Item{Quantity: 2, PricePerUnit: Money{Amount: 200, Currency: "USD"}}, false},
+		{"Reaches Limit", &CreditLimit{Limit: Money{Amount: 1000, Currency: "USD"}}, Item{Quantity: 5, PricePerUnit: Money{Amount: 100, Currency: "USD"}}, false},
+		{"Exceeds Limit", &CreditLimit{Limit: Money{Amount: 1000, Currency: "USD"}}, Item{Quantity: 6, PricePerUnit: Money{Amount: 100, Currency: "USD"}}, true},
+		{"Exceeds Limit In Other Currency", &CreditLimit{Limit: Money{Amount: 2750, Currency: "GEL"}}, Item{Quantity: 6, PricePerUnit: Money{Amount: 100, Currency: "USD"}}, true},
+		{"Zero Limit Disables Cap", &CreditLimit{}, Item{Quantity: 100, PricePerUnit: Money{Amount: 1000, Currency: "USD"}}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Bill already holding 500 USD
+			bill, _ := NewBill(uuid.New().String(), "USD")
+			assert.NoError(t, bill.AddLineItem(Item{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 500, Currency: "USD"}}))
+			bill.CreditLimit = tc.limit
+
+			tc.item.ID = uuid.New()
+			err := bill.AddLineItem(tc.item)
+			if tc.expectErr {
+				assert.True(t, errors.Is(err, ErrCreditLimitExceeded))
+				assert.Len(t, bill.Items, 1)
+				assert.Equal(t, MinorUnit(500), bill.Total.Amount)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, bill.Items, 2)
+			}
+		})
+	}
+}
+
+func TestRecordCreditWarnings(t *testing.T) {
+	now := time.Now()
+	actorID := uuid.New().String()
+
+	tests := []struct {
+		name        string
+		before      MinorUnit
+		after       MinorUnit
+		expectedPct []int
+	}{
+		{"Below Thresholds", 0, 400, nil},
+		{"Reaches First Threshold", 400, 500, []int{50}},
+		{"Crosses Both Thresholds", 400, 900, []int{50, 80}},
+		{"Already Above Threshold", 600, 700, nil},
+		{"Total Decreases", 900, 400, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bill := &Bill{
+				Total:       Money{Amount: tc.after, Currency: "USD"},
+				CreditLimit: &CreditLimit{Limit: Money{Amount: 1000, Currency: "USD"}, WarnAt: []int{50, 80}},
+			}
+
+			err := bill.RecordCreditWarnings(actorID, "request-1", Money{Amount: tc.before, Currency: "USD"}, now)
+			assert.NoError(t, err)
+
+			var recorded []int
+			for _, event := range bill.Events {
+				assert.Equal(t, EventCreditLimitWarning, event.Type)
+				assert.Equal(t, tc.before, event.TotalBefore.Amount)
+				assert.Equal(t, tc.after, event.TotalAfter.Amount)
+				recorded = append(recorded, event.Threshold)
+			}
+			assert.Equal(t, tc.expectedPct, recorded)
+			assert.Equal(t, 0, bill.Revision)
+		})
+	}
+}
@@ -10,14 +10,72 @@ import (
 )
 
 type Bill struct {
-	ID        uuid.UUID
-	Items     []Item
-	Total     Money
-	Status    Status
-	UserID    uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	ClosedAt  time.Time
+	ID             uuid.UUID
+	TenantID       string
+	Items          []Item
+	Total          Money
+	TaxRate        int64 // basis points
+	Tax            Money
+	Status         Status
+	UserID         uuid.UUID
+	BillingProfile *BillingProfile
+	Cancellation   *Cancellation
+	Approval       *CloseApproval
+	CreditLimit    *CreditLimit
+	PaymentTerms   *PaymentTerms
+	DueAt          *time.Time // set once a bill with payment terms is closed
+	Collection     CollectionStatus
+	LateFees       []LateFee         // charged while the bill is overdue
+	CreditApplied  Money             // prepaid credit drawn down when the bill was closed
+	ExternalRef    string            // the customer's own reference, e.g. an order ID
+	Metadata       map[string]string // the customer's own labels, e.g. cost-center codes and notes
+	Version        int
+	Revision       int        // incremented by every change recorded in the bill history
+	AmendsBillID   *uuid.UUID // previous version of an amended bill
+	AmendedByID    *uuid.UUID // next version, if this bill was amended
+	Events         []Event
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ClosedAt       time.Time
+}
+
+// BillingProfile is a snapshot of the customer's billing details,
+// taken when a bill is closed so invoices are unaffected by later profile edits.
+type BillingProfile struct {
+	Name              string
+	Address           Address
+	TaxID             string
+	PreferredCurrency string
+	Locale            string
+}
+
+// Cancellation records why and by whom a bill was voided.
+type Cancellation struct {
+	Reason      string
+	ActorID     string
+	CancelledAt time.Time
+}
+
+// CloseApproval is a request to close a bill whose total exceeds the approval limit,
+// which a second approver has to accept before its deadline.
+type CloseApproval struct {
+	RequestID   string
+	RequestedBy string
+	RequestedAt time.Time
+	Limit       Money
+	Deadline    time.Time
+	Approved    bool
+	DecidedBy   string // empty if the request timed out
+	DecidedAt   time.Time
+	Comment     string
+}
+
+type Address struct {
+	Line1      string
+	Line2      string
+	City       string
+	PostalCode string
+	Country    string
 }
 
 type Item struct {
@@ -25,8 +83,27 @@ type Item struct {
 	Quantity     int64
 	Description  string
 	PricePerUnit Money
+	Metadata     *ItemMetadata
+	Version      int // incremented on every change of the item, starting at 1
 }
 
+// ItemUpdate describes an update of a line item, made against the version of the item it was read at.
+// Fields left nil keep their current value.
+type ItemUpdate struct {
+	ID           uuid.UUID
+	Version      int
+	Quantity     *int64
+	Description  *string
+	PricePerUnit *Money
+	Metadata     *ItemMetadata // replaces the metadata of the item as a whole
+}
+
+// ErrItemNotFound is returned when a request names an item the bill does not have.
+var ErrItemNotFound = errors.New("item not found in bill")
+
+// ErrItemVersionConflict is returned when an item was changed after the version an update was made against.
+var ErrItemVersionConflict = errors.New("item was changed by another request")
+
 type MinorUnit int64
 
 type Money struct {
@@ -38,7 +115,9 @@ type Status string
 
 var BillOpen Status = "BillOpen"
 var BillClosing Status = "BillClosing"
+var BillAwaitingApproval Status = "BillAwaitingApproval"
 var BillClosed Status = "BillClosed"
+var BillCancelled Status = "BillCancelled"
 
 var ValidCurrency = map[string]struct{}{
 	"USD": {},
@@ -71,6 +150,7 @@ func NewBill(userID string, currency string) (*Bill, error) {
 		Items:     []Item{},
 		Total:     Money{Amount: 0, Currency: currency},
 		Status:    BillOpen,
+		Version:   1,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}, nil
@@ -101,11 +181,55 @@ func Convert(toCurrency string, fromCurrency string, amount MinorUnit) (MinorUni
 	return convertedAmount, nil
 }
 
+// IsFinal reports whether the bill has reached a status it can never leave.
+func (b *Bill) IsFinal() bool {
+	return b.Status == BillClosed || b.Status == BillCancelled
+}
+
+// Cancel voids an open bill, recording the reason and the user who cancelled it.
+// The bill keeps its items and totals, but is never payable.
+func (b *Bill) Cancel(reason string, actorID string, at time.Time) error {
+	if b.Status != BillOpen {
+		return fmt.Errorf("cannot cancel a bill with status %s", b.Status)
+	}
+	if reason == "" {
+		return errors.New("cancellation reason cannot be empty")
+	}
+
+	b.Status = BillCancelled
+	b.Cancellation = &Cancellation{Reason: reason, ActorID: actorID, CancelledAt: at}
+	b.UpdatedAt = at
+	return nil
+}
+
+// RequiresApproval reports whether closing the bill needs a second approver,
+// because its total converted to the currency of the limit exceeds the limit.
+// A limit of zero disables approvals.
+func (b *Bill) RequiresApproval(limit Money) (bool, error) {
+	if limit.Amount <= 0 {
+		return false, nil
+	}
+
+	total, err := Convert(limit.Currency, b.Total.Currency, b.Total.Amount)
+	if err != nil {
+		return false, err
+	}
+	return total > limit.Amount, nil
+}
+
 func (b *Bill) AddLineItem(itemToAdd Item) error {
-	if b.Status == BillClosed {
+	if b.IsFinal() {
 		return errors.New("cannot add item to a closed bill")
 	}
 
+	if err := itemToAdd.Metadata.Validate(); err != nil {
+		return err
+	}
+
+	if err := b.checkCreditLimit(itemToAdd); err != nil {
+		return err
+	}
+
 	for i, itemInBill := range b.Items {
 		if itemInBill.ID == itemToAdd.ID {
 			if itemInBill.PricePerUnit != itemToAdd.PricePerUnit {
@@ -113,14 +237,36 @@ func (b *Bill) AddLineItem(itemToAdd Item) error {
 			}
 
 			b.Items[i].Quantity += itemToAdd.Quantity
+			b.Items[i].Version++
 			return b.CalculateTotal()
 		}
 	}
+	itemToAdd.Version = 1
 	b.Items = append(b.Items, itemToAdd)
 
 	return b.CalculateTotal()
 }
 
+// AddLineItems adds several items to the bill at once, recording an item added event for each.
+// Either every item is added, or the bill is left unchanged and the error of the first item
+// that could not be added is returned.
+func (b *Bill) AddLineItems(items []Item, actorID string, requestID string, at time.Time) error {
+	itemsBefore := append([]Item{}, b.Items...)
+	eventsBefore, revisionBefore := b.Events, b.Revision
+	totalBefore, taxBefore := b.Total, b.Tax
+
+	for i := range items {
+		item := items[i]
+		total := b.Total
+		if err := b.AddLineItem(item); err != nil {
+			b.Items, b.Events, b.Revision, b.Total, b.Tax = itemsBefore, eventsBefore, revisionBefore, totalBefore, taxBefore
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+		b.Record(EventItemAdded, actorID, requestID, &item, total, at)
+	}
+	return nil
+}
+
 func (b *Bill) RemoveLineItem(itemToRemove Item) error {
 	found := false
 
@@ -132,6 +278,7 @@ func (b *Bill) RemoveLineItem(itemToRemove Item) error {
 			}
 
 			b.Items[i].Quantity -= itemToRemove.Quantity
+			b.Items[i].Version++
 			if b.Items[i].Quantity <= 0 {
 				b.Items = slices.Delete(b.Items, i, i+1)
 			}
@@ -140,9 +287,81 @@ func (b *Bill) RemoveLineItem(itemToRemove Item) error {
 	}
 
 	if !found {
-		return fmt.Errorf("item not found in bill")
+		return ErrItemNotFound
+	}
+
+	return b.CalculateTotal()
+}
+
+// UpdateLineItem changes the quantity, description or price of an item in place, keeping its ID,
+// and returns the item as updated. The change is rejected if the item was changed since the version
+// it names, or if it would take the bill over its credit limit.
+func (b *Bill) UpdateLineItem(update ItemUpdate) (Item, error) {
+	if b.IsFinal() {
+		return Item{}, errors.New("cannot update item of a closed bill")
+	}
+
+	i := slices.IndexFunc(b.Items, func(item Item) bool { return item.ID == update.ID })
+	if i < 0 {
+		return Item{}, ErrItemNotFound
+	}
+	current := b.Items[i]
+	if current.Version != update.Version {
+		return Item{}, fmt.Errorf("%w: item is at version %d, not %d", ErrItemVersionConflict, current.Version, update.Version)
+	}
+
+	updated := current
+	if update.Quantity != nil {
+		if *update.Quantity < 1 {
+			return Item{}, fmt.Errorf("invalid item quantity: %d", *update.Quantity)
+		}
+		updated.Quantity = *update.Quantity
+	}
+	if update.Description != nil {
+		updated.Description = *update.Description
 	}
+	if update.PricePerUnit != nil {
+		if update.PricePerUnit.Amount < 0 {
+			return Item{}, fmt.Errorf("invalid price: %d", update.PricePerUnit.Amount)
+		}
+		if _, err := IsValidCurrency(update.PricePerUnit.Currency); err != nil {
+			return Item{}, err
+		}
+		updated.PricePerUnit = *update.PricePerUnit
+	}
+	if update.Metadata != nil {
+		if err := update.Metadata.Validate(); err != nil {
+			return Item{}, err
+		}
+		updated.Metadata = update.Metadata
+	}
+	updated.Version++
+
+	totalBefore, taxBefore := b.Total, b.Tax
+	if err := b.replaceLineItem(updated); err != nil {
+		b.Items[i], b.Total, b.Tax = current, totalBefore, taxBefore
+		return Item{}, err
+	}
+
+	// Only changes that raise the total can take the bill over its credit limit
+	if b.CreditLimit != nil && b.CreditLimit.Limit.Amount > 0 && b.Total.Amount > totalBefore.Amount {
+		if err := b.checkCreditLimitTotal(b.Total.Amount); err != nil {
+			b.Items[i], b.Total, b.Tax = current, totalBefore, taxBefore
+			return Item{}, err
+		}
+	}
+
+	return updated, nil
+}
 
+// Replace the item of the same ID with the given item, recalculating the bill total.
+func (b *Bill) replaceLineItem(item Item) error {
+	i := slices.IndexFunc(b.Items, func(itemInBill Item) bool { return itemInBill.ID == item.ID })
+	if i < 0 {
+		return ErrItemNotFound
+	}
+
+	b.Items[i] = item
 	return b.CalculateTotal()
 }
 
@@ -161,6 +380,13 @@ func (b *Bill) CalculateTotal() error {
 		total += unitPrice * MinorUnit(v.Quantity)
 	}
 	b.Total.Amount = total
+	b.Tax = Money{Amount: CalculateTax(total, b.TaxRate), Currency: b.Total.Currency}
 
 	return nil
 }
+
+// CalculateTax returns the tax due on an amount at a rate given in basis points,
+// rounded half up to the nearest minor unit.
+func CalculateTax(amount MinorUnit, rate int64) MinorUnit {
+	return (amount*MinorUnit(rate) + 5000) / 10000
+}
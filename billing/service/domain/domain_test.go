@@ -141,6 +141,33 @@ func TestRemoveLineItem(t *testing.T) {
 	}
 }
 
+func TestCalculateTax(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   MinorUnit
+		rate     int64
+		expected MinorUnit
+	}{
+		{"No Tax", 1000, 0, 0},
+		{"Eighteen Percent", 1000, 1800, 180},
+		{"Rounds Half Up", 25, 1800, 5},
+		{"Rounds Down", 22, 1800, 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CalculateTax(tc.amount, tc.rate))
+		})
+	}
+
+	// Tax follows the bill total as items change
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	bill.TaxRate = 1800
+	err := bill.AddLineItem(Item{ID: uuid.New(), Quantity: 2, PricePerUnit: Money{Amount: 500, Currency: "USD"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Money{Amount: 180, Currency: "USD"}, bill.Tax)
+}
+
 func TestBillTimestamps(t *testing.T) {
 	bill, _ := NewBill(uuid.New().String(), "USD")
 	tests := []struct {
@@ -158,3 +185,186 @@ func TestBillTimestamps(t *testing.T) {
 		})
 	}
 }
+
+func TestCancel(t *testing.T) {
+	now := time.Now()
+	actorID := uuid.New().String()
+
+	tests := []struct {
+		name      string
+		status    Status
+		reason    string
+		expectErr bool
+	}{
+		{"Cancel Open Bill", BillOpen, "Duplicate bill", false},
+		{"Missing Reason", BillOpen, "", true},
+		{"Cancel Closing Bill", BillClosing, "Duplicate bill", true},
+		{"Cancel Closed Bill", BillClosed, "Duplicate bill", true},
+		{"Cancel Cancelled Bill", BillCancelled, "Duplicate bill", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bill, _ := NewBill(uuid.New().String(), "USD")
+			bill.Status = tc.status
+
+			err := bill.Cancel(tc.reason, actorID, now)
+			if tc.expectErr {
+				assert.Error(t, err)
+				assert.Equal(t, tc.status, bill.Status)
+				assert.Nil(t, bill.Cancellation)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, BillCancelled, bill.Status)
+				assert.True(t, bill.IsFinal())
+				assert.Equal(t, &Cancellation{Reason: tc.reason, ActorID: actorID, CancelledAt: now}, bill.Cancellation)
+			}
+		})
+	}
+
+	// Cancelled bills no longer accept items
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	assert.NoError(t, bill.Cancel("Duplicate bill", actorID, now))
+	err := bill.AddLineItem(Item{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 50, Currency: "USD"}})
+	assert.Error(t, err)
+}
+
+func TestRequiresApproval(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     Money
+		limit     Money
+		expected  bool
+		expectErr bool
+	}{
+		{"Below Limit", Money{Amount: 50000, Currency: "USD"}, Money{Amount: 100000, Currency: "USD"}, false, false},
+		{"At Limit", Money{Amount: 100000, Currency: "USD"}, Money{Amount: 100000, Currency: "USD"}, false, false},
+		{"Above Limit", Money{Amount: 100001, Currency: "USD"}, Money{Amount: 100000, Currency: "USD"}, true, false},
+		{"Above Limit In Other Currency", Money{Amount: 400000, Currency: "GEL"}, Money{Amount: 100000, Currency: "USD"}, true, false},
+		{"Approvals Disabled", Money{Amount: 100001, Currency: "USD"}, Money{}, false, false},
+		{"Unknown Limit Currency", Money{Amount: 100, Currency: "USD"}, Money{Amount: 100000, Currency: "XYZ"}, false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bill := &Bill{Total: tc.total}
+
+			required, err := bill.RequiresApproval(tc.limit)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, required)
+			}
+		})
+	}
+}
+
+func TestAddLineItems(t *testing.T) {
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	existing := Item{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 100, Currency: "USD"}}
+	assert.NoError(t, bill.AddLineItem(existing))
+
+	items := []Item{
+		{ID: existing.ID, Quantity: 2, PricePerUnit: existing.PricePerUnit},
+		{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 275, Currency: "GEL"}},
+	}
+	assert.NoError(t, bill.AddLineItems(items, "actor", "request", at))
+	assert.Len(t, bill.Items, 2)
+	assert.Equal(t, MinorUnit(400), bill.Total.Amount)
+	if assert.Len(t, bill.Events, 2) {
+		assert.Equal(t, MinorUnit(300), bill.Events[0].TotalAfter.Amount)
+		assert.Equal(t, MinorUnit(300), bill.Events[1].TotalBefore.Amount)
+		assert.Equal(t, "request", bill.Events[1].RequestID)
+	}
+
+	// A batch with an invalid item leaves the bill unchanged
+	invalid := []Item{
+		{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 100, Currency: "USD"}},
+		{ID: existing.ID, Quantity: 1, PricePerUnit: Money{Amount: 999, Currency: "USD"}},
+	}
+	err := bill.AddLineItems(invalid, "actor", "request", at)
+	assert.ErrorContains(t, err, "item 2")
+	assert.Len(t, bill.Items, 2)
+	assert.Equal(t, int64(3), bill.Items[0].Quantity)
+	assert.Equal(t, MinorUnit(400), bill.Total.Amount)
+	assert.Len(t, bill.Events, 2)
+	assert.Equal(t, 2, bill.Revision)
+}
+
+func TestUpdateLineItem(t *testing.T) {
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	item := Item{ID: uuid.New(), Quantity: 2, Description: "Seat", PricePerUnit: Money{Amount: 100, Currency: "USD"}}
+	assert.NoError(t, bill.AddLineItem(item))
+	assert.Equal(t, 1, bill.Items[0].Version)
+
+	quantity := int64(5)
+	description := "Premium seat"
+	price := Money{Amount: 550, Currency: "GEL"}
+	negative := Money{Amount: -1, Currency: "USD"}
+	zero := int64(0)
+
+	tests := []struct {
+		name   string
+		update ItemUpdate
+		err    error
+	}{
+		{"Unknown Item", ItemUpdate{ID: uuid.New(), Version: 1, Quantity: &quantity}, ErrItemNotFound},
+		{"Stale Version", ItemUpdate{ID: item.ID, Version: 0, Quantity: &quantity}, ErrItemVersionConflict},
+		{"Invalid Quantity", ItemUpdate{ID: item.ID, Version: 1, Quantity: &zero}, nil},
+		{"Invalid Price", ItemUpdate{ID: item.ID, Version: 1, PricePerUnit: &negative}, nil},
+		{"Invalid Metadata", ItemUpdate{ID: item.ID, Version: 1, Metadata: &ItemMetadata{Attributes: map[string]string{"": "eu"}}}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := bill.UpdateLineItem(tc.update)
+			assert.Error(t, err)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			}
+			assert.Equal(t, item.Quantity, bill.Items[0].Quantity)
+			assert.Equal(t, 1, bill.Items[0].Version)
+		})
+	}
+
+	// Only the given fields change, and the item moves to the next version
+	updated, err := bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 1, Quantity: &quantity, Description: &description})
+	assert.NoError(t, err)
+	assert.Equal(t, Item{ID: item.ID, Quantity: 5, Description: "Premium seat", PricePerUnit: item.PricePerUnit, Version: 2}, updated)
+	assert.Equal(t, []Item{updated}, bill.Items)
+	assert.Equal(t, MinorUnit(500), bill.Total.Amount)
+
+	// A price in another currency is converted into the bill total
+	updated, err = bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 2, PricePerUnit: &price})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updated.Version)
+	assert.Equal(t, MinorUnit(1000), bill.Total.Amount)
+
+	// Raising the total over the credit limit leaves the item unchanged
+	bill.CreditLimit = &CreditLimit{Limit: Money{Amount: 1200, Currency: "USD"}}
+	more := int64(7)
+	_, err = bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 3, Quantity: &more})
+	assert.ErrorIs(t, err, ErrCreditLimitExceeded)
+	assert.Equal(t, updated, bill.Items[0])
+	assert.Equal(t, MinorUnit(1000), bill.Total.Amount)
+
+	// Lowering it is allowed even over the limit
+	bill.CreditLimit.Limit.Amount = 100
+	less := int64(1)
+	_, err = bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 3, Quantity: &less})
+	assert.NoError(t, err)
+	assert.Equal(t, MinorUnit(200), bill.Total.Amount)
+
+	// Metadata is replaced as a whole
+	metadata := &ItemMetadata{Category: "seats"}
+	updated, err = bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 4, Metadata: metadata})
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, updated.Metadata)
+	assert.Equal(t, int64(1), updated.Quantity)
+
+	// Items of closed bills cannot be updated
+	bill.Status = BillClosed
+	_, err = bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 5, Quantity: &quantity})
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+var EventBillCreated EventType = "bill_created"
+var EventItemAdded EventType = "item_added"
+var EventItemRemoved EventType = "item_removed"
+var EventItemUpdated EventType = "item_updated"
+var EventBillClosed EventType = "bill_closed"
+var EventBillCancelled EventType = "bill_cancelled"
+var EventBillAmended EventType = "bill_amended"
+var EventCloseApprovalRequested EventType = "close_approval_requested"
+var EventCloseApproved EventType = "close_approved"
+var EventCloseRejected EventType = "close_rejected"
+var EventCloseFailed EventType = "close_failed"
+var EventCreditLimitWarning EventType = "credit_limit_warning"
+var EventCreditLimitExceeded EventType = "credit_limit_exceeded"
+
+// Mutates reports whether events of the type record a change of the bill, which moves it to its next revision.
+// Credit limit warnings and rejected additions are only kept for the record.
+func (t EventType) Mutates() bool {
+	return t != EventCreditLimitWarning && t != EventCreditLimitExceeded
+}
+
+// Event records a single change of a bill, who made it and how it affected the bill total.
+// Events of a bill are numbered in the order they happened, starting at 1.
+type Event struct {
+	Sequence    int
+	Type        EventType
+	ActorID     string
+	RequestID   string
+	Item        *Item // item added or removed, or the item as updated, if any
+	TotalBefore Money
+	TotalAfter  Money
+	Threshold   int // percentage of the credit limit reached, for credit limit warnings
+	OccurredAt  time.Time
+}
+
+// Record appends an event to the bill's history, once the change it describes was applied to the bill,
+// and moves the bill to its next revision if the event mutates it.
+func (b *Bill) Record(eventType EventType, actorID string, requestID string, item *Item, totalBefore Money, at time.Time) {
+	if eventType.Mutates() {
+		b.Revision++
+	}
+	b.Events = append(b.Events, Event{
+		Sequence:    len(b.Events) + 1,
+		Type:        eventType,
+		ActorID:     actorID,
+		RequestID:   requestID,
+		Item:        item,
+		TotalBefore: totalBefore,
+		TotalAfter:  b.Total,
+		OccurredAt:  at,
+	})
+}
+
+// ErrStaleRevision is returned when a change was made against an earlier revision of the bill.
+var ErrStaleRevision = errors.New("bill was changed since it was read")
+
+// CheckRevision checks that the bill is still at the revision a change was made against.
+// A revision of zero makes the change unconditional.
+func (b *Bill) CheckRevision(expected int) error {
+	if expected != 0 && expected != b.Revision {
+		return fmt.Errorf("%w: bill is at revision %d, not %d", ErrStaleRevision, b.Revision, expected)
+	}
+	return nil
+}
+
+// Unrecord drops the latest event of the given type, for changes that were rolled back after being recorded,
+// returning the bill to the revision it had before.
+func (b *Bill) Unrecord(eventType EventType) {
+	if n := len(b.Events); n > 0 && b.Events[n-1].Type == eventType {
+		b.Events = b.Events[:n-1]
+		if eventType.Mutates() {
+			b.Revision--
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	bill, err := NewBill(uuid.New().String(), "USD")
+	assert.NoError(t, err)
+
+	actorID := uuid.NewString()
+	bill.Record(EventBillCreated, actorID, "", nil, bill.Total, time.Now())
+
+	item := Item{ID: uuid.New(), Quantity: 2, PricePerUnit: Money{Amount: 100, Currency: "USD"}}
+	before := bill.Total
+	assert.NoError(t, bill.AddLineItem(item))
+	assert.NoError(t, bill.CalculateTotal())
+	bill.Record(EventItemAdded, actorID, "request-1", &item, before, time.Now())
+
+	assert.Len(t, bill.Events, 2)
+	added := bill.Events[1]
+	assert.Equal(t, 2, added.Sequence)
+	assert.Equal(t, EventItemAdded, added.Type)
+	assert.Equal(t, "request-1", added.RequestID)
+	assert.Equal(t, MinorUnit(0), added.TotalBefore.Amount)
+	assert.Equal(t, MinorUnit(200), added.TotalAfter.Amount)
+	assert.Equal(t, 2, bill.Revision)
+
+	// Only the latest event is dropped, and only if it is of the given type
+	bill.Unrecord(EventBillClosed)
+	assert.Len(t, bill.Events, 2)
+	bill.Unrecord(EventItemAdded)
+	assert.Len(t, bill.Events, 1)
+	assert.Equal(t, EventBillCreated, bill.Events[0].Type)
+
+	// A rolled back change leaves the bill at the revision it had before
+	assert.Equal(t, 1, bill.Revision)
+
+	// Events kept only for the record do not change the revision
+	bill.Record(EventCreditLimitExceeded, actorID, "request-2", &item, bill.Total, time.Now())
+	assert.Equal(t, 1, bill.Revision)
+	bill.Unrecord(EventCreditLimitExceeded)
+	assert.Equal(t, 1, bill.Revision)
+}
+
+func TestCheckRevision(t *testing.T) {
+	bill, err := NewBill(uuid.New().String(), "USD")
+	assert.NoError(t, err)
+	bill.Record(EventBillCreated, uuid.NewString(), "", nil, bill.Total, time.Now())
+
+	assert.NoError(t, bill.CheckRevision(0))
+	assert.NoError(t, bill.CheckRevision(1))
+	assert.ErrorIs(t, bill.CheckRevision(2), ErrStaleRevision)
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format ledger exports are written in.
+type ExportFormat string
+
+// ExportCSV writes one row per posting, for spreadsheets and accounting imports.
+var ExportCSV ExportFormat = "csv"
+
+// ExportLedger writes a plain text journal in the format of ledger-cli, one transaction per entry
+// and one line per posting, under the account code and name.
+var ExportLedger ExportFormat = "ledger"
+
+var csvExportHeader = []string{
+	"date", "source", "source_key", "entry_id", "bill_id", "description",
+	"account_code", "account_name", "debit", "credit", "currency",
+}
+
+// ParseExportFormat returns the export format of the given name.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch ExportFormat(name) {
+	case ExportCSV, ExportLedger:
+		return ExportFormat(name), nil
+	}
+	return "", fmt.Errorf("unknown export format %s", name)
+}
+
+// ContentType returns the media type of exports in the format.
+func (f ExportFormat) ContentType() string {
+	if f == ExportCSV {
+		return "text/csv"
+	}
+	return "text/plain"
+}
+
+// WriteExport writes journal entries in the given format. Entries are written in the order they occurred,
+// tied by their source key, and postings in the order they were posted, so the same entries always
+// produce the same output.
+func WriteExport(w io.Writer, format ExportFormat, entries []JournalEntry) error {
+	sorted := append([]JournalEntry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].OccurredAt.Equal(sorted[j].OccurredAt) {
+			return sorted[i].OccurredAt.Before(sorted[j].OccurredAt)
+		}
+		return sorted[i].SourceKey < sorted[j].SourceKey
+	})
+
+	switch format {
+	case ExportCSV:
+		return writeCSVExport(w, sorted)
+	case ExportLedger:
+		return writeLedgerExport(w, sorted)
+	}
+	return fmt.Errorf("unknown export format %s", format)
+}
+
+func writeCSVExport(w io.Writer, entries []JournalEntry) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvExportHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		billID := ""
+		if entry.BillID != nil {
+			billID = entry.BillID.String()
+		}
+
+		for _, posting := range entry.Postings {
+			account, _ := LookupAccount(posting.AccountCode)
+			err := out.Write([]string{
+				entry.OccurredAt.UTC().Format(time.RFC3339),
+				string(entry.Source),
+				entry.SourceKey,
+				entry.ID.String(),
+				billID,
+				entry.Description,
+				posting.AccountCode,
+				account.Name,
+				FormatMinorUnits(posting.Debit),
+				FormatMinorUnits(posting.Credit),
+				posting.Currency,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func writeLedgerExport(w io.Writer, entries []JournalEntry) error {
+	for i, entry := range entries {
+		var b strings.Builder
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		// Descriptions are free text, so keep them to a single line
+		description := strings.Join(strings.Fields(entry.Description), " ")
+		fmt.Fprintf(&b, "%s * (%s) %s\n", entry.OccurredAt.UTC().Format("2006-01-02"), entry.SourceKey, description)
+		fmt.Fprintf(&b, "    ; entry: %s\n", entry.ID)
+		if entry.BillID != nil {
+			fmt.Fprintf(&b, "    ; bill: %s\n", entry.BillID)
+		}
+
+		for _, posting := range entry.Postings {
+			account, _ := LookupAccount(posting.AccountCode)
+			amount := posting.Debit - posting.Credit
+			fmt.Fprintf(&b, "    %-36s  %12s %s\n", posting.AccountCode+" "+account.Name, FormatMinorUnits(amount), posting.Currency)
+		}
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatMinorUnits formats an amount in minor units as a decimal of the major unit, e.g. 12345 as 123.45.
+// Every supported currency has a hundred minor units to the major unit.
+func FormatMinorUnits(amount MinorUnit) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
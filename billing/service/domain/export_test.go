@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportEntries() []JournalEntry {
+	billID := uuid.MustParse("0194d3a0-0000-7000-8000-000000000001")
+	closedAt := time.Date(2025, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	closed := NewJournalEntry("default", JournalBillClosed, billID.String(), &billID, "Bill closed", closedAt)
+	closed.ID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	closed.Post(AccountReceivable, Money{Amount: 1100, Currency: "USD"})
+	closed.Post(AccountSales, Money{Amount: -1000, Currency: "USD"})
+	closed.Post(AccountTaxPayable, Money{Amount: -100, Currency: "USD"})
+
+	paid := NewJournalEntry("default", JournalPayment, billID.String(), &billID, "Payment received", closedAt.Add(48*time.Hour))
+	paid.ID = uuid.MustParse("00000000-0000-4000-8000-000000000002")
+	paid.Post(AccountCash, Money{Amount: 1100, Currency: "USD"})
+	paid.Post(AccountReceivable, Money{Amount: -1100, Currency: "USD"})
+
+	return []JournalEntry{*paid, *closed}
+}
+
+func TestWriteExportCSV(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, WriteExport(&out, ExportCSV, exportEntries()))
+
+	// Entries are written in the order they occurred, one row per posting
+	expected := "date,source,source_key,entry_id,bill_id,description,account_code,account_name,debit,credit,currency\n" +
+		"2025-01-02T09:30:00Z,bill_closed,bill_closed:0194d3a0-0000-7000-8000-000000000001,00000000-0000-4000-8000-000000000001,0194d3a0-0000-7000-8000-000000000001,Bill closed,1100,Accounts receivable,11.00,0.00,USD\n" +
+		"2025-01-02T09:30:00Z,bill_closed,bill_closed:0194d3a0-0000-7000-8000-000000000001,00000000-0000-4000-8000-000000000001,0194d3a0-0000-7000-8000-000000000001,Bill closed,4000,Revenue,0.00,10.00,USD\n" +
+		"2025-01-02T09:30:00Z,bill_closed,bill_closed:0194d3a0-0000-7000-8000-000000000001,00000000-0000-4000-8000-000000000001,0194d3a0-0000-7000-8000-000000000001,Bill closed,2200,Tax payable,0.00,1.00,USD\n" +
+		"2025-01-04T09:30:00Z,payment,payment:0194d3a0-0000-7000-8000-000000000001,00000000-0000-4000-8000-000000000002,0194d3a0-0000-7000-8000-000000000001,Payment received,1000,Cash,11.00,0.00,USD\n" +
+		"2025-01-04T09:30:00Z,payment,payment:0194d3a0-0000-7000-8000-000000000001,00000000-0000-4000-8000-000000000002,0194d3a0-0000-7000-8000-000000000001,Payment received,1100,Accounts receivable,0.00,11.00,USD\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWriteExportLedger(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, WriteExport(&out, ExportLedger, exportEntries()))
+
+	expected := "2025-01-02 * (bill_closed:0194d3a0-0000-7000-8000-000000000001) Bill closed\n" +
+		"    ; entry: 00000000-0000-4000-8000-000000000001\n" +
+		"    ; bill: 0194d3a0-0000-7000-8000-000000000001\n" +
+		"    1100 Accounts receivable                     11.00 USD\n" +
+		"    4000 Revenue                                -10.00 USD\n" +
+		"    2200 Tax payable                             -1.00 USD\n" +
+		"\n" +
+		"2025-01-04 * (payment:0194d3a0-0000-7000-8000-000000000001) Payment received\n" +
+		"    ; entry: 00000000-0000-4000-8000-000000000002\n" +
+		"    ; bill: 0194d3a0-0000-7000-8000-000000000001\n" +
+		"    1000 Cash                                    11.00 USD\n" +
+		"    1100 Accounts receivable                    -11.00 USD\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWriteExportDeterministic(t *testing.T) {
+	entries := exportEntries()
+	reversed := []JournalEntry{entries[1], entries[0]}
+
+	// The same entries always produce the same output, whatever order they were read in
+	for _, format := range []ExportFormat{ExportCSV, ExportLedger} {
+		var a, b bytes.Buffer
+		assert.NoError(t, WriteExport(&a, format, entries))
+		assert.NoError(t, WriteExport(&b, format, reversed))
+		assert.Equal(t, a.String(), b.String())
+	}
+
+	var out bytes.Buffer
+	assert.Error(t, WriteExport(&out, ExportFormat("xml"), entries))
+}
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := ParseExportFormat("ledger")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportLedger, format)
+
+	_, err = ParseExportFormat("xlsx")
+	assert.Error(t, err)
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	assert.Equal(t, "0.00", FormatMinorUnits(0))
+	assert.Equal(t, "0.05", FormatMinorUnits(5))
+	assert.Equal(t, "123.45", FormatMinorUnits(12345))
+	assert.Equal(t, "-1.50", FormatMinorUnits(-150))
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LateFeeType sets how the late fees of an overdue bill are calculated.
+type LateFeeType string
+
+var LateFeeFlat LateFeeType = "flat"
+var LateFeePercentage LateFeeType = "percentage"
+var LateFeeDailyInterest LateFeeType = "daily_interest"
+
+// LateFeePolicy sets the fees charged on a closed bill that remains unpaid past its due date.
+// Flat and percentage fees are charged once the bill is overdue, while interest accrues for every full day
+// it stays overdue. Fees are converted to the currency of the bill.
+type LateFeePolicy struct {
+	Type   LateFeeType
+	Amount Money // flat fee
+	Rate   int64 // percentage fee, or interest per day, in basis points of the amount due
+	Cap    Money // most a bill is charged in late fees, uncapped if zero
+}
+
+// LateFee is a fee line item charged on an overdue bill, covering the fees accrued up to AccruedThrough.
+type LateFee struct {
+	ID             uuid.UUID
+	BillID         uuid.UUID
+	Type           LateFeeType
+	Description    string
+	Amount         Money
+	AccruedThrough time.Time
+	CreatedAt      time.Time
+}
+
+// DaysOverdue returns the number of full days elapsed since a bill fell due.
+func DaysOverdue(dueAt time.Time, at time.Time) int {
+	if !at.After(dueAt) {
+		return 0
+	}
+	return int(at.Sub(dueAt) / (24 * time.Hour))
+}
+
+// Accrued returns the total late fees owed, as of the given time, on an amount that fell due at dueAt.
+func (p LateFeePolicy) Accrued(amountDue Money, dueAt time.Time, at time.Time) (Money, error) {
+	accrued := Money{Currency: amountDue.Currency}
+	if !at.After(dueAt) {
+		return accrued, nil
+	}
+
+	switch p.Type {
+	case LateFeeFlat:
+		amount, err := Convert(amountDue.Currency, p.Amount.Currency, p.Amount.Amount)
+		if err != nil {
+			return accrued, err
+		}
+		accrued.Amount = amount
+	case LateFeePercentage:
+		accrued.Amount = CalculateTax(amountDue.Amount, p.Rate)
+	case LateFeeDailyInterest:
+		accrued.Amount = CalculateTax(amountDue.Amount*MinorUnit(DaysOverdue(dueAt, at)), p.Rate)
+	default:
+		return accrued, fmt.Errorf("unknown late fee type %s", p.Type)
+	}
+
+	if p.Cap.Amount > 0 {
+		limit, err := Convert(amountDue.Currency, p.Cap.Currency, p.Cap.Amount)
+		if err != nil {
+			return accrued, err
+		}
+		accrued.Amount = min(accrued.Amount, limit)
+	}
+	return accrued, nil
+}
+
+// NextLateFee returns the fee line item to charge on an overdue bill, as of the given time,
+// for the fees accrued beyond those already charged. Returns nil if nothing more is owed.
+func (p LateFeePolicy) NextLateFee(billID uuid.UUID, amountDue Money, dueAt time.Time, charged Money, at time.Time) (*LateFee, error) {
+	accrued, err := p.Accrued(amountDue, dueAt, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if accrued.Amount <= charged.Amount {
+		return nil, nil
+	}
+
+	description := "Late fee"
+	accruedThrough := at
+	switch p.Type {
+	case LateFeePercentage:
+		description = fmt.Sprintf("Late fee of %s%% of the amount due", formatBasisPoints(p.Rate))
+	case LateFeeDailyInterest:
+		days := DaysOverdue(dueAt, at)
+		description = fmt.Sprintf("Interest at %s%% per day for %d days overdue", formatBasisPoints(p.Rate), days)
+		accruedThrough = dueAt.Add(time.Duration(days) * 24 * time.Hour)
+	}
+
+	return &LateFee{
+		ID:             uuid.New(),
+		BillID:         billID,
+		Type:           p.Type,
+		Description:    description,
+		Amount:         Money{Amount: accrued.Amount - charged.Amount, Currency: accrued.Currency},
+		AccruedThrough: accruedThrough,
+		CreatedAt:      at,
+	}, nil
+}
+
+// Format a rate given in basis points as a percentage, e.g. 150 as 1.5.
+func formatBasisPoints(rate int64) string {
+	s := fmt.Sprintf("%d.%02d", rate/100, rate%100)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
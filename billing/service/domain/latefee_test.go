@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLateFeeAccrued(t *testing.T) {
+	dueAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	amountDue := Money{Amount: 10000, Currency: "USD"}
+
+	tests := []struct {
+		name     string
+		policy   LateFeePolicy
+		at       time.Time
+		expected MinorUnit
+	}{
+		{
+			name:     "Not Yet Overdue",
+			policy:   LateFeePolicy{Type: LateFeeFlat, Amount: Money{Amount: 500, Currency: "USD"}},
+			at:       dueAt,
+			expected: 0,
+		},
+		{
+			name:     "Flat Fee",
+			policy:   LateFeePolicy{Type: LateFeeFlat, Amount: Money{Amount: 500, Currency: "USD"}},
+			at:       dueAt.Add(time.Hour),
+			expected: 500,
+		},
+		{
+			name:     "Flat Fee Converted To Bill Currency",
+			policy:   LateFeePolicy{Type: LateFeeFlat, Amount: Money{Amount: 275, Currency: "GEL"}},
+			at:       dueAt.Add(time.Hour),
+			expected: 100,
+		},
+		{
+			name:     "Percentage Fee",
+			policy:   LateFeePolicy{Type: LateFeePercentage, Rate: 150},
+			at:       dueAt.Add(40 * 24 * time.Hour),
+			expected: 150,
+		},
+		{
+			name:     "Daily Interest Over Full Days",
+			policy:   LateFeePolicy{Type: LateFeeDailyInterest, Rate: 10},
+			at:       dueAt.Add(3*24*time.Hour + 12*time.Hour),
+			expected: 30,
+		},
+		{
+			name:     "Daily Interest Capped",
+			policy:   LateFeePolicy{Type: LateFeeDailyInterest, Rate: 10, Cap: Money{Amount: 250, Currency: "USD"}},
+			at:       dueAt.Add(365 * 24 * time.Hour),
+			expected: 250,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accrued, err := tt.policy.Accrued(amountDue, dueAt, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, Money{Amount: tt.expected, Currency: "USD"}, accrued)
+		})
+	}
+
+	_, err := LateFeePolicy{Type: "compound"}.Accrued(amountDue, dueAt, dueAt.Add(time.Hour))
+	assert.Error(t, err)
+}
+
+func TestNextLateFee(t *testing.T) {
+	billID := uuid.New()
+	dueAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	amountDue := Money{Amount: 10000, Currency: "USD"}
+	policy := LateFeePolicy{Type: LateFeeDailyInterest, Rate: 10}
+
+	// Interest is charged up to the last full day overdue
+	at := dueAt.Add(2*24*time.Hour + time.Hour)
+	fee, err := policy.NextLateFee(billID, amountDue, dueAt, Money{Currency: "USD"}, at)
+	assert.NoError(t, err)
+	if assert.NotNil(t, fee) {
+		assert.Equal(t, billID, fee.BillID)
+		assert.Equal(t, LateFeeDailyInterest, fee.Type)
+		assert.Equal(t, Money{Amount: 20, Currency: "USD"}, fee.Amount)
+		assert.Equal(t, dueAt.Add(2*24*time.Hour), fee.AccruedThrough)
+		assert.Equal(t, "Interest at 0.1% per day for 2 days overdue", fee.Description)
+	}
+
+	// Only interest accrued since the fees already charged is charged again
+	fee, err = policy.NextLateFee(billID, amountDue, dueAt, Money{Amount: 20, Currency: "USD"}, at.Add(24*time.Hour))
+	assert.NoError(t, err)
+	if assert.NotNil(t, fee) {
+		assert.Equal(t, Money{Amount: 10, Currency: "USD"}, fee.Amount)
+	}
+
+	// Nothing more is owed within the same day
+	fee, err = policy.NextLateFee(billID, amountDue, dueAt, Money{Amount: 20, Currency: "USD"}, at)
+	assert.NoError(t, err)
+	assert.Nil(t, fee)
+
+	// One-off fees are charged once
+	flat := LateFeePolicy{Type: LateFeeFlat, Amount: Money{Amount: 500, Currency: "USD"}}
+	fee, err = flat.NextLateFee(billID, amountDue, dueAt, Money{Amount: 500, Currency: "USD"}, at)
+	assert.NoError(t, err)
+	assert.Nil(t, fee)
+}
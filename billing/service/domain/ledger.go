@@ -0,0 +1,320 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountType classifies a ledger account, which sets whether debits or credits increase its balance.
+type AccountType string
+
+var AccountAsset AccountType = "asset"
+var AccountLiability AccountType = "liability"
+var AccountRevenue AccountType = "revenue"
+var AccountExpense AccountType = "expense"
+
+// Account is an account of the general ledger that journal entries post to.
+type Account struct {
+	Code string
+	Name string
+	Type AccountType
+}
+
+var AccountCash = Account{Code: "1000", Name: "Cash", Type: AccountAsset}
+var AccountReceivable = Account{Code: "1100", Name: "Accounts receivable", Type: AccountAsset}
+var AccountCustomerCredit = Account{Code: "2100", Name: "Customer prepaid credit", Type: AccountLiability}
+var AccountTaxPayable = Account{Code: "2200", Name: "Tax payable", Type: AccountLiability}
+var AccountSales = Account{Code: "4000", Name: "Revenue", Type: AccountRevenue}
+var AccountLateFeeIncome = Account{Code: "4100", Name: "Late fee income", Type: AccountRevenue}
+var AccountBadDebt = Account{Code: "5100", Name: "Bad debt expense", Type: AccountExpense}
+
+// ChartOfAccounts lists every account billing money movements are posted to.
+var ChartOfAccounts = []Account{
+	AccountCash,
+	AccountReceivable,
+	AccountCustomerCredit,
+	AccountTaxPayable,
+	AccountSales,
+	AccountLateFeeIncome,
+	AccountBadDebt,
+}
+
+// LookupAccount returns the account of the chart of accounts with the given code.
+func LookupAccount(code string) (Account, bool) {
+	for _, account := range ChartOfAccounts {
+		if account.Code == code {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+// DebitNormal reports whether debits increase the balance of the account, as they do for assets and expenses.
+func (a Account) DebitNormal() bool {
+	return a.Type == AccountAsset || a.Type == AccountExpense
+}
+
+// Balance returns the balance of the account resulting from the given debits and credits.
+func (a Account) Balance(debit MinorUnit, credit MinorUnit) MinorUnit {
+	if a.DebitNormal() {
+		return debit - credit
+	}
+	return credit - debit
+}
+
+// JournalSource is the kind of money movement a journal entry records.
+type JournalSource string
+
+var JournalBillClosed JournalSource = "bill_closed"
+var JournalCreditGranted JournalSource = "credit_granted"
+var JournalCreditApplied JournalSource = "credit_applied"
+var JournalLateFee JournalSource = "late_fee"
+var JournalPayment JournalSource = "payment"
+var JournalWriteOff JournalSource = "write_off"
+var JournalAmendment JournalSource = "amendment"
+
+// JournalEntry records a money movement as postings to ledger accounts, whose debits and credits balance.
+// Its source key is unique within the tenant, so a movement is never journaled twice.
+type JournalEntry struct {
+	ID          uuid.UUID
+	TenantID    string
+	Source      JournalSource
+	SourceKey   string
+	BillID      *uuid.UUID
+	Description string
+	Postings    []Posting
+	OccurredAt  time.Time
+}
+
+// Posting debits or credits a single account of a journal entry.
+type Posting struct {
+	AccountCode string
+	Debit       MinorUnit
+	Credit      MinorUnit
+	Currency    string
+}
+
+// ErrUnbalancedEntry is returned for journal entries whose debits and credits differ.
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+// NewJournalEntry returns an entry without postings for a movement of the given source.
+func NewJournalEntry(tenantID string, source JournalSource, sourceKey string, billID *uuid.UUID, description string, at time.Time) *JournalEntry {
+	return &JournalEntry{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Source:      source,
+		SourceKey:   string(source) + ":" + sourceKey,
+		BillID:      billID,
+		Description: description,
+		OccurredAt:  at,
+	}
+}
+
+// Post adds a posting of a signed amount to an account: positive amounts are debited, negative ones credited.
+// Zero amounts are not posted.
+func (e *JournalEntry) Post(account Account, amount Money) {
+	switch {
+	case amount.Amount > 0:
+		e.Postings = append(e.Postings, Posting{AccountCode: account.Code, Debit: amount.Amount, Currency: amount.Currency})
+	case amount.Amount < 0:
+		e.Postings = append(e.Postings, Posting{AccountCode: account.Code, Credit: -amount.Amount, Currency: amount.Currency})
+	}
+}
+
+// Validate checks that every posting goes to a known account, and that debits equal credits in every currency.
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: %d postings", ErrUnbalancedEntry, len(e.Postings))
+	}
+
+	net := map[string]MinorUnit{}
+	for _, posting := range e.Postings {
+		if _, ok := LookupAccount(posting.AccountCode); !ok {
+			return fmt.Errorf("unknown account %s", posting.AccountCode)
+		}
+		if posting.Debit < 0 || posting.Credit < 0 || (posting.Debit == 0) == (posting.Credit == 0) {
+			return fmt.Errorf("posting to %s must either debit or credit a positive amount", posting.AccountCode)
+		}
+		net[posting.Currency] += posting.Debit - posting.Credit
+	}
+
+	for currency, amount := range net {
+		if amount != 0 {
+			return fmt.Errorf("%w: debits exceed credits by %d %s", ErrUnbalancedEntry, amount, currency)
+		}
+	}
+	return nil
+}
+
+// BillClosedEntry journals the amount due on a closed bill as receivable, split into revenue and tax.
+func BillClosedEntry(bill *Bill) *JournalEntry {
+	entry := NewJournalEntry(bill.TenantID, JournalBillClosed, bill.ID.String(), &bill.ID, "Bill closed", bill.ClosedAt)
+	entry.Post(AccountReceivable, Money{Amount: bill.Total.Amount + bill.Tax.Amount, Currency: bill.Total.Currency})
+	entry.Post(AccountSales, Money{Amount: -bill.Total.Amount, Currency: bill.Total.Currency})
+	entry.Post(AccountTaxPayable, Money{Amount: -bill.Tax.Amount, Currency: bill.Tax.Currency})
+	return entry
+}
+
+// CreditGrantedEntry journals prepaid credit received from a customer as owed back to them in credit.
+func CreditGrantedEntry(tenantID string, grant *WalletEntry) *JournalEntry {
+	entry := NewJournalEntry(tenantID, JournalCreditGranted, grant.ID.String(), nil, "Prepaid credit granted", grant.CreatedAt)
+	entry.Post(AccountCash, grant.Amount)
+	entry.Post(AccountCustomerCredit, Money{Amount: -grant.Amount.Amount, Currency: grant.Amount.Currency})
+	return entry
+}
+
+// CreditAppliedEntry journals prepaid credit drawn down against the amount due on a closed bill.
+func CreditAppliedEntry(bill *Bill, applied Money) *JournalEntry {
+	entry := NewJournalEntry(bill.TenantID, JournalCreditApplied, bill.ID.String(), &bill.ID, "Prepaid credit applied", bill.ClosedAt)
+	entry.Post(AccountCustomerCredit, applied)
+	entry.Post(AccountReceivable, Money{Amount: -applied.Amount, Currency: applied.Currency})
+	return entry
+}
+
+// LateFeeEntry journals a late fee charged on an overdue bill as receivable.
+func LateFeeEntry(tenantID string, fee *LateFee) *JournalEntry {
+	entry := NewJournalEntry(tenantID, JournalLateFee, fee.ID.String(), &fee.BillID, fee.Description, fee.CreatedAt)
+	entry.Post(AccountReceivable, fee.Amount)
+	entry.Post(AccountLateFeeIncome, Money{Amount: -fee.Amount.Amount, Currency: fee.Amount.Currency})
+	return entry
+}
+
+// SettlementEntry journals the balance still due on a bill as collected once the bill is paid,
+// or as a bad debt once it is written off as uncollectible.
+func SettlementEntry(bill *Bill, status CollectionStatus, balance Money, at time.Time) (*JournalEntry, error) {
+	var entry *JournalEntry
+	switch status {
+	case CollectionPaid:
+		entry = NewJournalEntry(bill.TenantID, JournalPayment, bill.ID.String(), &bill.ID, "Payment received", at)
+		entry.Post(AccountCash, balance)
+	case CollectionUncollectible:
+		entry = NewJournalEntry(bill.TenantID, JournalWriteOff, bill.ID.String(), &bill.ID, "Written off as uncollectible", at)
+		entry.Post(AccountBadDebt, balance)
+	default:
+		return nil, fmt.Errorf("bills are not settled as %s", status)
+	}
+
+	entry.Post(AccountReceivable, Money{Amount: -balance.Amount, Currency: balance.Currency})
+	return entry, nil
+}
+
+// AmendmentEntry journals the credit or debit note issued by an amendment,
+// adjusting revenue and tax by the difference between both versions of the bill.
+// Credit notes stand in for refunds, which are never paid out through billing.
+func AmendmentEntry(original *Bill, amended *Bill, amendment *Amendment) *JournalEntry {
+	currency := amended.Total.Currency
+	revenue := amended.Total.Amount - original.Total.Amount
+	tax := amended.Tax.Amount - original.Tax.Amount
+
+	description := fmt.Sprintf("Amendment to version %d", amendment.Version)
+	entry := NewJournalEntry(amendment.TenantID, JournalAmendment, amendment.ID.String(), &amended.ID, description, amendment.CreatedAt)
+	entry.Post(AccountReceivable, Money{Amount: revenue + tax, Currency: currency})
+	entry.Post(AccountSales, Money{Amount: -revenue, Currency: currency})
+	entry.Post(AccountTaxPayable, Money{Amount: -tax, Currency: currency})
+	return entry
+}
+
+// AccountTotal sums the debits and credits posted to an account in a single currency.
+type AccountTotal struct {
+	AccountCode string
+	Debit       MinorUnit
+	Credit      MinorUnit
+}
+
+// TrialBalance lists the balance of every account with postings in a currency up to a point in time.
+// The ledger is in balance when total debits equal total credits.
+type TrialBalance struct {
+	Currency    string
+	AsOf        time.Time
+	Lines       []TrialBalanceLine
+	TotalDebit  MinorUnit
+	TotalCredit MinorUnit
+	Balanced    bool
+}
+
+type TrialBalanceLine struct {
+	Account Account
+	Debit   MinorUnit
+	Credit  MinorUnit
+	Balance MinorUnit // in the normal direction of the account
+}
+
+// NewTrialBalance builds the trial balance of a currency from the totals posted to each account, in chart order.
+func NewTrialBalance(currency string, asOf time.Time, totals []AccountTotal) (*TrialBalance, error) {
+	byCode := map[string]AccountTotal{}
+	for _, total := range totals {
+		if _, ok := LookupAccount(total.AccountCode); !ok {
+			return nil, fmt.Errorf("unknown account %s", total.AccountCode)
+		}
+		byCode[total.AccountCode] = total
+	}
+
+	tb := &TrialBalance{Currency: currency, AsOf: asOf, Lines: []TrialBalanceLine{}}
+	for _, account := range ChartOfAccounts {
+		total, ok := byCode[account.Code]
+		if !ok {
+			continue
+		}
+
+		tb.Lines = append(tb.Lines, TrialBalanceLine{
+			Account: account,
+			Debit:   total.Debit,
+			Credit:  total.Credit,
+			Balance: account.Balance(total.Debit, total.Credit),
+		})
+		tb.TotalDebit += total.Debit
+		tb.TotalCredit += total.Credit
+	}
+	tb.Balanced = tb.TotalDebit == tb.TotalCredit
+
+	return tb, nil
+}
+
+// AccountStatement lists the postings to an account in a currency over a period,
+// with the balance of the account before, after and running through them.
+type AccountStatement struct {
+	Account        Account
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance MinorUnit
+	Lines          []StatementLine
+	ClosingBalance MinorUnit
+}
+
+type StatementLine struct {
+	EntryID     uuid.UUID
+	Source      JournalSource
+	BillID      *uuid.UUID
+	Description string
+	Debit       MinorUnit
+	Credit      MinorUnit
+	Balance     MinorUnit
+	OccurredAt  time.Time
+}
+
+// NewAccountStatement builds the statement of an account from the totals posted before the period
+// and the postings within it, in the order they occurred.
+func NewAccountStatement(account Account, currency string, from time.Time, to time.Time, opening AccountTotal, lines []StatementLine) *AccountStatement {
+	statement := &AccountStatement{
+		Account:        account,
+		Currency:       currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: account.Balance(opening.Debit, opening.Credit),
+		Lines:          []StatementLine{},
+	}
+
+	balance := statement.OpeningBalance
+	for _, line := range lines {
+		balance += account.Balance(line.Debit, line.Credit)
+		line.Balance = balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+
+	return statement
+}
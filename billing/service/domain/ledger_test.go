@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Sum the debits and credits posted to each account by the given entries.
+func postedTotals(entries ...*JournalEntry) []AccountTotal {
+	var totals []AccountTotal
+	index := map[string]int{}
+	for _, entry := range entries {
+		for _, posting := range entry.Postings {
+			i, ok := index[posting.AccountCode]
+			if !ok {
+				i = len(totals)
+				index[posting.AccountCode] = i
+				totals = append(totals, AccountTotal{AccountCode: posting.AccountCode})
+			}
+			totals[i].Debit += posting.Debit
+			totals[i].Credit += posting.Credit
+		}
+	}
+	return totals
+}
+
+func TestJournalEntryValidate(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entry := NewJournalEntry("default", JournalPayment, "key", nil, "Payment", at)
+	entry.Post(AccountCash, Money{Amount: 100, Currency: "USD"})
+	entry.Post(AccountReceivable, Money{Amount: -100, Currency: "USD"})
+	assert.NoError(t, entry.Validate())
+	assert.Equal(t, "payment:key", entry.SourceKey)
+
+	// Debits and credits must match in every currency
+	unbalanced := NewJournalEntry("default", JournalPayment, "key", nil, "Payment", at)
+	unbalanced.Post(AccountCash, Money{Amount: 100, Currency: "USD"})
+	unbalanced.Post(AccountReceivable, Money{Amount: -100, Currency: "GEL"})
+	assert.True(t, errors.Is(unbalanced.Validate(), ErrUnbalancedEntry))
+
+	// Zero amounts are not posted, leaving a single posting
+	single := NewJournalEntry("default", JournalPayment, "key", nil, "Payment", at)
+	single.Post(AccountCash, Money{Amount: 0, Currency: "USD"})
+	single.Post(AccountReceivable, Money{Amount: 0, Currency: "USD"})
+	assert.Empty(t, single.Postings)
+	assert.Error(t, single.Validate())
+
+	unknown := &JournalEntry{Postings: []Posting{
+		{AccountCode: "9999", Debit: 100, Currency: "USD"},
+		{AccountCode: AccountCash.Code, Credit: 100, Currency: "USD"},
+	}}
+	assert.Error(t, unknown.Validate())
+
+	both := &JournalEntry{Postings: []Posting{
+		{AccountCode: AccountCash.Code, Debit: 100, Credit: 100, Currency: "USD"},
+		{AccountCode: AccountReceivable.Code, Debit: 100, Credit: 100, Currency: "USD"},
+	}}
+	assert.Error(t, both.Validate())
+}
+
+func TestBillingEntriesBalance(t *testing.T) {
+	bill, _ := NewBill(uuid.New().String(), "USD")
+	bill.TenantID = "default"
+	bill.TaxRate = 1000
+	assert.NoError(t, bill.AddLineItem(Item{ID: uuid.New(), Quantity: 2, PricePerUnit: Money{Amount: 500, Currency: "USD"}}))
+	bill.PaymentTerms = &PaymentTerms{DueIn: 24 * time.Hour}
+	bill.SetDueDate(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	closed := BillClosedEntry(bill)
+	applied, err := bill.ApplyCredit(Money{Amount: 300, Currency: "USD"})
+	assert.NoError(t, err)
+	credit := CreditAppliedEntry(bill, applied)
+	fee := LateFeeEntry(bill.TenantID, &LateFee{ID: uuid.New(), BillID: bill.ID, Description: "Late fee", Amount: Money{Amount: 50, Currency: "USD"}})
+	payment, err := SettlementEntry(bill, CollectionPaid, Money{Amount: 850, Currency: "USD"}, time.Now())
+	assert.NoError(t, err)
+	grant := CreditGrantedEntry(bill.TenantID, &WalletEntry{ID: uuid.New(), Amount: Money{Amount: 300, Currency: "USD"}})
+
+	for _, entry := range []*JournalEntry{closed, credit, fee, payment, grant} {
+		assert.NoError(t, entry.Validate(), entry.Source)
+	}
+
+	// Revenue and tax are split out of the amount due
+	assert.Equal(t, []Posting{
+		{AccountCode: AccountReceivable.Code, Debit: 1100, Currency: "USD"},
+		{AccountCode: AccountSales.Code, Credit: 1000, Currency: "USD"},
+		{AccountCode: AccountTaxPayable.Code, Credit: 100, Currency: "USD"},
+	}, closed.Postings)
+
+	// Once credit, fees and payment are journaled, nothing remains receivable
+	tb, err := NewTrialBalance("USD", time.Now(), postedTotals(closed, credit, fee, payment, grant))
+	assert.NoError(t, err)
+	assert.True(t, tb.Balanced)
+	for _, line := range tb.Lines {
+		switch line.Account {
+		case AccountReceivable:
+			assert.Zero(t, line.Balance)
+		case AccountCash:
+			assert.Equal(t, MinorUnit(1150), line.Balance)
+		case AccountCustomerCredit:
+			assert.Zero(t, line.Balance)
+		case AccountSales:
+			assert.Equal(t, MinorUnit(1000), line.Balance)
+		}
+	}
+
+	// Written off bills are settled as bad debt
+	writeOff, err := SettlementEntry(bill, CollectionUncollectible, Money{Amount: 850, Currency: "USD"}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, JournalWriteOff, writeOff.Source)
+	assert.Equal(t, AccountBadDebt.Code, writeOff.Postings[0].AccountCode)
+
+	_, err = SettlementEntry(bill, CollectionUnpaid, Money{Amount: 850, Currency: "USD"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestAmendmentEntry(t *testing.T) {
+	original, _ := NewBill(uuid.New().String(), "USD")
+	original.TenantID = "default"
+	original.TaxRate = 1000
+	item := Item{ID: uuid.New(), Quantity: 2, PricePerUnit: Money{Amount: 500, Currency: "USD"}}
+	assert.NoError(t, original.AddLineItem(item))
+	original.Status = BillClosed
+
+	// A credit note reverses revenue and tax for the removed quantity
+	item.Quantity = 1
+	amended, amendment, err := NewAmendment(original, []Item{item}, "Billed twice", "admin", time.Now())
+	assert.NoError(t, err)
+
+	entry := AmendmentEntry(original, amended, amendment)
+	assert.NoError(t, entry.Validate())
+	assert.Equal(t, []Posting{
+		{AccountCode: AccountReceivable.Code, Credit: 550, Currency: "USD"},
+		{AccountCode: AccountSales.Code, Debit: 500, Currency: "USD"},
+		{AccountCode: AccountTaxPayable.Code, Debit: 50, Currency: "USD"},
+	}, entry.Postings)
+}
+
+func TestNewTrialBalance(t *testing.T) {
+	tb, err := NewTrialBalance("USD", time.Now(), []AccountTotal{
+		{AccountCode: AccountSales.Code, Credit: 1000},
+		{AccountCode: AccountReceivable.Code, Debit: 1000, Credit: 400},
+		{AccountCode: AccountCash.Code, Debit: 400},
+	})
+	assert.NoError(t, err)
+	assert.True(t, tb.Balanced)
+	assert.Equal(t, MinorUnit(1400), tb.TotalDebit)
+
+	// Lines follow the chart of accounts, with balances in the normal direction of each account
+	if assert.Len(t, tb.Lines, 3) {
+		assert.Equal(t, AccountCash, tb.Lines[0].Account)
+		assert.Equal(t, MinorUnit(600), tb.Lines[1].Balance)
+		assert.Equal(t, MinorUnit(1000), tb.Lines[2].Balance)
+	}
+
+	_, err = NewTrialBalance("USD", time.Now(), []AccountTotal{{AccountCode: "9999"}})
+	assert.Error(t, err)
+}
+
+func TestNewAccountStatement(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(30 * 24 * time.Hour)
+
+	statement := NewAccountStatement(AccountReceivable, "USD", from, to, AccountTotal{Debit: 1000, Credit: 200}, []StatementLine{
+		{Source: JournalBillClosed, Debit: 500},
+		{Source: JournalPayment, Credit: 300},
+	})
+
+	assert.Equal(t, MinorUnit(800), statement.OpeningBalance)
+	assert.Equal(t, MinorUnit(1300), statement.Lines[0].Balance)
+	assert.Equal(t, MinorUnit(1000), statement.Lines[1].Balance)
+	assert.Equal(t, MinorUnit(1000), statement.ClosingBalance)
+
+	// Liabilities grow with credits
+	statement = NewAccountStatement(AccountCustomerCredit, "USD", from, to, AccountTotal{}, []StatementLine{{Source: JournalCreditGranted, Credit: 500}})
+	assert.Equal(t, MinorUnit(500), statement.ClosingBalance)
+}
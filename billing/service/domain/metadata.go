@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits on the metadata of an item, keeping it small enough to be stored and indexed with every item
+const (
+	maxMetadataLength = 64  // of categories, SKUs and attribute keys
+	maxAttributeValue = 256 // of attribute values
+	maxAttributes     = 20
+
+	maxBillMetadataValue = 1024 // of bill metadata values, which may hold notes
+)
+
+// ItemMetadata classifies a line item, so fees can be grouped and reported by type.
+// The service period is the time the item was billed for, which may differ from when it was added.
+type ItemMetadata struct {
+	Category    string
+	SKU         string
+	Attributes  map[string]string
+	PeriodStart *time.Time
+	PeriodEnd   *time.Time
+}
+
+// Validate checks that the metadata is within its limits and its service period is not reversed.
+func (m *ItemMetadata) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if len(m.Category) > maxMetadataLength {
+		return fmt.Errorf("category cannot exceed %d characters", maxMetadataLength)
+	}
+	if len(m.SKU) > maxMetadataLength {
+		return fmt.Errorf("SKU cannot exceed %d characters", maxMetadataLength)
+	}
+
+	if len(m.Attributes) > maxAttributes {
+		return fmt.Errorf("items cannot have more than %d attributes", maxAttributes)
+	}
+	for key, value := range m.Attributes {
+		if key == "" {
+			return errors.New("attribute keys cannot be empty")
+		}
+		if len(key) > maxMetadataLength {
+			return fmt.Errorf("attribute keys cannot exceed %d characters", maxMetadataLength)
+		}
+		if len(value) > maxAttributeValue {
+			return fmt.Errorf("value of attribute %s cannot exceed %d characters", key, maxAttributeValue)
+		}
+	}
+
+	if m.PeriodStart != nil && m.PeriodEnd != nil && m.PeriodEnd.Before(*m.PeriodStart) {
+		return errors.New("service period cannot end before it starts")
+	}
+	return nil
+}
+
+// ItemFilter selects the items of closed bills a report covers. Items are selected by the time their bill
+// was closed, and by any of their metadata that is set in the filter.
+type ItemFilter struct {
+	From       time.Time
+	To         time.Time
+	Category   string
+	SKU        string
+	Attributes map[string]string // items must have all of them
+	PeriodFrom *time.Time        // items whose service period overlaps the period from PeriodFrom to PeriodTo
+	PeriodTo   *time.Time
+}
+
+// ItemTotal sums the items of a category priced in a currency.
+// Items without a category are summed under an empty category.
+type ItemTotal struct {
+	Category string
+	Currency string
+	Lines    int   // items summed
+	Quantity int64 // units summed over the items
+	Amount   MinorUnit
+}
+
+// ValidateBillMetadata checks that the external reference and metadata of a bill are within their limits.
+func ValidateBillMetadata(externalRef string, metadata map[string]string) error {
+	if len(externalRef) > maxMetadataLength {
+		return fmt.Errorf("external reference cannot exceed %d characters", maxMetadataLength)
+	}
+
+	if len(metadata) > maxAttributes {
+		return fmt.Errorf("bills cannot have more than %d metadata keys", maxAttributes)
+	}
+	for key, value := range metadata {
+		if key == "" {
+			return errors.New("metadata keys cannot be empty")
+		}
+		if len(key) > maxMetadataLength {
+			return fmt.Errorf("metadata keys cannot exceed %d characters", maxMetadataLength)
+		}
+		if len(value) > maxBillMetadataValue {
+			return fmt.Errorf("value of metadata %s cannot exceed %d characters", key, maxBillMetadataValue)
+		}
+	}
+	return nil
+}
+
+// BillFilter selects the open and closed bills of a tenant a listing covers, by any of its fields that are set.
+type BillFilter struct {
+	UserID      *uuid.UUID
+	Status      Status
+	ExternalRef string
+	Metadata    map[string]string // bills must have all of them
+	Limit       int
+	Offset      int
+}
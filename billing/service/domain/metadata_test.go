@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemMetadataValidate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	tooManyAttributes := map[string]string{}
+	for i := 0; i <= maxAttributes; i++ {
+		tooManyAttributes[fmt.Sprintf("key%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name      string
+		metadata  *ItemMetadata
+		expectErr bool
+	}{
+		{"No Metadata", nil, false},
+		{"Valid", &ItemMetadata{Category: "usage", SKU: "API-CALLS", Attributes: map[string]string{"region": "eu"}, PeriodStart: &start, PeriodEnd: &end}, false},
+		{"Open Service Period", &ItemMetadata{PeriodStart: &start}, false},
+		{"Category Too Long", &ItemMetadata{Category: strings.Repeat("a", maxMetadataLength+1)}, true},
+		{"SKU Too Long", &ItemMetadata{SKU: strings.Repeat("a", maxMetadataLength+1)}, true},
+		{"Too Many Attributes", &ItemMetadata{Attributes: tooManyAttributes}, true},
+		{"Empty Attribute Key", &ItemMetadata{Attributes: map[string]string{"": "value"}}, true},
+		{"Attribute Key Too Long", &ItemMetadata{Attributes: map[string]string{strings.Repeat("a", maxMetadataLength+1): "value"}}, true},
+		{"Attribute Value Too Long", &ItemMetadata{Attributes: map[string]string{"region": strings.Repeat("a", maxAttributeValue+1)}}, true},
+		{"Reversed Service Period", &ItemMetadata{PeriodStart: &end, PeriodEnd: &start}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.metadata.Validate()
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateBillMetadata(t *testing.T) {
+	tooManyKeys := map[string]string{}
+	for i := 0; i <= maxAttributes; i++ {
+		tooManyKeys[fmt.Sprintf("key%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name        string
+		externalRef string
+		metadata    map[string]string
+		expectErr   bool
+	}{
+		{"No Metadata", "", nil, false},
+		{"Valid", "PO-1001", map[string]string{"cost_center": "CC-42", "note": strings.Repeat("a", maxBillMetadataValue)}, false},
+		{"External Ref Too Long", strings.Repeat("a", maxMetadataLength+1), nil, true},
+		{"Too Many Keys", "", tooManyKeys, true},
+		{"Empty Key", "", map[string]string{"": "CC-42"}, true},
+		{"Key Too Long", "", map[string]string{strings.Repeat("a", maxMetadataLength+1): "CC-42"}, true},
+		{"Value Too Long", "", map[string]string{"note": strings.Repeat("a", maxBillMetadataValue+1)}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateBillMetadata(tc.externalRef, tc.metadata)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoHistory is returned when a bill cannot be rebuilt, because it has no events up to the requested time.
+var ErrNoHistory = errors.New("bill has no recorded history at the given time")
+
+// Replay rebuilds the state of a bill as it was at the given time, by applying its events in order
+// through the same methods that changed the bill when the events happened.
+// The bill provides the attributes no event changes, such as its tenant, owner, currency, tax rate,
+// external reference and metadata.
+func Replay(bill *Bill, events []Event, at time.Time) (*Bill, error) {
+	projected := &Bill{
+		ID:           bill.ID,
+		TenantID:     bill.TenantID,
+		UserID:       bill.UserID,
+		Items:        []Item{},
+		Total:        Money{Currency: bill.Total.Currency},
+		TaxRate:      bill.TaxRate,
+		Tax:          Money{Currency: bill.Total.Currency},
+		Status:       BillOpen,
+		Version:      bill.Version,
+		AmendsBillID: bill.AmendsBillID,
+		ExternalRef:  bill.ExternalRef,
+		Metadata:     bill.Metadata,
+	}
+
+	for _, event := range events {
+		if event.OccurredAt.After(at) {
+			break
+		}
+
+		if err := projected.apply(event, bill); err != nil {
+			return nil, fmt.Errorf("Error replaying event %d (%s): %v", event.Sequence, event.Type, err)
+		}
+		projected.Events = append(projected.Events, event)
+		if event.Type.Mutates() {
+			projected.Revision++
+		}
+		projected.UpdatedAt = event.OccurredAt
+	}
+
+	if len(projected.Events) == 0 {
+		return nil, ErrNoHistory
+	}
+	projected.CreatedAt = projected.Events[0].OccurredAt
+
+	return projected, nil
+}
+
+// Apply a single event to a bill being replayed.
+// Closing, cancelling and amending a bill are final, and their events do not carry their details, so the
+// billing profile, due date, cancellation and amended items are taken from the bill's current state.
+func (b *Bill) apply(event Event, current *Bill) error {
+	switch event.Type {
+	case EventBillCreated:
+		return nil
+	case EventItemAdded:
+		if event.Item == nil {
+			return errors.New("item missing from event")
+		}
+		return b.AddLineItem(*event.Item)
+	case EventItemRemoved:
+		if event.Item == nil {
+			return errors.New("item missing from event")
+		}
+		return b.RemoveLineItem(*event.Item)
+	case EventItemUpdated:
+		if event.Item == nil {
+			return errors.New("item missing from event")
+		}
+		return b.replaceLineItem(*event.Item)
+	case EventBillClosed:
+		b.Status = BillClosed
+		b.BillingProfile = current.BillingProfile
+		b.DueAt = current.DueAt
+		b.ClosedAt = event.OccurredAt
+		return b.CalculateTotal()
+	case EventCloseApprovalRequested:
+		b.Status = BillAwaitingApproval
+		return nil
+	case EventCloseApproved:
+		return nil
+	case EventCloseRejected, EventCloseFailed:
+		b.Status = BillOpen
+		return nil
+	case EventCreditLimitWarning, EventCreditLimitExceeded:
+		// Warnings and rejected additions leave the bill unchanged
+		return nil
+	case EventBillCancelled:
+		b.Status = BillCancelled
+		b.Cancellation = current.Cancellation
+		return nil
+	case EventBillAmended:
+		// Amended versions are created closed with their final items
+		b.Items = append([]Item{}, current.Items...)
+		b.Status = BillClosed
+		b.BillingProfile = current.BillingProfile
+		b.ClosedAt = event.OccurredAt
+		return b.CalculateTotal()
+	}
+	return fmt.Errorf("unknown event type %s", event.Type)
+}
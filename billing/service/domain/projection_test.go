@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	actorID := uuid.NewString()
+	item := Item{ID: uuid.New(), Quantity: 3, Description: "Service Fee", PricePerUnit: Money{Amount: 100, Currency: "USD"}}
+	removed := item
+	removed.Quantity = 1
+
+	// Build a bill's history the way its workflow does
+	bill, err := NewBill(uuid.New().String(), "USD")
+	assert.NoError(t, err)
+	bill.TaxRate = 1000
+	bill.Record(EventBillCreated, actorID, "", nil, bill.Total, created)
+
+	before := bill.Total
+	assert.NoError(t, bill.AddLineItem(item))
+	bill.Record(EventItemAdded, actorID, "add", &item, before, created.Add(time.Hour))
+
+	before = bill.Total
+	assert.NoError(t, bill.RemoveLineItem(removed))
+	bill.Record(EventItemRemoved, actorID, "remove", &removed, before, created.Add(2*time.Hour))
+
+	before = bill.Total
+	price := Money{Amount: 150, Currency: "USD"}
+	updated, err := bill.UpdateLineItem(ItemUpdate{ID: item.ID, Version: 2, PricePerUnit: &price})
+	assert.NoError(t, err)
+	bill.Record(EventItemUpdated, actorID, "update", &updated, before, created.Add(150*time.Minute))
+
+	bill.ExternalRef = "PO-1001"
+	bill.Metadata = map[string]string{"cost_center": "CC-42"}
+	bill.Status = BillClosed
+	bill.BillingProfile = &BillingProfile{Name: "Acme LLC"}
+	dueAt := created.Add(30 * 24 * time.Hour)
+	bill.DueAt = &dueAt
+	bill.Record(EventBillClosed, actorID, "close", nil, bill.Total, created.Add(3*time.Hour))
+
+	t.Run("Before Creation", func(t *testing.T) {
+		_, err := Replay(bill, bill.Events, created.Add(-time.Minute))
+		assert.ErrorIs(t, err, ErrNoHistory)
+	})
+
+	t.Run("After Item Added", func(t *testing.T) {
+		projected, err := Replay(bill, bill.Events, created.Add(90*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, BillOpen, projected.Status)
+		assert.Equal(t, int64(3), projected.Items[0].Quantity)
+		assert.Equal(t, MinorUnit(300), projected.Total.Amount)
+		assert.Equal(t, MinorUnit(30), projected.Tax.Amount)
+		assert.Len(t, projected.Events, 2)
+		assert.Equal(t, created, projected.CreatedAt)
+		assert.Equal(t, 2, projected.Revision)
+		assert.Equal(t, "PO-1001", projected.ExternalRef)
+		assert.Equal(t, bill.Metadata, projected.Metadata)
+		assert.Nil(t, projected.DueAt)
+	})
+
+	t.Run("After Close", func(t *testing.T) {
+		projected, err := Replay(bill, bill.Events, created.Add(4*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, BillClosed, projected.Status)
+		assert.Equal(t, bill.Items, projected.Items)
+		assert.Equal(t, MinorUnit(300), projected.Total.Amount)
+		assert.Equal(t, bill.Total, projected.Total)
+		assert.Equal(t, bill.BillingProfile, projected.BillingProfile)
+		assert.Equal(t, bill.DueAt, projected.DueAt)
+	})
+
+	t.Run("Inconsistent History", func(t *testing.T) {
+		events := []Event{{Sequence: 1, Type: EventItemRemoved, Item: &removed, OccurredAt: created}}
+		_, err := Replay(bill, events, created)
+		assert.Error(t, err)
+	})
+}
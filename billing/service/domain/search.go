@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchFilter selects the closed bills a search covers. Bills match the query by the description, category
+// or SKU of their items, or by their external reference and metadata values.
+type SearchFilter struct {
+	Query  string // in web search syntax, e.g. "annual support" -trial
+	UserID *uuid.UUID
+	From   *time.Time // bills closed from, inclusive
+	To     *time.Time // bills closed until, exclusive
+	Limit  int
+	Offset int
+}
+
+// SearchResult is a closed bill matching a search, ranked by how well it and its items match.
+// The highlight marks the matching words of the bill's reference and metadata, if they matched.
+type SearchResult struct {
+	Bill      Bill // without its items
+	Rank      float64
+	Highlight string
+	Items     []ItemMatch // the items of the bill that matched
+}
+
+// ItemMatch is an item of a closed bill matching a search, with the matching words of its description marked.
+type ItemMatch struct {
+	ItemID      uuid.UUID
+	Description string
+	Highlight   string
+	Rank        float64
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalletEntryType tells whether a wallet entry added prepaid credit to a customer's balance or drew it down.
+type WalletEntryType string
+
+var WalletGrant WalletEntryType = "grant"
+var WalletConsumption WalletEntryType = "consumption"
+
+// Wallet holds the prepaid credit of a customer, with a balance per currency
+// and the ledger of grants and consumptions it results from, oldest first.
+type Wallet struct {
+	UserID   uuid.UUID
+	Balances []Money
+	Entries  []WalletEntry
+}
+
+// WalletEntry is a single grant or consumption of prepaid credit.
+// Consumptions reference the bill the credit was applied to.
+type WalletEntry struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      WalletEntryType
+	Amount    Money
+	BillID    *uuid.UUID
+	Reason    string
+	ActorID   string
+	RequestID string
+	CreatedAt time.Time
+}
+
+// ErrCreditCurrencyMismatch is returned when credit is applied to a bill in another currency.
+var ErrCreditCurrencyMismatch = errors.New("credit currency does not match the bill currency")
+
+// NewWalletGrant returns an entry adding prepaid credit to a customer's balance.
+func NewWalletGrant(userID uuid.UUID, amount Money, reason string, actorID string, requestID string, at time.Time) (*WalletEntry, error) {
+	if amount.Amount <= 0 {
+		return nil, fmt.Errorf("credit must be positive, got %d", amount.Amount)
+	}
+	if _, err := IsValidCurrency(amount.Currency); err != nil {
+		return nil, err
+	}
+
+	return &WalletEntry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      WalletGrant,
+		Amount:    amount,
+		Reason:    reason,
+		ActorID:   actorID,
+		RequestID: requestID,
+		CreatedAt: at,
+	}, nil
+}
+
+// ApplyCredit draws down a customer's prepaid credit against the amount due on a bill being closed,
+// up to the available balance, and returns the credit applied. A bill that is fully covered is paid.
+func (b *Bill) ApplyCredit(balance Money) (Money, error) {
+	applied := Money{Currency: b.Total.Currency}
+	if balance.Currency != b.Total.Currency {
+		return applied, fmt.Errorf("%w: %s, bill in %s", ErrCreditCurrencyMismatch, balance.Currency, b.Total.Currency)
+	}
+
+	applied.Amount = min(max(balance.Amount, 0), b.AmountDue().Amount)
+	if applied.Amount <= 0 {
+		return applied, nil
+	}
+
+	b.CreditApplied = Money{Amount: b.CreditApplied.Amount + applied.Amount, Currency: b.Total.Currency}
+	if b.Collection == CollectionUnpaid && b.AmountDue().Amount <= 0 {
+		b.Collection = CollectionPaid
+	}
+	return applied, nil
+}
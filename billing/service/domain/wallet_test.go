@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWalletGrant(t *testing.T) {
+	userID := uuid.New()
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	grant, err := NewWalletGrant(userID, Money{Amount: 1000, Currency: "USD"}, "Prepayment", "admin", "request", at)
+	assert.NoError(t, err)
+	assert.Equal(t, WalletGrant, grant.Type)
+	assert.Equal(t, userID, grant.UserID)
+	assert.Nil(t, grant.BillID)
+	assert.Equal(t, at, grant.CreatedAt)
+
+	_, err = NewWalletGrant(userID, Money{Amount: 0, Currency: "USD"}, "", "admin", "request", at)
+	assert.Error(t, err)
+
+	_, err = NewWalletGrant(userID, Money{Amount: 1000, Currency: "XYZ"}, "", "admin", "request", at)
+	assert.Error(t, err)
+}
+
+func TestApplyCredit(t *testing.T) {
+	newClosingBill := func() *Bill {
+		bill, _ := NewBill(uuid.New().String(), "USD")
+		bill.TaxRate = 1000
+		_ = bill.AddLineItem(Item{ID: uuid.New(), Quantity: 1, PricePerUnit: Money{Amount: 1000, Currency: "USD"}})
+		bill.PaymentTerms = &PaymentTerms{DueIn: 24 * time.Hour}
+		bill.SetDueDate(time.Now())
+		return bill
+	}
+
+	// Credit short of the amount due is drawn down entirely, and the rest remains due
+	bill := newClosingBill()
+	applied, err := bill.ApplyCredit(Money{Amount: 400, Currency: "USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, Money{Amount: 400, Currency: "USD"}, applied)
+	assert.Equal(t, Money{Amount: 700, Currency: "USD"}, bill.AmountDue())
+	assert.Equal(t, CollectionUnpaid, bill.Collection)
+
+	// Only the amount due, tax included, is drawn from a larger balance, and the bill is paid
+	bill = newClosingBill()
+	applied, err = bill.ApplyCredit(Money{Amount: 5000, Currency: "USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, Money{Amount: 1100, Currency: "USD"}, applied)
+	assert.Equal(t, Money{Amount: 0, Currency: "USD"}, bill.AmountDue())
+	assert.Equal(t, CollectionPaid, bill.Collection)
+
+	// An empty wallet leaves the bill unchanged
+	bill = newClosingBill()
+	applied, err = bill.ApplyCredit(Money{Amount: 0, Currency: "USD"})
+	assert.NoError(t, err)
+	assert.Zero(t, applied.Amount)
+	assert.Zero(t, bill.CreditApplied.Amount)
+
+	// Credit is only applied in the bill currency
+	bill = newClosingBill()
+	_, err = bill.ApplyCredit(Money{Amount: 500, Currency: "GEL"})
+	assert.True(t, errors.Is(err, ErrCreditCurrencyMismatch))
+	assert.Zero(t, bill.CreditApplied.Amount)
+}
@@ -2,6 +2,8 @@ package billing
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,16 +11,20 @@ import (
 )
 
 type CreateBillRequest struct {
-	UserID   string `json:"user_id"`
-	Currency string `json:"currency"`
+	UserID      string            `json:"user_id"`
+	Currency    string            `json:"currency"`
+	ExternalRef string            `json:"external_ref,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 type CreateBillResponse struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
-	Status    string    `json:"status"`
+	ID          string            `json:"id"`
+	UserID      string            `json:"user_id"`
+	Currency    string            `json:"currency"`
+	ExternalRef string            `json:"external_ref,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Status      string            `json:"status"`
 }
 
 func validateCreateBillRequest(req *CreateBillRequest) error {
@@ -30,6 +36,9 @@ func validateCreateBillRequest(req *CreateBillRequest) error {
 	if err != nil {
 		return fmt.Errorf("Invalid Currency: %v", err)
 	}
+	if err := domain.ValidateBillMetadata(req.ExternalRef, req.Metadata); err != nil {
+		return fmt.Errorf("Invalid metadata: %v", err)
+	}
 	return nil
 }
 
@@ -38,20 +47,71 @@ type GetBillRequest struct {
 }
 
 type GetBillResponse struct {
-	ID        string        `json:"id"`
-	Items     []domain.Item `json:"items"`
-	Total     domain.Money  `json:"total"`
-	Status    domain.Status `json:"status"`
-	UserID    string        `json:"user_id"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID             string                  `json:"id"`
+	Items          []domain.Item           `json:"items"`
+	Total          domain.Money            `json:"total"`
+	Tax            domain.Money            `json:"tax"`
+	Status         domain.Status           `json:"status"`
+	UserID         string                  `json:"user_id"`
+	BillingProfile *domain.BillingProfile  `json:"billing_profile,omitempty"`
+	Cancellation   *domain.Cancellation    `json:"cancellation,omitempty"`
+	Approval       *domain.CloseApproval   `json:"approval,omitempty"`
+	Version        int                     `json:"version"`
+	Revision       int                     `json:"revision,omitempty"`
+	ExternalRef    string                  `json:"external_ref,omitempty"`
+	Metadata       map[string]string       `json:"metadata,omitempty"`
+	AmendsBillID   *uuid.UUID              `json:"amends_bill_id,omitempty"`
+	AmendedByID    *uuid.UUID              `json:"amended_by_id,omitempty"`
+	DueAt          *time.Time              `json:"due_at,omitempty"`
+	Collection     domain.CollectionStatus `json:"collection,omitempty"`
+	LateFees       []domain.LateFee        `json:"late_fees,omitempty"`
+	CreditApplied  *domain.Money           `json:"credit_applied,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+	ETag           string                  `header:"ETag"` // revision of open bills, for conditional changes
+}
+
+// ETag identifying the revision of an open bill.
+func billETag(revision int) string {
+	return strconv.Quote(strconv.Itoa(revision))
+}
+
+// Parse an If-Match header into the bill revisions it accepts.
+// Returns no revisions for an absent header or "*", which accept any revision of an open bill.
+func parseIfMatch(header string) ([]int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var revisions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			return nil, fmt.Errorf("Weak ETags cannot be matched: %s", tag)
+		}
+
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ETag: %s", tag)
+		}
+		revision, err := strconv.Atoi(unquoted)
+		if err != nil || revision < 0 {
+			return nil, fmt.Errorf("Invalid ETag: %s", tag)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
 }
 
 type AddLineItemRequest struct {
-	ID           string       `json:"id"`
-	Quantity     int64        `json:"quantity"`
-	Description  string       `json:"description"`
-	PricePerUnit domain.Money `json:"price_per_unit"`
+	IfMatch      string               `header:"If-Match"`
+	RequestID    string               `json:"request_id"`
+	ID           string               `json:"id"`
+	Quantity     int64                `json:"quantity"`
+	Description  string               `json:"description"`
+	PricePerUnit domain.Money         `json:"price_per_unit"`
+	Metadata     *domain.ItemMetadata `json:"metadata,omitempty"`
 }
 
 type AddLineItemResponse struct {
@@ -77,10 +137,146 @@ func validateAddLineItemRequest(req *AddLineItemRequest) error {
 		return fmt.Errorf("Invalid currency %v", err)
 	}
 
+	if err := req.Metadata.Validate(); err != nil {
+		return fmt.Errorf("Invalid metadata: %v", err)
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	}
+
 	return nil
 }
 
+// Most items added to a bill in a single batch
+const maxBatchItems = 1000
+
+type AddLineItemsRequest struct {
+	IfMatch   string          `header:"If-Match"`
+	RequestID string          `json:"request_id"`
+	Items     []BatchLineItem `json:"items"`
+}
+
+// BatchLineItem is a line item added as part of a batch.
+type BatchLineItem struct {
+	ID           string               `json:"id"`
+	Quantity     int64                `json:"quantity"`
+	Description  string               `json:"description"`
+	PricePerUnit domain.Money         `json:"price_per_unit"`
+	Metadata     *domain.ItemMetadata `json:"metadata,omitempty"`
+}
+
+type AddLineItemsResponse struct {
+	Bill  *domain.Bill `json:"bill"`
+	Added int          `json:"added"`
+}
+
+// Validate every item of a batch up front, returning the items to add.
+func validateAddLineItemsRequest(req *AddLineItemsRequest) ([]domain.Item, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("No items to add")
+	}
+	if len(req.Items) > maxBatchItems {
+		return nil, fmt.Errorf("Cannot add more than %d items at once", maxBatchItems)
+	}
+
+	items := make([]domain.Item, 0, len(req.Items))
+	for i, item := range req.Items {
+		single := AddLineItemRequest{
+			RequestID:    req.RequestID,
+			ID:           item.ID,
+			Quantity:     item.Quantity,
+			Description:  item.Description,
+			PricePerUnit: item.PricePerUnit,
+			Metadata:     item.Metadata,
+		}
+		if err := validateAddLineItemRequest(&single); err != nil {
+			return nil, fmt.Errorf("Item %d: %v", i+1, err)
+		}
+
+		items = append(items, domain.Item{
+			ID:           uuid.MustParse(item.ID),
+			Quantity:     item.Quantity,
+			Description:  item.Description,
+			PricePerUnit: item.PricePerUnit,
+			Metadata:     item.Metadata,
+		})
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	}
+
+	return items, nil
+}
+
+// UpdateLineItemRequest changes an item in place. Fields left out keep their current value,
+// and Version is the version of the item the change was made against.
+type UpdateLineItemRequest struct {
+	IfMatch      string               `header:"If-Match"`
+	RequestID    string               `json:"request_id"`
+	Version      int                  `json:"version"`
+	Quantity     *int64               `json:"quantity,omitempty"`
+	Description  *string              `json:"description,omitempty"`
+	PricePerUnit *domain.Money        `json:"price_per_unit,omitempty"`
+	Metadata     *domain.ItemMetadata `json:"metadata,omitempty"`
+}
+
+type UpdateLineItemResponse struct {
+	Bill *domain.Bill `json:"bill"`
+	Item *domain.Item `json:"item"`
+}
+
+// Validate a change of an item, returning the update to apply.
+func validateUpdateLineItemRequest(itemID string, req *UpdateLineItemRequest) (*domain.ItemUpdate, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid item ID: %v", err)
+	}
+
+	if req.Version < 1 {
+		return nil, fmt.Errorf("Invalid item version: %v", req.Version)
+	}
+
+	if req.Quantity == nil && req.Description == nil && req.PricePerUnit == nil && req.Metadata == nil {
+		return nil, fmt.Errorf("Nothing to update")
+	}
+
+	if req.Quantity != nil && *req.Quantity < 1 {
+		return nil, fmt.Errorf("Invalid item quantity: %v", *req.Quantity)
+	}
+
+	if req.PricePerUnit != nil {
+		if req.PricePerUnit.Amount < 0 {
+			return nil, fmt.Errorf("Invalid price: %v", *req.PricePerUnit)
+		}
+		_, err = domain.IsValidCurrency(req.PricePerUnit.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid currency %v", err)
+		}
+	}
+
+	if err := req.Metadata.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid metadata: %v", err)
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	}
+
+	return &domain.ItemUpdate{
+		ID:           id,
+		Version:      req.Version,
+		Quantity:     req.Quantity,
+		Description:  req.Description,
+		PricePerUnit: req.PricePerUnit,
+		Metadata:     req.Metadata,
+	}, nil
+}
+
 type RemoveLineItemRequest struct {
+	IfMatch      string       `header:"If-Match"`
+	RequestID    string       `json:"request_id"`
 	ID           string       `json:"id"`
 	Quantity     int64        `json:"quantity"`
 	Description  string       `json:"description"`
@@ -104,10 +300,39 @@ func validateRemoveLineItemRequest(req *RemoveLineItemRequest) error {
 	if req.Quantity < 1 {
 		return fmt.Errorf("Invalid item quantity: %v", req.Quantity)
 	}
+
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	}
+
 	return nil
 }
 
+type ListBillEventsResponse struct {
+	Events []domain.Event `json:"events"`
+}
+
+type GetBillAsOfRequest struct {
+	At string `query:"at"`
+}
+
+// Validate a request for the state of a bill at a point in time, returning that time.
+func validateGetBillAsOfRequest(id string, req *GetBillAsOfRequest) (time.Time, error) {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	at, err := time.Parse(time.RFC3339, req.At)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time, expected RFC 3339: %v", err)
+	}
+
+	return at, nil
+}
+
 type CloseBillRequest struct {
+	IfMatch   string `header:"If-Match"`
 	RequestID string `json:"request_id"`
 }
 
@@ -128,3 +353,616 @@ func validateCloseBillRequest(id string, req *CloseBillRequest) error {
 
 	return nil
 }
+
+type CloseApprovalRequest struct {
+	Approved bool   `json:"approved"`
+	Comment  string `json:"comment"`
+}
+
+type CloseApprovalResponse struct {
+	Message string `json:"message"`
+}
+
+func validateCloseApprovalRequest(id string, req *CloseApprovalRequest) error {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	req.Comment = strings.TrimSpace(req.Comment)
+	if len(req.Comment) > maxReasonLength {
+		return fmt.Errorf("Comment cannot exceed %d characters", maxReasonLength)
+	}
+
+	return nil
+}
+
+type CancelBillRequest struct {
+	RequestID string `json:"request_id"`
+	Reason    string `json:"reason"`
+}
+
+type CancelBillResponse struct {
+	Bill   *domain.Bill
+	Status string
+}
+
+// Longest reason or comment that is stored with a bill
+const maxReasonLength = 500
+
+func validateCancelBillRequest(id string, req *CancelBillRequest) error {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return fmt.Errorf("Cancellation reason cannot be empty")
+	}
+	if len(req.Reason) > maxReasonLength {
+		return fmt.Errorf("Cancellation reason cannot exceed %d characters", maxReasonLength)
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	}
+
+	return nil
+}
+
+type AmendBillRequest struct {
+	RequestID string      `json:"request_id"`
+	Reason    string      `json:"reason"`
+	Items     []AmendItem `json:"items"`
+}
+
+// AmendItem is an item of the amended bill, replacing the item of the same ID in the original.
+// Items of the original that are left out are removed by the amendment.
+// Items left without metadata keep the metadata of the item they replace.
+type AmendItem struct {
+	ID           string               `json:"id"`
+	Quantity     int64                `json:"quantity"`
+	Description  string               `json:"description"`
+	PricePerUnit domain.Money         `json:"price_per_unit"`
+	Metadata     *domain.ItemMetadata `json:"metadata,omitempty"`
+}
+
+type AmendBillResponse struct {
+	Bill      *domain.Bill
+	Amendment *domain.Amendment
+	Status    string
+}
+
+type ListBillAmendmentsResponse struct {
+	Amendments []domain.Amendment `json:"amendments"`
+}
+
+func validateAmendBillRequest(id string, req *AmendBillRequest) error {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return fmt.Errorf("Amendment reason cannot be empty")
+	}
+	if len(req.Reason) > maxReasonLength {
+		return fmt.Errorf("Amendment reason cannot exceed %d characters", maxReasonLength)
+	}
+
+	for _, item := range req.Items {
+		_, err := uuid.Parse(item.ID)
+		if err != nil {
+			return fmt.Errorf("Invalid item ID: %v", err)
+		}
+
+		if item.PricePerUnit.Amount < 0 {
+			return fmt.Errorf("Invalid price: %v", item.PricePerUnit)
+		}
+
+		if item.Quantity < 1 {
+			return fmt.Errorf("Invalid item quantity: %v", item.Quantity)
+		}
+
+		_, err = domain.IsValidCurrency(item.PricePerUnit.Currency)
+		if err != nil {
+			return fmt.Errorf("Invalid currency %v", err)
+		}
+
+		if err := item.Metadata.Validate(); err != nil {
+			return fmt.Errorf("Invalid metadata: %v", err)
+		}
+	}
+
+	// Amendments are stored under their request ID to make retries idempotent
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	} else if _, err := uuid.Parse(req.RequestID); err != nil {
+		return fmt.Errorf("Invalid request ID: %v", err)
+	}
+
+	return nil
+}
+
+// Convert the items of an amendment request to domain items.
+// Expects a request that passed validateAmendBillRequest.
+func amendItems(req *AmendBillRequest) []domain.Item {
+	items := make([]domain.Item, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, domain.Item{
+			ID:           uuid.MustParse(item.ID),
+			Quantity:     item.Quantity,
+			Description:  item.Description,
+			PricePerUnit: item.PricePerUnit,
+			Metadata:     item.Metadata,
+		})
+	}
+	return items
+}
+
+type GrantWalletCreditRequest struct {
+	RequestID string       `json:"request_id"`
+	Amount    domain.Money `json:"amount"`
+	Reason    string       `json:"reason"`
+}
+
+type GetWalletResponse struct {
+	UserID   string               `json:"user_id"`
+	Balances []domain.Money       `json:"balances"`
+	Entries  []domain.WalletEntry `json:"entries"`
+}
+
+func validateGrantWalletCreditRequest(id string, req *GrantWalletCreditRequest) error {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("Invalid user ID: %v", err)
+	}
+
+	if req.Amount.Amount <= 0 {
+		return fmt.Errorf("Invalid credit amount: %v", req.Amount)
+	}
+
+	_, err = domain.IsValidCurrency(req.Amount.Currency)
+	if err != nil {
+		return fmt.Errorf("Invalid currency %v", err)
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) > maxReasonLength {
+		return fmt.Errorf("Reason cannot exceed %d characters", maxReasonLength)
+	}
+
+	// Grants are stored under their request ID to make retries idempotent
+	if req.RequestID == "" {
+		req.RequestID = uuid.NewString()
+	} else if _, err := uuid.Parse(req.RequestID); err != nil {
+		return fmt.Errorf("Invalid request ID: %v", err)
+	}
+
+	return nil
+}
+
+type GetTrialBalanceRequest struct {
+	Currency string `query:"currency"`
+	AsOf     string `query:"as_of"`
+}
+
+type GetTrialBalanceResponse struct {
+	TrialBalance *domain.TrialBalance `json:"trial_balance"`
+}
+
+// Validate a trial balance request, returning the time it is drawn up to, which defaults to now.
+func validateGetTrialBalanceRequest(req *GetTrialBalanceRequest, now time.Time) (time.Time, error) {
+	if _, err := domain.IsValidCurrency(req.Currency); err != nil {
+		return time.Time{}, fmt.Errorf("Invalid currency %v", err)
+	}
+
+	if req.AsOf == "" {
+		return now, nil
+	}
+	asOf, err := time.Parse(time.RFC3339, req.AsOf)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid as_of time, expected RFC 3339: %v", err)
+	}
+
+	return asOf, nil
+}
+
+type GetAccountStatementRequest struct {
+	Currency string `query:"currency"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+}
+
+type GetAccountStatementResponse struct {
+	Statement *domain.AccountStatement `json:"statement"`
+}
+
+// Validate an account statement request, returning the account and the period it covers.
+// Periods without a start cover the account from its first posting, and those without an end up to now.
+func validateGetAccountStatementRequest(code string, req *GetAccountStatementRequest, now time.Time) (domain.Account, time.Time, time.Time, error) {
+	account, ok := domain.LookupAccount(code)
+	if !ok {
+		return account, time.Time{}, time.Time{}, fmt.Errorf("Unknown account %s", code)
+	}
+
+	if _, err := domain.IsValidCurrency(req.Currency); err != nil {
+		return account, time.Time{}, time.Time{}, fmt.Errorf("Invalid currency %v", err)
+	}
+
+	var from time.Time
+	to := now
+	var err error
+	if req.From != "" {
+		if from, err = time.Parse(time.RFC3339, req.From); err != nil {
+			return account, time.Time{}, time.Time{}, fmt.Errorf("Invalid from time, expected RFC 3339: %v", err)
+		}
+	}
+	if req.To != "" {
+		if to, err = time.Parse(time.RFC3339, req.To); err != nil {
+			return account, time.Time{}, time.Time{}, fmt.Errorf("Invalid to time, expected RFC 3339: %v", err)
+		}
+	}
+	if to.Before(from) {
+		return account, time.Time{}, time.Time{}, fmt.Errorf("Period ends before it starts")
+	}
+
+	return account, from, to, nil
+}
+
+type ExportLedgerRequest struct {
+	Format string `query:"format"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+}
+
+type ExportLedgerResponse struct {
+	Format      domain.ExportFormat `json:"format"`
+	ContentType string              `json:"content_type"`
+	Content     string              `json:"content"`
+}
+
+// Validate a ledger export request, returning the format and the period [from, to) it covers.
+// Exports are written as CSV unless another format is requested.
+func validateExportLedgerRequest(req *ExportLedgerRequest) (domain.ExportFormat, time.Time, time.Time, error) {
+	format := domain.ExportCSV
+	if req.Format != "" {
+		var err error
+		if format, err = domain.ParseExportFormat(req.Format); err != nil {
+			return "", time.Time{}, time.Time{}, err
+		}
+	}
+
+	from, err := time.Parse(time.RFC3339, req.From)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("Invalid from time, expected RFC 3339: %v", err)
+	}
+	to, err := time.Parse(time.RFC3339, req.To)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("Invalid to time, expected RFC 3339: %v", err)
+	}
+	if !to.After(from) {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("Period ends before it starts")
+	}
+
+	return format, from, to, nil
+}
+
+// ItemReportRequest selects the items of closed bills a report sums. Attributes are given as key=value,
+// and items must have all of them.
+type ItemReportRequest struct {
+	From       string   `query:"from"`
+	To         string   `query:"to"`
+	Category   string   `query:"category"`
+	SKU        string   `query:"sku"`
+	Attributes []string `query:"attribute"`
+	PeriodFrom string   `query:"period_from"`
+	PeriodTo   string   `query:"period_to"`
+}
+
+type ItemReportResponse struct {
+	From   time.Time          `json:"from"`
+	To     time.Time          `json:"to"`
+	Totals []domain.ItemTotal `json:"totals"`
+}
+
+// Validate an item report request, returning the filter selecting the items it covers.
+func validateItemReportRequest(req *ItemReportRequest) (domain.ItemFilter, error) {
+	filter := domain.ItemFilter{Category: req.Category, SKU: req.SKU}
+
+	var err error
+	if filter.From, err = time.Parse(time.RFC3339, req.From); err != nil {
+		return filter, fmt.Errorf("Invalid from time, expected RFC 3339: %v", err)
+	}
+	if filter.To, err = time.Parse(time.RFC3339, req.To); err != nil {
+		return filter, fmt.Errorf("Invalid to time, expected RFC 3339: %v", err)
+	}
+	if !filter.To.After(filter.From) {
+		return filter, fmt.Errorf("Period ends before it starts")
+	}
+
+	for _, attribute := range req.Attributes {
+		key, value, ok := strings.Cut(attribute, "=")
+		if !ok || key == "" {
+			return filter, fmt.Errorf("Invalid attribute %s, expected key=value", attribute)
+		}
+		if filter.Attributes == nil {
+			filter.Attributes = map[string]string{}
+		}
+		filter.Attributes[key] = value
+	}
+
+	if req.PeriodFrom != "" {
+		periodFrom, err := time.Parse(time.RFC3339, req.PeriodFrom)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid period_from time, expected RFC 3339: %v", err)
+		}
+		filter.PeriodFrom = &periodFrom
+	}
+	if req.PeriodTo != "" {
+		periodTo, err := time.Parse(time.RFC3339, req.PeriodTo)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid period_to time, expected RFC 3339: %v", err)
+		}
+		filter.PeriodTo = &periodTo
+	}
+	if filter.PeriodFrom != nil && filter.PeriodTo != nil && !filter.PeriodTo.After(*filter.PeriodFrom) {
+		return filter, fmt.Errorf("Service period ends before it starts")
+	}
+
+	return filter, nil
+}
+
+// Bills listed per page when the request does not say
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// ListBillsRequest selects the bills to list. Metadata is given as key=value, and bills must have all of it.
+type ListBillsRequest struct {
+	UserID      string   `query:"user_id"`
+	Status      string   `query:"status"`
+	ExternalRef string   `query:"external_ref"`
+	Metadata    []string `query:"metadata"`
+	Limit       int      `query:"limit"`
+	Offset      int      `query:"offset"`
+}
+
+type ListBillsResponse struct {
+	Bills []BillSummary `json:"bills"`
+}
+
+// BillSummary is a bill as listed, without its items. Open bills are listed without their total,
+// which is kept by their workflow until they close.
+type BillSummary struct {
+	ID          string            `json:"id"`
+	UserID      string            `json:"user_id"`
+	Status      domain.Status     `json:"status"`
+	Currency    string            `json:"currency"`
+	Total       *domain.Money     `json:"total,omitempty"`
+	Tax         *domain.Money     `json:"tax,omitempty"`
+	Version     int               `json:"version"`
+	ExternalRef string            `json:"external_ref,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	ClosedAt    *time.Time        `json:"closed_at,omitempty"`
+}
+
+// Validate a list bills request, returning the filter selecting the bills it lists.
+func validateListBillsRequest(req *ListBillsRequest) (domain.BillFilter, error) {
+	filter := domain.BillFilter{Status: domain.Status(req.Status), ExternalRef: req.ExternalRef, Limit: req.Limit, Offset: req.Offset}
+
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid UserID: %v", err)
+		}
+		filter.UserID = &userID
+	}
+
+	switch filter.Status {
+	case "", domain.BillOpen, domain.BillClosed, domain.BillCancelled:
+	default:
+		return filter, fmt.Errorf("Invalid status %s", req.Status)
+	}
+
+	for _, entry := range req.Metadata {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return filter, fmt.Errorf("Invalid metadata %s, expected key=value", entry)
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[key] = value
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return filter, fmt.Errorf("Limit must be between 1 and %d", maxListLimit)
+	}
+	if filter.Offset < 0 {
+		return filter, fmt.Errorf("Offset cannot be negative")
+	}
+
+	return filter, nil
+}
+
+// Summarize a listed bill. Totals are only known for bills that are no longer open.
+func billSummary(bill domain.Bill) BillSummary {
+	summary := BillSummary{
+		ID:          bill.ID.String(),
+		UserID:      bill.UserID.String(),
+		Status:      bill.Status,
+		Currency:    bill.Total.Currency,
+		Version:     bill.Version,
+		ExternalRef: bill.ExternalRef,
+		Metadata:    bill.Metadata,
+		CreatedAt:   bill.CreatedAt,
+		UpdatedAt:   bill.UpdatedAt,
+	}
+	if bill.Status != domain.BillOpen {
+		summary.Total = &bill.Total
+		summary.Tax = &bill.Tax
+		summary.ClosedAt = &bill.ClosedAt
+	}
+	return summary
+}
+
+// Longest search query accepted, in characters
+const maxSearchQuery = 256
+
+// SearchBillsRequest searches closed bills. The query is in web search syntax: words match in any order,
+// "quoted phrases" match in order, "or" matches either side and -word excludes bills matching it.
+type SearchBillsRequest struct {
+	Query  string `query:"q"`
+	UserID string `query:"user_id"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+type SearchBillsResponse struct {
+	Results []BillSearchResult `json:"results"`
+}
+
+type BillSearchResult struct {
+	Bill      BillSummary        `json:"bill"`
+	Rank      float64            `json:"rank"`
+	Highlight string             `json:"highlight,omitempty"` // of the bill's reference and metadata
+	Items     []domain.ItemMatch `json:"items"`
+}
+
+// Validate a search bills request, returning the filter selecting the bills it searches.
+func validateSearchBillsRequest(req *SearchBillsRequest) (domain.SearchFilter, error) {
+	filter := domain.SearchFilter{Query: strings.TrimSpace(req.Query), Limit: req.Limit, Offset: req.Offset}
+
+	if filter.Query == "" {
+		return filter, fmt.Errorf("Query cannot be empty")
+	}
+	if len(filter.Query) > maxSearchQuery {
+		return filter, fmt.Errorf("Query cannot exceed %d characters", maxSearchQuery)
+	}
+
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid UserID: %v", err)
+		}
+		filter.UserID = &userID
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid from time, expected RFC 3339: %v", err)
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid to time, expected RFC 3339: %v", err)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return filter, fmt.Errorf("Period ends before it starts")
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return filter, fmt.Errorf("Limit must be between 1 and %d", maxListLimit)
+	}
+	if filter.Offset < 0 {
+		return filter, fmt.Errorf("Offset cannot be negative")
+	}
+
+	return filter, nil
+}
+
+// RevenueReportRequest selects the days a revenue report covers, from inclusive to exclusive, and how they
+// are broken down. Amounts are normalized to the given currency, or to the tenant's reporting currency.
+type RevenueReportRequest struct {
+	From     string `query:"from"`
+	To       string `query:"to"`
+	Interval string `query:"interval"` // day or month, day by default
+	Currency string `query:"currency"`
+}
+
+type RevenueReportResponse struct {
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	Interval    domain.ReportInterval  `json:"interval"`
+	Currency    string                 `json:"currency"`
+	Periods     []domain.RevenuePeriod `json:"periods"`
+	Summary     domain.RevenuePeriod   `json:"summary"`      // of the whole report
+	RefreshedAt *time.Time             `json:"refreshed_at"` // of the rollups the report was read from
+}
+
+// CategoryReportRequest selects the days a category report covers, from inclusive to exclusive.
+type CategoryReportRequest struct {
+	From     string `query:"from"`
+	To       string `query:"to"`
+	Currency string `query:"currency"`
+}
+
+type CategoryReportResponse struct {
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	Currency    string             `json:"currency"`
+	Categories  []domain.ItemTotal `json:"categories"`
+	RefreshedAt *time.Time         `json:"refreshed_at"`
+}
+
+type BillCountReportResponse struct {
+	Open        int                `json:"open"`
+	Closed      int                `json:"closed"`
+	Cancelled   int                `json:"cancelled"`
+	Counts      []domain.BillCount `json:"counts"` // by status and currency
+	RefreshedAt *time.Time         `json:"refreshed_at"`
+}
+
+// Parse the period of an analytics report, which must not be empty.
+func parseReportPeriod(from string, to string) (time.Time, time.Time, error) {
+	fromTime, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid from time, expected RFC 3339: %v", err)
+	}
+	toTime, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid to time, expected RFC 3339: %v", err)
+	}
+	if !toTime.After(fromTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("Period ends before it starts")
+	}
+	return fromTime, toTime, nil
+}
+
+// Validate a revenue report request, returning its period and interval.
+func validateRevenueReportRequest(req *RevenueReportRequest) (time.Time, time.Time, domain.ReportInterval, error) {
+	from, to, err := parseReportPeriod(req.From, req.To)
+	if err != nil {
+		return from, to, "", err
+	}
+
+	interval := domain.ReportInterval(req.Interval)
+	switch interval {
+	case "":
+		interval = domain.IntervalDay
+	case domain.IntervalDay, domain.IntervalMonth:
+	default:
+		return from, to, "", fmt.Errorf("Invalid interval %s, expected day or month", req.Interval)
+	}
+
+	return from, to, interval, nil
+}
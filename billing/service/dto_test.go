@@ -1,7 +1,9 @@
 package billing
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +21,10 @@ func TestValidateCreateBillRequest(t *testing.T) {
 		{"Invalid Currency", CreateBillRequest{UserID: uuid.NewString(), Currency: "EUR"}, true},
 		{"Empty UserID", CreateBillRequest{UserID: "", Currency: "USD"}, true},
 		{"Empty Currency", CreateBillRequest{UserID: uuid.NewString(), Currency: ""}, true},
+		{"Valid Metadata", CreateBillRequest{UserID: uuid.NewString(), Currency: "USD", ExternalRef: "PO-1001", Metadata: map[string]string{"cost_center": "CC-42"}}, false},
+		{"External Ref Too Long", CreateBillRequest{UserID: uuid.NewString(), Currency: "USD", ExternalRef: strings.Repeat("a", 65)}, true},
+		{"Empty Metadata Key", CreateBillRequest{UserID: uuid.NewString(), Currency: "USD", Metadata: map[string]string{"": "CC-42"}}, true},
+		{"Metadata Value Too Long", CreateBillRequest{UserID: uuid.NewString(), Currency: "USD", Metadata: map[string]string{"note": strings.Repeat("a", 1025)}}, true},
 	}
 
 	for _, tc := range tests {
@@ -46,6 +52,8 @@ func TestValidateAddLineItemRequest(t *testing.T) {
 		{"Invalid Currency", AddLineItemRequest{ID: uuid.NewString(), Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "EUR"}}, true},
 		{"Negative Price", AddLineItemRequest{ID: uuid.NewString(), Quantity: 2, PricePerUnit: domain.Money{Amount: -100, Currency: "USD"}}, true},
 		{"Empty ID", AddLineItemRequest{ID: "", Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}}, true},
+		{"Valid Metadata", AddLineItemRequest{ID: uuid.NewString(), Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Metadata: &domain.ItemMetadata{Category: "usage", Attributes: map[string]string{"region": "eu"}}}, false},
+		{"Invalid Metadata", AddLineItemRequest{ID: uuid.NewString(), Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Metadata: &domain.ItemMetadata{Attributes: map[string]string{"": "eu"}}}, true},
 	}
 
 	for _, tc := range tests {
@@ -141,3 +149,477 @@ func TestValidateCloseBillRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCancelBillRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		req       CancelBillRequest
+		expectErr bool
+	}{
+		{"Valid Request", uuid.NewString(), CancelBillRequest{RequestID: uuid.NewString(), Reason: "Duplicate bill"}, false},
+		{"Empty RequestID", uuid.NewString(), CancelBillRequest{Reason: "Duplicate bill"}, false},
+		{"Invalid ID", "invalid-uuid", CancelBillRequest{Reason: "Duplicate bill"}, true},
+		{"Empty Reason", uuid.NewString(), CancelBillRequest{Reason: ""}, true},
+		{"Blank Reason", uuid.NewString(), CancelBillRequest{Reason: "   "}, true},
+		{"Reason Too Long", uuid.NewString(), CancelBillRequest{Reason: strings.Repeat("a", maxReasonLength+1)}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCancelBillRequest(tc.id, &tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tc.req.RequestID)
+			}
+		})
+	}
+}
+
+func TestValidateCloseApprovalRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		req       CloseApprovalRequest
+		expectErr bool
+	}{
+		{"Valid Approval", uuid.NewString(), CloseApprovalRequest{Approved: true}, false},
+		{"Valid Rejection With Comment", uuid.NewString(), CloseApprovalRequest{Comment: "Discount missing"}, false},
+		{"Invalid ID", "invalid-uuid", CloseApprovalRequest{Approved: true}, true},
+		{"Comment Too Long", uuid.NewString(), CloseApprovalRequest{Comment: strings.Repeat("a", maxReasonLength+1)}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCloseApprovalRequest(tc.id, &tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateAmendBillRequest(t *testing.T) {
+	item := AmendItem{ID: uuid.NewString(), Quantity: 2, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}}
+
+	tests := []struct {
+		name      string
+		id        string
+		req       AmendBillRequest
+		expectErr bool
+	}{
+		{"Valid Request", uuid.NewString(), AmendBillRequest{RequestID: uuid.NewString(), Reason: "Wrong quantity", Items: []AmendItem{item}}, false},
+		{"Empty RequestID", uuid.NewString(), AmendBillRequest{Reason: "Wrong quantity", Items: []AmendItem{item}}, false},
+		{"Removes All Items", uuid.NewString(), AmendBillRequest{Reason: "Billed by mistake"}, false},
+		{"Invalid RequestID", uuid.NewString(), AmendBillRequest{RequestID: "retry-1", Reason: "Wrong quantity"}, true},
+		{"Invalid ID", "invalid-uuid", AmendBillRequest{Reason: "Wrong quantity"}, true},
+		{"Blank Reason", uuid.NewString(), AmendBillRequest{Reason: "   "}, true},
+		{"Reason Too Long", uuid.NewString(), AmendBillRequest{Reason: strings.Repeat("a", maxReasonLength+1)}, true},
+		{"Invalid Item ID", uuid.NewString(), AmendBillRequest{Reason: "Wrong quantity", Items: []AmendItem{{ID: "invalid-uuid", Quantity: 1, PricePerUnit: item.PricePerUnit}}}, true},
+		{"Zero Quantity", uuid.NewString(), AmendBillRequest{Reason: "Wrong quantity", Items: []AmendItem{{ID: item.ID, Quantity: 0, PricePerUnit: item.PricePerUnit}}}, true},
+		{"Negative Price", uuid.NewString(), AmendBillRequest{Reason: "Wrong quantity", Items: []AmendItem{{ID: item.ID, Quantity: 1, PricePerUnit: domain.Money{Amount: -1, Currency: "USD"}}}}, true},
+		{"Invalid Currency", uuid.NewString(), AmendBillRequest{Reason: "Wrong quantity", Items: []AmendItem{{ID: item.ID, Quantity: 1, PricePerUnit: domain.Money{Amount: 1, Currency: "XYZ"}}}}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAmendBillRequest(tc.id, &tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tc.req.RequestID)
+			}
+		})
+	}
+}
+
+func TestValidateGetBillAsOfRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		at        string
+		expectErr bool
+	}{
+		{"Valid Request", uuid.NewString(), "2025-01-01T12:00:00Z", false},
+		{"Valid Request With Offset", uuid.NewString(), "2025-01-01T12:00:00+04:00", false},
+		{"Missing Time", uuid.NewString(), "", true},
+		{"Invalid Time", uuid.NewString(), "2025-01-01", true},
+		{"Invalid ID", "invalid-uuid", "2025-01-01T12:00:00Z", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := validateGetBillAsOfRequest(tc.id, &GetBillAsOfRequest{At: tc.at})
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateGrantWalletCreditRequest(t *testing.T) {
+	credit := domain.Money{Amount: 1000, Currency: "USD"}
+
+	tests := []struct {
+		name      string
+		id        string
+		req       GrantWalletCreditRequest
+		expectErr bool
+	}{
+		{"Valid Request", uuid.NewString(), GrantWalletCreditRequest{RequestID: uuid.NewString(), Amount: credit, Reason: "Prepayment"}, false},
+		{"Empty RequestID", uuid.NewString(), GrantWalletCreditRequest{Amount: credit}, false},
+		{"Invalid RequestID", uuid.NewString(), GrantWalletCreditRequest{RequestID: "retry-1", Amount: credit}, true},
+		{"Invalid ID", "invalid-uuid", GrantWalletCreditRequest{Amount: credit}, true},
+		{"Zero Amount", uuid.NewString(), GrantWalletCreditRequest{Amount: domain.Money{Currency: "USD"}}, true},
+		{"Negative Amount", uuid.NewString(), GrantWalletCreditRequest{Amount: domain.Money{Amount: -1, Currency: "USD"}}, true},
+		{"Invalid Currency", uuid.NewString(), GrantWalletCreditRequest{Amount: domain.Money{Amount: 1, Currency: "XYZ"}}, true},
+		{"Reason Too Long", uuid.NewString(), GrantWalletCreditRequest{Amount: credit, Reason: strings.Repeat("a", maxReasonLength+1)}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateGrantWalletCreditRequest(tc.id, &tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tc.req.RequestID)
+			}
+		})
+	}
+}
+
+func TestValidateGetTrialBalanceRequest(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		req       GetTrialBalanceRequest
+		expectErr bool
+	}{
+		{"Valid Request", GetTrialBalanceRequest{Currency: "USD", AsOf: "2024-12-31T00:00:00Z"}, false},
+		{"Defaults To Now", GetTrialBalanceRequest{Currency: "GEL"}, false},
+		{"Invalid Currency", GetTrialBalanceRequest{Currency: "XYZ"}, true},
+		{"Invalid Time", GetTrialBalanceRequest{Currency: "USD", AsOf: "2024-12-31"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			asOf, err := validateGetTrialBalanceRequest(&tc.req, now)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				if tc.req.AsOf == "" {
+					assert.Equal(t, now, asOf)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGetAccountStatementRequest(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		code      string
+		req       GetAccountStatementRequest
+		expectErr bool
+	}{
+		{"Valid Request", "1100", GetAccountStatementRequest{Currency: "USD", From: "2024-12-01T00:00:00Z", To: "2025-01-01T00:00:00Z"}, false},
+		{"Open Period", "4000", GetAccountStatementRequest{Currency: "USD"}, false},
+		{"Unknown Account", "9999", GetAccountStatementRequest{Currency: "USD"}, true},
+		{"Invalid Currency", "1100", GetAccountStatementRequest{Currency: "XYZ"}, true},
+		{"Invalid From", "1100", GetAccountStatementRequest{Currency: "USD", From: "2024-12-01"}, true},
+		{"Period Ends Before Start", "1100", GetAccountStatementRequest{Currency: "USD", From: "2025-01-01T00:00:00Z", To: "2024-12-01T00:00:00Z"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, to, err := validateGetAccountStatementRequest(tc.code, &tc.req, now)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				if tc.req.To == "" {
+					assert.Equal(t, now, to)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateExportLedgerRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       ExportLedgerRequest
+		expectErr bool
+	}{
+		{"Valid Request", ExportLedgerRequest{Format: "ledger", From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"}, false},
+		{"Defaults To CSV", ExportLedgerRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"}, false},
+		{"Unknown Format", ExportLedgerRequest{Format: "xlsx", From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"}, true},
+		{"Missing From", ExportLedgerRequest{To: "2025-02-01T00:00:00Z"}, true},
+		{"Missing To", ExportLedgerRequest{From: "2025-01-01T00:00:00Z"}, true},
+		{"Empty Period", ExportLedgerRequest{From: "2025-01-01T00:00:00Z", To: "2025-01-01T00:00:00Z"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			format, _, _, err := validateExportLedgerRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				if tc.req.Format == "" {
+					assert.Equal(t, domain.ExportCSV, format)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAddLineItemsRequest(t *testing.T) {
+	item := BatchLineItem{ID: uuid.NewString(), Quantity: 1, PricePerUnit: domain.Money{Amount: 10, Currency: "USD"}}
+	tooMany := make([]BatchLineItem, maxBatchItems+1)
+	for i := range tooMany {
+		tooMany[i] = item
+	}
+
+	tests := []struct {
+		name      string
+		req       AddLineItemsRequest
+		expectErr bool
+	}{
+		{"Valid Request", AddLineItemsRequest{RequestID: uuid.NewString(), Items: []BatchLineItem{item}}, false},
+		{"Empty RequestID", AddLineItemsRequest{Items: []BatchLineItem{item, item}}, false},
+		{"No Items", AddLineItemsRequest{}, true},
+		{"Too Many Items", AddLineItemsRequest{Items: tooMany}, true},
+		{"Invalid Item ID", AddLineItemsRequest{Items: []BatchLineItem{item, {ID: "invalid", Quantity: 1, PricePerUnit: item.PricePerUnit}}}, true},
+		{"Zero Quantity", AddLineItemsRequest{Items: []BatchLineItem{{ID: item.ID, PricePerUnit: item.PricePerUnit}}}, true},
+		{"Invalid Currency", AddLineItemsRequest{Items: []BatchLineItem{{ID: item.ID, Quantity: 1, PricePerUnit: domain.Money{Amount: 10, Currency: "XYZ"}}}}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := validateAddLineItemsRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, items, len(tc.req.Items))
+				assert.NotEmpty(t, tc.req.RequestID)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateLineItemRequest(t *testing.T) {
+	itemID := uuid.NewString()
+	quantity := int64(2)
+	zero := int64(0)
+	description := "Seat"
+	price := domain.Money{Amount: 10, Currency: "USD"}
+	negative := domain.Money{Amount: -10, Currency: "USD"}
+	unknown := domain.Money{Amount: 10, Currency: "XYZ"}
+
+	tests := []struct {
+		name      string
+		itemID    string
+		req       UpdateLineItemRequest
+		expectErr bool
+	}{
+		{"Valid Quantity", itemID, UpdateLineItemRequest{Version: 1, Quantity: &quantity}, false},
+		{"Valid Description And Price", itemID, UpdateLineItemRequest{Version: 3, Description: &description, PricePerUnit: &price}, false},
+		{"Invalid Item ID", "invalid", UpdateLineItemRequest{Version: 1, Quantity: &quantity}, true},
+		{"Missing Version", itemID, UpdateLineItemRequest{Quantity: &quantity}, true},
+		{"Nothing To Update", itemID, UpdateLineItemRequest{Version: 1}, true},
+		{"Zero Quantity", itemID, UpdateLineItemRequest{Version: 1, Quantity: &zero}, true},
+		{"Negative Price", itemID, UpdateLineItemRequest{Version: 1, PricePerUnit: &negative}, true},
+		{"Invalid Currency", itemID, UpdateLineItemRequest{Version: 1, PricePerUnit: &unknown}, true},
+		{"Valid Metadata Only", itemID, UpdateLineItemRequest{Version: 1, Metadata: &domain.ItemMetadata{Category: "seats"}}, false},
+		{"Invalid Metadata", itemID, UpdateLineItemRequest{Version: 1, Metadata: &domain.ItemMetadata{Attributes: map[string]string{"": "eu"}}}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			update, err := validateUpdateLineItemRequest(tc.itemID, &tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.req.Version, update.Version)
+				assert.Equal(t, tc.req.Quantity, update.Quantity)
+				assert.NotEmpty(t, tc.req.RequestID)
+			}
+		})
+	}
+}
+
+func TestValidateItemReportRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       ItemReportRequest
+		expectErr bool
+	}{
+		{"Valid Request", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"}, false},
+		{"Valid Filters", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Category: "usage", SKU: "API-CALLS", Attributes: []string{"region=eu", "tier="}, PeriodFrom: "2024-12-01T00:00:00Z", PeriodTo: "2025-01-01T00:00:00Z"}, false},
+		{"Missing From", ItemReportRequest{To: "2025-02-01T00:00:00Z"}, true},
+		{"Missing To", ItemReportRequest{From: "2025-01-01T00:00:00Z"}, true},
+		{"Empty Period", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-01-01T00:00:00Z"}, true},
+		{"Attribute Without Value", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Attributes: []string{"region"}}, true},
+		{"Attribute Without Key", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Attributes: []string{"=eu"}}, true},
+		{"Invalid Service Period", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", PeriodFrom: "December"}, true},
+		{"Reversed Service Period", ItemReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", PeriodFrom: "2025-01-01T00:00:00Z", PeriodTo: "2024-12-01T00:00:00Z"}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := validateItemReportRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.req.Category, filter.Category)
+				assert.Len(t, filter.Attributes, len(tc.req.Attributes))
+				assert.Equal(t, tc.req.PeriodFrom != "", filter.PeriodFrom != nil)
+			}
+		})
+	}
+}
+
+func TestValidateListBillsRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       ListBillsRequest
+		expectErr bool
+	}{
+		{"Valid Request", ListBillsRequest{}, false},
+		{"Valid Filters", ListBillsRequest{UserID: uuid.NewString(), Status: "BillOpen", ExternalRef: "PO-1001", Metadata: []string{"cost_center=CC-42", "note="}, Limit: 200, Offset: 400}, false},
+		{"Invalid UserID", ListBillsRequest{UserID: "invalid-uuid"}, true},
+		{"Invalid Status", ListBillsRequest{Status: "BillClosing"}, true},
+		{"Metadata Without Value", ListBillsRequest{Metadata: []string{"cost_center"}}, true},
+		{"Metadata Without Key", ListBillsRequest{Metadata: []string{"=CC-42"}}, true},
+		{"Limit Too High", ListBillsRequest{Limit: 201}, true},
+		{"Negative Limit", ListBillsRequest{Limit: -1}, true},
+		{"Negative Offset", ListBillsRequest{Offset: -1}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := validateListBillsRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, filter.Metadata, len(tc.req.Metadata))
+				assert.Equal(t, tc.req.UserID != "", filter.UserID != nil)
+				assert.Positive(t, filter.Limit)
+			}
+		})
+	}
+}
+
+func TestValidateSearchBillsRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       SearchBillsRequest
+		expectErr bool
+	}{
+		{"Valid Request", SearchBillsRequest{Query: "annual support"}, false},
+		{"Valid Filters", SearchBillsRequest{Query: `"annual support" -trial`, UserID: uuid.NewString(), From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Limit: 20}, false},
+		{"Open Period", SearchBillsRequest{Query: "support", From: "2025-01-01T00:00:00Z"}, false},
+		{"Empty Query", SearchBillsRequest{Query: "  "}, true},
+		{"Query Too Long", SearchBillsRequest{Query: strings.Repeat("a", maxSearchQuery+1)}, true},
+		{"Invalid UserID", SearchBillsRequest{Query: "support", UserID: "invalid-uuid"}, true},
+		{"Invalid From", SearchBillsRequest{Query: "support", From: "2025-01-01"}, true},
+		{"Empty Period", SearchBillsRequest{Query: "support", From: "2025-01-01T00:00:00Z", To: "2025-01-01T00:00:00Z"}, true},
+		{"Limit Too High", SearchBillsRequest{Query: "support", Limit: maxListLimit + 1}, true},
+		{"Negative Offset", SearchBillsRequest{Query: "support", Offset: -1}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := validateSearchBillsRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, strings.TrimSpace(tc.req.Query), filter.Query)
+				assert.Equal(t, tc.req.From != "", filter.From != nil)
+				assert.Equal(t, tc.req.To != "", filter.To != nil)
+				assert.Positive(t, filter.Limit)
+			}
+		})
+	}
+}
+
+func TestValidateRevenueReportRequest(t *testing.T) {
+	tests := []struct {
+		name             string
+		req              RevenueReportRequest
+		expectedInterval domain.ReportInterval
+		expectErr        bool
+	}{
+		{"Valid Request", RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z"}, domain.IntervalDay, false},
+		{"Valid Monthly", RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2026-01-01T00:00:00Z", Interval: "month", Currency: "GEL"}, domain.IntervalMonth, false},
+		{"Missing From", RevenueReportRequest{To: "2025-02-01T00:00:00Z"}, "", true},
+		{"Invalid To", RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "February"}, "", true},
+		{"Empty Period", RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-01-01T00:00:00Z"}, "", true},
+		{"Invalid Interval", RevenueReportRequest{From: "2025-01-01T00:00:00Z", To: "2025-02-01T00:00:00Z", Interval: "week"}, "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, interval, err := validateRevenueReportRequest(&tc.req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedInterval, interval)
+			}
+		})
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		revisions []int
+		expectErr bool
+	}{
+		{"Absent", "", nil, false},
+		{"Any", "*", nil, false},
+		{"Single", `"4"`, []int{4}, false},
+		{"List", `"4", "5"`, []int{4, 5}, false},
+		{"Weak", `W/"4"`, nil, true},
+		{"Unquoted", "4", nil, true},
+		{"Not A Revision", `"abc"`, nil, true},
+		{"Negative", `"-1"`, nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			revisions, err := parseIfMatch(tc.header)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.revisions, revisions)
+			}
+		})
+	}
+
+	// ETags of bills are matched by the revisions they were issued for
+	revisions, err := parseIfMatch(billETag(7))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{7}, revisions)
+}
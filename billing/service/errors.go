@@ -0,0 +1,65 @@
+package billing
+
+import (
+	"fmt"
+
+	"encore.dev/beta/errs"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
+// ErrorDetails are returned to clients alongside typed API errors.
+type ErrorDetails struct {
+	BillID string        `json:"bill_id,omitempty"`
+	Field  string        `json:"field,omitempty"`
+	Status domain.Status `json:"status,omitempty"`
+}
+
+func (ErrorDetails) ErrDetails() {}
+
+// Build a typed API error with the given code and details.
+// The cause is appended to the message, except for internal errors
+// where it is only kept in the error metadata and never exposed to clients.
+func newError(code errs.ErrCode, details ErrorDetails, msg string, cause error) error {
+	e := &errs.Error{Code: code, Message: msg, Details: details}
+	if cause == nil {
+		return e
+	}
+
+	if code == errs.Internal {
+		e.Meta = errs.Metadata{"cause": cause.Error()}
+	} else {
+		e.Message = fmt.Sprintf("%s: %v", msg, cause)
+	}
+	return e
+}
+
+// Map the kind of a billing failure to the code it is reported with.
+func codeOf(err error) errs.ErrCode {
+	switch billerr.Kind(err) {
+	case billerr.ErrNotFound:
+		return errs.NotFound
+	case billerr.ErrInvalidRequest, billerr.ErrUserInput:
+		return errs.InvalidArgument
+	case billerr.ErrDuplicateRequest, billerr.ErrWorkflowExists:
+		return errs.AlreadyExists
+	case billerr.ErrVersionConflict:
+		return errs.Aborted
+	case billerr.ErrBillClosed, billerr.ErrBillClosing, billerr.ErrBillCancelled, billerr.ErrBillAmended,
+		billerr.ErrAwaitingApproval, billerr.ErrCloseRejected, billerr.ErrStaleRevision:
+		return errs.FailedPrecondition
+	case billerr.ErrTransient:
+		return errs.Unavailable
+	}
+	return errs.Internal
+}
+
+// Build a typed API error from a failure of the Execution layer.
+// Clients only see the kind of the failure, while internal errors keep their full cause.
+func executionError(details ErrorDetails, msg string, err error) error {
+	code := codeOf(err)
+	if code == errs.Internal {
+		return newError(code, details, msg, err)
+	}
+	return newError(code, details, msg, billerr.Kind(err))
+}
@@ -2,13 +2,18 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/vvvakho/feezy/billing/billerr"
 	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
+	"github.com/vvvakho/feezy/billing/tenant"
 	"github.com/vvvakho/feezy/billing/workflows"
 	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 )
 
 type TemporalClient struct {
@@ -28,18 +33,53 @@ func (tc *TemporalClient) Close() {
 	tc.Client.Close()
 }
 
-func (tc *TemporalClient) IsWorkflowRunning(workflowsID string) error {
-	response, err := tc.Client.DescribeWorkflowExecution(context.Background(), workflowsID, "")
+// Resolve the workflow ID of a bill within the tenant of the request context.
+// Bills of the default tenant opened before tenants were introduced keep their legacy workflow ID
+// until their workflow completes.
+func (tc *TemporalClient) workflowID(ctx context.Context, billID string) (string, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	w := workflows.WorkflowID(tenantID, billID)
+	if tenantID != conf.DEFAULT_TENANT {
+		return w, nil
+	}
+
+	var notFound *serviceerror.NotFound
+	if _, err := tc.Client.DescribeWorkflowExecution(ctx, w, ""); !errors.As(err, &notFound) {
+		return w, nil
+	}
+	legacy := workflows.LegacyWorkflowID(billID)
+	if _, err := tc.Client.DescribeWorkflowExecution(ctx, legacy, ""); err != nil {
+		return w, nil
+	}
+	return legacy, nil
+}
+
+func (tc *TemporalClient) IsWorkflowRunning(ctx context.Context, billID string) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	response, err := tc.Client.DescribeWorkflowExecution(ctx, w, "")
 	if err != nil {
 		return err
 	}
 	if response.WorkflowExecutionInfo.Status != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
-		return fmt.Errorf("Workflow not running")
+		return fmt.Errorf("Workflow not running: %w", billerr.ErrBillClosing)
 	}
 	return nil
 }
 
-func (tc *TemporalClient) GetBillQuery(ctx context.Context, w string, bill *domain.Bill) error {
+func (tc *TemporalClient) GetBillQuery(ctx context.Context, billID string, bill *domain.Bill) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
 	// Start signal synchronously
 	resp, err := tc.Client.QueryWorkflow(ctx, w, "", "getBill")
 	if err != nil {
@@ -54,11 +94,18 @@ func (tc *TemporalClient) GetBillQuery(ctx context.Context, w string, bill *doma
 }
 
 func (tc *TemporalClient) CreateBillWorkflow(ctx context.Context, bill *domain.Bill) error {
-	_, err := tc.Client.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-		ID:        bill.ID.String(),
-		TaskQueue: "create-bill-queue",
-	}, workflows.BillWorkflow, bill)
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if bill.TenantID != tenantID {
+		return fmt.Errorf("bill belongs to tenant %s, not %s", bill.TenantID, tenantID)
+	}
 
+	_, err = tc.Client.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        workflows.WorkflowID(tenantID, bill.ID.String()),
+		TaskQueue: workflows.TaskQueue(tenantID),
+	}, workflows.BillWorkflow, bill)
 	if err != nil {
 		return fmt.Errorf("Unable to initiate workflows: %v", err)
 	}
@@ -66,8 +113,13 @@ func (tc *TemporalClient) CreateBillWorkflow(ctx context.Context, bill *domain.B
 	return nil
 }
 
-func (tc *TemporalClient) AddLineItemSignal(ctx context.Context, w string, billItem *domain.Item) error {
-	err := tc.Client.SignalWorkflow(ctx, w, "", workflows.AddLineItemRoute.Name, workflows.AddItemSignal{LineItem: *billItem})
+func (tc *TemporalClient) AddLineItemSignal(ctx context.Context, billID string, addReq *workflows.AddItemSignal) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	err = tc.Client.SignalWorkflow(ctx, w, "", workflows.AddLineItemRoute.Name, addReq)
 	if err != nil {
 		return fmt.Errorf("Error signaling %s task: %v", workflows.AddLineItemRoute.Name, err)
 	}
@@ -75,8 +127,13 @@ func (tc *TemporalClient) AddLineItemSignal(ctx context.Context, w string, billI
 	return nil
 }
 
-func (tc *TemporalClient) RemoveLineItemSignal(ctx context.Context, w string, billItem *domain.Item) error {
-	err := tc.Client.SignalWorkflow(ctx, w, "", workflows.RemoveLineItemRoute.Name, workflows.AddItemSignal{LineItem: *billItem})
+func (tc *TemporalClient) RemoveLineItemSignal(ctx context.Context, billID string, removeReq *workflows.RemoveItemSignal) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	err = tc.Client.SignalWorkflow(ctx, w, "", workflows.RemoveLineItemRoute.Name, removeReq)
 	if err != nil {
 		return fmt.Errorf("Error signaling %s task: %v", workflows.RemoveLineItemRoute.Name, err)
 	}
@@ -84,8 +141,13 @@ func (tc *TemporalClient) RemoveLineItemSignal(ctx context.Context, w string, bi
 	return nil
 }
 
-func (tc *TemporalClient) CloseBillSignal(ctx context.Context, w string, closeReq *workflows.CloseBillSignal) error {
-	err := tc.Client.SignalWorkflow(ctx, w, "", workflows.CloseBillRoute.Name, closeReq)
+func (tc *TemporalClient) CloseBillSignal(ctx context.Context, billID string, closeReq *workflows.CloseBillSignal) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	err = tc.Client.SignalWorkflow(ctx, w, "", workflows.CloseBillRoute.Name, closeReq)
 	if err != nil {
 		return fmt.Errorf("Error signaling %s task: %v", workflows.CloseBillRoute.Name, err)
 	}
@@ -93,32 +155,185 @@ func (tc *TemporalClient) CloseBillSignal(ctx context.Context, w string, closeRe
 	return nil
 }
 
-func (tc *TemporalClient) CloseBillUpdate(ctx context.Context, w string, closeReq *workflows.CloseBillSignal) (*domain.Bill, error) {
+// AddLineItemsUpdate adds a batch of line items to an open bill, returning the bill with the items added.
+// Retries of the same request are answered with the result of the first.
+func (tc *TemporalClient) AddLineItemsUpdate(ctx context.Context, billID string, addReq *workflows.AddItemsUpdate) (*domain.Bill, error) {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return &domain.Bill{}, err
+	}
+
+	updateHandle, err := tc.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   w,
+		UpdateID:     addReq.RequestID,
+		UpdateName:   "AddLineItemsUpdate",
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{addReq.RequestID, addReq.LineItems, addReq.ActorID, addReq.ExpectedRevision},
+	})
+	if err != nil {
+		return &domain.Bill{}, fmt.Errorf("Error updating %s task: %w", "AddLineItemsUpdate", err)
+	}
+
+	var bill *domain.Bill
+	err = updateHandle.Get(ctx, &bill)
+	if err != nil {
+		// Keep the workflow's error kind so the API can report it
+		return &domain.Bill{}, fmt.Errorf("Error getting update result: %w", err)
+	}
+
+	return bill, nil
+}
+
+// UpdateLineItemUpdate changes a line item of an open bill in place, returning the bill with the item updated.
+// Retries of the same request are answered with the result of the first.
+func (tc *TemporalClient) UpdateLineItemUpdate(ctx context.Context, billID string, updateReq *workflows.UpdateItemUpdate) (*domain.Bill, error) {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return &domain.Bill{}, err
+	}
+
+	updateHandle, err := tc.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   w,
+		UpdateID:     updateReq.RequestID,
+		UpdateName:   "UpdateLineItemUpdate",
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{updateReq.RequestID, updateReq.Update, updateReq.ActorID, updateReq.ExpectedRevision},
+	})
+	if err != nil {
+		return &domain.Bill{}, fmt.Errorf("Error updating %s task: %w", "UpdateLineItemUpdate", err)
+	}
+
+	var bill *domain.Bill
+	err = updateHandle.Get(ctx, &bill)
+	if err != nil {
+		// Keep the workflow's error kind so the API can report it
+		return &domain.Bill{}, fmt.Errorf("Error getting update result: %w", err)
+	}
+
+	return bill, nil
+}
+
+func (tc *TemporalClient) CloseBillUpdate(ctx context.Context, billID string, closeReq *workflows.CloseBillSignal) (*domain.Bill, error) {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return &domain.Bill{}, err
+	}
+
 	updateHandle, err := tc.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
 		WorkflowID:   w,
 		UpdateName:   "CloseBillUpdate",
 		WaitForStage: client.WorkflowUpdateStageCompleted,
-		Args:         []any{closeReq.RequestID},
+		Args:         []any{closeReq.RequestID, closeReq.BillingProfile, closeReq.ActorID, closeReq.ApprovalPolicy, closeReq.ExpectedRevision, closeReq.Paid},
 	})
 	if err != nil {
-		return &domain.Bill{}, fmt.Errorf("Error updating %s task: %v", "CloseBillUpdate", err)
+		return &domain.Bill{}, fmt.Errorf("Error updating %s task: %w", "CloseBillUpdate", err)
 	}
 
 	var closedBill *domain.Bill
 	err = updateHandle.Get(ctx, &closedBill)
 	if err != nil {
-		return &domain.Bill{}, fmt.Errorf("Error getting update result: %v", err)
+		// Keep the workflow's error kind so the API can report it
+		return &domain.Bill{}, fmt.Errorf("Error getting update result: %w", err)
 	}
 
-	if err := tc.CloseWorkflowSignal(ctx, w, closeReq); err != nil {
+	// Bills awaiting approval are closed by their workflow once approved
+	if closedBill.Status == domain.BillAwaitingApproval {
+		return closedBill, nil
+	}
+
+	if err := tc.CloseWorkflowSignal(ctx, billID, closeReq); err != nil {
 		return &domain.Bill{}, fmt.Errorf("Error closing workflow: %v", err)
 	}
 
 	return closedBill, nil
 }
 
-func (tc *TemporalClient) CloseWorkflowSignal(ctx context.Context, w string, closeReq *workflows.CloseBillSignal) error {
-	err := tc.Client.SignalWorkflow(ctx, w, "", workflows.CloseWorkflowRoute.Name, closeReq)
+func (tc *TemporalClient) CloseApprovalSignal(ctx context.Context, billID string, decision *workflows.CloseApprovalSignal) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	err = tc.Client.SignalWorkflow(ctx, w, "", workflows.CloseApprovalRoute.Name, decision)
+	if err != nil {
+		return fmt.Errorf("Error signaling %s task: %v", workflows.CloseApprovalRoute.Name, err)
+	}
+
+	return nil
+}
+
+func (tc *TemporalClient) CancelBillUpdate(ctx context.Context, billID string, cancelReq *workflows.CancelBillSignal) (*domain.Bill, error) {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return &domain.Bill{}, err
+	}
+
+	updateHandle, err := tc.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   w,
+		UpdateName:   "CancelBillUpdate",
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+		Args:         []any{cancelReq.RequestID, cancelReq.Reason, cancelReq.ActorID},
+	})
+	if err != nil {
+		return &domain.Bill{}, fmt.Errorf("Error updating %s task: %w", "CancelBillUpdate", err)
+	}
+
+	var cancelledBill *domain.Bill
+	err = updateHandle.Get(ctx, &cancelledBill)
+	if err != nil {
+		// Keep the workflow's error kind so the API can report it
+		return &domain.Bill{}, fmt.Errorf("Error getting update result: %w", err)
+	}
+
+	// Cancelled bills are final, so their workflow can finish
+	if err := tc.CloseWorkflowSignal(ctx, billID, &workflows.CloseBillSignal{RequestID: cancelReq.RequestID}); err != nil {
+		return &domain.Bill{}, fmt.Errorf("Error closing workflow: %v", err)
+	}
+
+	return cancelledBill, nil
+}
+
+func (tc *TemporalClient) AmendBillWorkflow(ctx context.Context, bill *domain.Bill, amendment *domain.Amendment, requestID string) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if bill.TenantID != tenantID {
+		return fmt.Errorf("bill belongs to tenant %s, not %s", bill.TenantID, tenantID)
+	}
+	if bill.AmendsBillID == nil {
+		return fmt.Errorf("bill %s does not amend another version: %w", bill.ID, billerr.ErrInvalidRequest)
+	}
+
+	run, err := tc.Client.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		// One amendment of a bill version may run at a time, a concurrent one is rejected
+		ID:                       workflows.AmendWorkflowID(tenantID, bill.AmendsBillID.String()),
+		TaskQueue:                workflows.TaskQueue(tenantID),
+		WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_FAIL,
+	}, workflows.AmendBillWorkflow, bill, amendment, requestID)
+
+	if temporal.IsWorkflowExecutionAlreadyStartedError(err) {
+		return fmt.Errorf("Unable to initiate workflows: %w", billerr.ErrWorkflowExists)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to initiate workflows: %v", err)
+	}
+
+	// Keep the workflow's error kind so the API can report it
+	if err := run.Get(ctx, nil); err != nil {
+		return fmt.Errorf("Error amending bill: %w", err)
+	}
+
+	return nil
+}
+
+func (tc *TemporalClient) CloseWorkflowSignal(ctx context.Context, billID string, closeReq *workflows.CloseBillSignal) error {
+	w, err := tc.workflowID(ctx, billID)
+	if err != nil {
+		return err
+	}
+
+	err = tc.Client.SignalWorkflow(ctx, w, "", workflows.CloseWorkflowRoute.Name, closeReq)
 	if err != nil {
 		return fmt.Errorf("Error signaling %s task: %v", workflows.CloseBillRoute.Name, err)
 	}
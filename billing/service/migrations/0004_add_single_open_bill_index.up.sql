@@ -0,0 +1,5 @@
+ALTER TABLE open_bills ADD COLUMN single_open BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Bills created while the single open bill policy is enabled are flagged,
+-- so that at most one of them may exist per user and currency
+CREATE UNIQUE INDEX idx_open_bills_single_open ON open_bills(user_id, currency) WHERE single_open;
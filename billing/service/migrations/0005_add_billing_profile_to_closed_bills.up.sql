@@ -0,0 +1,2 @@
+-- Snapshot of the customer's billing profile at the time the bill was closed
+ALTER TABLE closed_bills ADD COLUMN billing_profile JSONB;
@@ -0,0 +1,17 @@
+-- Existing rows belong to the default tenant
+ALTER TABLE open_bills ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE closed_bills ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE closed_bills_items ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+-- Tax applied under the tenant's configuration when the bill was closed
+ALTER TABLE closed_bills ADD COLUMN tax_rate INT NOT NULL DEFAULT 0;
+ALTER TABLE closed_bills ADD COLUMN tax_amount DECIMAL(18, 4) NOT NULL DEFAULT 0;
+
+-- The single open bill policy applies within a tenant
+DROP INDEX idx_open_bills_single_open;
+CREATE UNIQUE INDEX idx_open_bills_single_open ON open_bills(tenant_id, user_id, currency) WHERE single_open;
+
+-- Indices
+CREATE INDEX idx_open_bills_tenant_id ON open_bills(tenant_id);
+CREATE INDEX idx_closed_bills_tenant_id ON closed_bills(tenant_id);
+CREATE INDEX idx_closed_bills_items_tenant_id ON closed_bills_items(tenant_id);
@@ -0,0 +1,4 @@
+-- Cancelled bills are kept in closed_bills with the reason and actor of the cancellation
+ALTER TABLE closed_bills ADD COLUMN cancel_reason TEXT;
+ALTER TABLE closed_bills ADD COLUMN cancelled_by UUID;
+ALTER TABLE closed_bills ADD COLUMN cancelled_at TIMESTAMP;
@@ -0,0 +1,27 @@
+-- Amended bills are stored as new versions, linked to the version they replace
+ALTER TABLE closed_bills ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE closed_bills ADD COLUMN amends_bill_id UUID REFERENCES closed_bills(id);
+
+-- Each version can be amended at most once, keeping the history linear
+CREATE UNIQUE INDEX idx_closed_bills_amends_bill_id ON closed_bills(amends_bill_id) WHERE amends_bill_id IS NOT NULL;
+
+-- Item changes and the credit or debit issued for each amendment
+CREATE TABLE bill_amendments (
+    id                UUID PRIMARY KEY,
+    tenant_id         TEXT NOT NULL,
+    original_bill_id  UUID NOT NULL REFERENCES closed_bills(id),
+    amended_bill_id   UUID NOT NULL UNIQUE REFERENCES closed_bills(id),
+    version           INT NOT NULL,
+    changes           JSONB NOT NULL,
+    adjustment_type   VARCHAR(10) NOT NULL,
+    adjustment_amount DECIMAL(18, 4) NOT NULL,
+    currency          CHAR(3) NOT NULL,
+    reason            TEXT NOT NULL,
+    actor_id          UUID NOT NULL,
+    request_id        UUID UNIQUE,
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indices
+CREATE INDEX idx_bill_amendments_original_bill_id ON bill_amendments(original_bill_id);
+CREATE INDEX idx_bill_amendments_tenant_id ON bill_amendments(tenant_id);
@@ -0,0 +1,18 @@
+-- History of changes made to bills, persisted once a bill leaves its workflow
+CREATE TABLE bill_events (
+    bill_id      UUID NOT NULL REFERENCES closed_bills(id),
+    sequence     INT NOT NULL,
+    tenant_id    TEXT NOT NULL,
+    type         VARCHAR(50) NOT NULL,
+    actor_id     TEXT NOT NULL DEFAULT '',
+    request_id   TEXT NOT NULL DEFAULT '',
+    item         JSONB,
+    total_before DECIMAL(18, 4) NOT NULL,
+    total_after  DECIMAL(18, 4) NOT NULL,
+    currency     CHAR(3) NOT NULL,
+    occurred_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (bill_id, sequence)
+);
+
+-- Indices
+CREATE INDEX idx_bill_events_tenant_id ON bill_events(tenant_id);
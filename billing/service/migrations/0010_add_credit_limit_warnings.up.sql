@@ -0,0 +1,2 @@
+-- Percentage of the credit limit reached, recorded with credit limit warnings
+ALTER TABLE bill_events ADD COLUMN threshold INT NOT NULL DEFAULT 0;
@@ -0,0 +1,18 @@
+-- Due date and collection status of closed bills, NULL for bills closed without payment terms
+ALTER TABLE closed_bills ADD COLUMN due_at TIMESTAMP;
+ALTER TABLE closed_bills ADD COLUMN collection_status VARCHAR(20);
+
+-- Late fees charged by the dunning workflow of overdue bills
+CREATE TABLE bill_late_fees (
+    id         UUID PRIMARY KEY,
+    bill_id    UUID NOT NULL REFERENCES closed_bills(id),
+    tenant_id  TEXT NOT NULL,
+    amount     DECIMAL(18, 4) NOT NULL,
+    currency   CHAR(3) NOT NULL,
+    request_id UUID UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indices
+CREATE INDEX idx_closed_bills_due_at ON closed_bills(due_at) WHERE collection_status = 'unpaid';
+CREATE INDEX idx_bill_late_fees_bill_id ON bill_late_fees(bill_id);
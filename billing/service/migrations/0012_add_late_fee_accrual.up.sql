@@ -0,0 +1,4 @@
+-- Late fees are accrued as fee line items of overdue bills by the late fee schedule
+ALTER TABLE bill_late_fees ADD COLUMN fee_type VARCHAR(20) NOT NULL DEFAULT 'flat';
+ALTER TABLE bill_late_fees ADD COLUMN description TEXT NOT NULL DEFAULT 'Late fee';
+ALTER TABLE bill_late_fees ADD COLUMN accrued_through TIMESTAMP;
@@ -0,0 +1,30 @@
+-- Prepaid credit of customers, with a balance per currency that is drawn down when their bills close
+CREATE TABLE wallets (
+    tenant_id  TEXT NOT NULL,
+    user_id    UUID NOT NULL,
+    currency   CHAR(3) NOT NULL,
+    balance    DECIMAL(18, 4) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id, currency)
+);
+
+-- Ledger of the credit grants and consumptions that wallet balances result from
+CREATE TABLE wallet_entries (
+    id         UUID PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    user_id    UUID NOT NULL,
+    type       VARCHAR(20) NOT NULL,
+    amount     DECIMAL(18, 4) NOT NULL,
+    currency   CHAR(3) NOT NULL,
+    bill_id    UUID REFERENCES closed_bills(id),
+    reason     TEXT NOT NULL DEFAULT '',
+    actor_id   TEXT NOT NULL DEFAULT '',
+    request_id UUID NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Credit drawn down against the amount due when a bill was closed
+ALTER TABLE closed_bills ADD COLUMN credit_applied DECIMAL(18, 4) NOT NULL DEFAULT 0;
+
+-- Indices
+CREATE INDEX idx_wallet_entries_user_id ON wallet_entries(tenant_id, user_id, created_at);
@@ -0,0 +1,66 @@
+-- Chart of accounts of the general ledger, shared by every tenant
+CREATE TABLE ledger_accounts (
+    code VARCHAR(10) PRIMARY KEY,
+    name TEXT NOT NULL,
+    type VARCHAR(20) NOT NULL
+);
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('1000', 'Cash', 'asset'),
+    ('1100', 'Accounts receivable', 'asset'),
+    ('2100', 'Customer prepaid credit', 'liability'),
+    ('2200', 'Tax payable', 'liability'),
+    ('4000', 'Revenue', 'revenue'),
+    ('4100', 'Late fee income', 'revenue'),
+    ('5100', 'Bad debt expense', 'expense');
+
+-- Journal entries record every billing money movement once, under a source key unique within the tenant
+CREATE TABLE journal_entries (
+    id          UUID PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    source      VARCHAR(30) NOT NULL,
+    source_key  TEXT NOT NULL,
+    bill_id     UUID,
+    description TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, source_key)
+);
+
+-- Postings debit or credit a single account, and must balance within their entry and currency
+CREATE TABLE journal_postings (
+    id           BIGSERIAL PRIMARY KEY,
+    entry_id     UUID NOT NULL REFERENCES journal_entries(id),
+    tenant_id    TEXT NOT NULL,
+    account_code VARCHAR(10) NOT NULL REFERENCES ledger_accounts(code),
+    debit        DECIMAL(18, 4) NOT NULL DEFAULT 0,
+    credit       DECIMAL(18, 4) NOT NULL DEFAULT 0,
+    currency     CHAR(3) NOT NULL,
+    CHECK (debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0))
+);
+
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM journal_postings
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency
+        HAVING SUM(debit) <> SUM(credit)
+    ) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id USING ERRCODE = '23514';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Checked once the transaction writing the entry commits, after all of its postings were inserted
+CREATE CONSTRAINT TRIGGER journal_postings_balanced
+    AFTER INSERT OR UPDATE ON journal_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Indices
+CREATE INDEX idx_journal_entries_bill_id ON journal_entries(bill_id);
+CREATE INDEX idx_journal_entries_occurred_at ON journal_entries(tenant_id, occurred_at);
+CREATE INDEX idx_journal_postings_account ON journal_postings(tenant_id, account_code, currency);
+CREATE INDEX idx_journal_postings_entry_id ON journal_postings(entry_id);
@@ -0,0 +1,6 @@
+-- Category, SKU, custom attributes and service period of each item, for grouping and reporting fees by type
+ALTER TABLE closed_bills_items ADD COLUMN metadata JSONB;
+
+-- Reports filter items by category and by the attributes they contain
+CREATE INDEX idx_closed_bills_items_category ON closed_bills_items(tenant_id, (metadata->>'Category'));
+CREATE INDEX idx_closed_bills_items_metadata ON closed_bills_items USING GIN (metadata jsonb_path_ops);
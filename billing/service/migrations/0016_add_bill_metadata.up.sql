@@ -0,0 +1,11 @@
+-- The customer's own reference and labels of each bill, e.g. order IDs, cost-center codes and notes
+ALTER TABLE open_bills ADD COLUMN external_ref TEXT;
+ALTER TABLE open_bills ADD COLUMN metadata JSONB;
+ALTER TABLE closed_bills ADD COLUMN external_ref TEXT;
+ALTER TABLE closed_bills ADD COLUMN metadata JSONB;
+
+-- Bills are listed by their external reference and by the metadata they contain
+CREATE INDEX idx_open_bills_external_ref ON open_bills(tenant_id, external_ref);
+CREATE INDEX idx_closed_bills_external_ref ON closed_bills(tenant_id, external_ref);
+CREATE INDEX idx_open_bills_metadata ON open_bills USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_closed_bills_metadata ON closed_bills USING GIN (metadata jsonb_path_ops);
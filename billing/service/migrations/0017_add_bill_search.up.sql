@@ -0,0 +1,14 @@
+-- Full-text search over item descriptions, with their category and SKU, and over the reference and metadata
+-- values of closed bills. The documents are generated, so they stay in sync with every write.
+ALTER TABLE closed_bills_items ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(description, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(metadata->>'Category', '') || ' ' || COALESCE(metadata->>'SKU', '')), 'B')
+) STORED;
+
+ALTER TABLE closed_bills ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(external_ref, '')), 'A') ||
+    setweight(jsonb_to_tsvector('english', COALESCE(metadata, '{}'), '["string"]'), 'B')
+) STORED;
+
+CREATE INDEX idx_closed_bills_items_search ON closed_bills_items USING GIN (search_vector);
+CREATE INDEX idx_closed_bills_search ON closed_bills USING GIN (search_vector);
@@ -0,0 +1,37 @@
+-- Rollups behind the analytics reports, rebuilt per tenant by the scheduled analytics workflow.
+-- Revenue counts the latest version of every closed bill, on the day it was closed, in its own currency.
+CREATE TABLE revenue_daily (
+    tenant_id TEXT NOT NULL,
+    day       DATE NOT NULL,
+    currency  CHAR(3) NOT NULL,
+    bills     INT NOT NULL,
+    subtotal  BIGINT NOT NULL,
+    tax       BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, day, currency)
+);
+
+-- Items of the same bills by category, in the currency each item was priced in
+CREATE TABLE revenue_daily_categories (
+    tenant_id TEXT NOT NULL,
+    day       DATE NOT NULL,
+    currency  CHAR(3) NOT NULL,
+    category  TEXT NOT NULL,
+    lines     INT NOT NULL,
+    quantity  BIGINT NOT NULL,
+    amount    BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, day, currency, category)
+);
+
+-- Bills by status and currency as of the last refresh, counting the latest version of amended bills
+CREATE TABLE bill_counts (
+    tenant_id TEXT NOT NULL,
+    status    TEXT NOT NULL,
+    currency  CHAR(3) NOT NULL,
+    bills     INT NOT NULL,
+    PRIMARY KEY (tenant_id, status, currency)
+);
+
+CREATE TABLE analytics_refreshes (
+    tenant_id    TEXT PRIMARY KEY,
+    refreshed_at TIMESTAMP NOT NULL
+);
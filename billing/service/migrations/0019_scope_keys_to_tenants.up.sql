@@ -0,0 +1,46 @@
+-- Bill IDs and request IDs are unique within a tenant only, so that keys of one tenant
+-- never collide with, or reveal, the rows of another
+
+-- References to closed bills are rebuilt against the tenant scoped key
+ALTER TABLE closed_bills_items DROP CONSTRAINT closed_bills_items_bill_id_fkey;
+ALTER TABLE closed_bills DROP CONSTRAINT closed_bills_amends_bill_id_fkey;
+ALTER TABLE bill_amendments DROP CONSTRAINT bill_amendments_original_bill_id_fkey;
+ALTER TABLE bill_amendments DROP CONSTRAINT bill_amendments_amended_bill_id_fkey;
+ALTER TABLE bill_events DROP CONSTRAINT bill_events_bill_id_fkey;
+ALTER TABLE bill_late_fees DROP CONSTRAINT bill_late_fees_bill_id_fkey;
+ALTER TABLE wallet_entries DROP CONSTRAINT wallet_entries_bill_id_fkey;
+
+-- Primary keys
+ALTER TABLE open_bills DROP CONSTRAINT open_bills_pkey;
+ALTER TABLE open_bills ADD PRIMARY KEY (tenant_id, id);
+ALTER TABLE closed_bills DROP CONSTRAINT closed_bills_pkey;
+ALTER TABLE closed_bills ADD PRIMARY KEY (tenant_id, id);
+ALTER TABLE bill_events DROP CONSTRAINT bill_events_pkey;
+ALTER TABLE bill_events ADD PRIMARY KEY (tenant_id, bill_id, sequence);
+
+-- Unique keys
+ALTER TABLE open_bills DROP CONSTRAINT open_bills_request_id_key;
+CREATE UNIQUE INDEX idx_open_bills_request_id ON open_bills(tenant_id, request_id);
+ALTER TABLE closed_bills DROP CONSTRAINT closed_bills_request_id_key;
+CREATE UNIQUE INDEX idx_closed_bills_request_id ON closed_bills(tenant_id, request_id);
+ALTER TABLE closed_bills_items DROP CONSTRAINT closed_bills_items_bill_id_item_id_key;
+ALTER TABLE closed_bills_items ADD UNIQUE (tenant_id, bill_id, item_id);
+DROP INDEX idx_closed_bills_amends_bill_id;
+CREATE UNIQUE INDEX idx_closed_bills_amends_bill_id ON closed_bills(tenant_id, amends_bill_id) WHERE amends_bill_id IS NOT NULL;
+ALTER TABLE bill_amendments DROP CONSTRAINT bill_amendments_amended_bill_id_key;
+ALTER TABLE bill_amendments ADD UNIQUE (tenant_id, amended_bill_id);
+ALTER TABLE bill_amendments DROP CONSTRAINT bill_amendments_request_id_key;
+ALTER TABLE bill_amendments ADD UNIQUE (tenant_id, request_id);
+ALTER TABLE bill_late_fees DROP CONSTRAINT bill_late_fees_request_id_key;
+ALTER TABLE bill_late_fees ADD UNIQUE (tenant_id, request_id);
+ALTER TABLE wallet_entries DROP CONSTRAINT wallet_entries_request_id_key;
+ALTER TABLE wallet_entries ADD UNIQUE (tenant_id, request_id);
+
+-- Foreign keys
+ALTER TABLE closed_bills_items ADD FOREIGN KEY (tenant_id, bill_id) REFERENCES closed_bills(tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE closed_bills ADD FOREIGN KEY (tenant_id, amends_bill_id) REFERENCES closed_bills(tenant_id, id);
+ALTER TABLE bill_amendments ADD FOREIGN KEY (tenant_id, original_bill_id) REFERENCES closed_bills(tenant_id, id);
+ALTER TABLE bill_amendments ADD FOREIGN KEY (tenant_id, amended_bill_id) REFERENCES closed_bills(tenant_id, id);
+ALTER TABLE bill_events ADD FOREIGN KEY (tenant_id, bill_id) REFERENCES closed_bills(tenant_id, id);
+ALTER TABLE bill_late_fees ADD FOREIGN KEY (tenant_id, bill_id) REFERENCES closed_bills(tenant_id, id);
+ALTER TABLE wallet_entries ADD FOREIGN KEY (tenant_id, bill_id) REFERENCES closed_bills(tenant_id, id);
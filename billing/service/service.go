@@ -2,10 +2,21 @@ package billing
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"encore.dev/middleware"
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	"github.com/vvvakho/feezy/billing/service/execution"
+	"github.com/vvvakho/feezy/billing/tenant"
 	"github.com/vvvakho/feezy/billing/workflows"
 )
 
@@ -19,18 +30,42 @@ type Service struct {
 type Execution interface {
 	CreateBillWorkflow(context.Context, *domain.Bill) error
 	GetBillQuery(context.Context, string, *domain.Bill) error
-	IsWorkflowRunning(string) error
-	AddLineItemSignal(context.Context, string, *domain.Item) error
-	RemoveLineItemSignal(context.Context, string, *domain.Item) error
+	IsWorkflowRunning(context.Context, string) error
+	AddLineItemSignal(context.Context, string, *workflows.AddItemSignal) error
+	AddLineItemsUpdate(context.Context, string, *workflows.AddItemsUpdate) (*domain.Bill, error)
+	UpdateLineItemUpdate(context.Context, string, *workflows.UpdateItemUpdate) (*domain.Bill, error)
+	RemoveLineItemSignal(context.Context, string, *workflows.RemoveItemSignal) error
 	CloseBillUpdate(context.Context, string, *workflows.CloseBillSignal) (*domain.Bill, error)
+	CloseApprovalSignal(context.Context, string, *workflows.CloseApprovalSignal) error
+	CancelBillUpdate(context.Context, string, *workflows.CancelBillSignal) (*domain.Bill, error)
+	AmendBillWorkflow(context.Context, *domain.Bill, *domain.Amendment, string) error
 	Close()
 }
 
 // Interface for the Repository entity
 type Repository interface {
 	GetOpenBillFromDB(context.Context, string) (*domain.Bill, error)
+	GetOpenBillByUserFromDB(context.Context, string, string) (*domain.Bill, error)
+	ClaimOpenBillInDB(context.Context, *domain.Bill) (*domain.Bill, error)
+	ReleaseOpenBillFromDB(context.Context, string) error
 	GetClosedBillFromDB(context.Context, string) (*domain.Bill, error)
 	GetClosedBillItemsFromDB(context.Context, string) ([]domain.Item, error)
+	GetBillingProfile(context.Context, string) (*domain.BillingProfile, error)
+	GetCreditLimit(context.Context, string) (*domain.Money, error)
+	GetBillAmendmentsFromDB(context.Context, string) ([]domain.Amendment, error)
+	GetBillEventsFromDB(context.Context, string) ([]domain.Event, error)
+	GetWalletFromDB(context.Context, string) (*domain.Wallet, error)
+	AddWalletGrantToDB(context.Context, *domain.WalletEntry) error
+	GetTrialBalanceFromDB(context.Context, string, time.Time) ([]domain.AccountTotal, error)
+	GetAccountPostingsFromDB(context.Context, string, string, time.Time, time.Time) (domain.AccountTotal, []domain.StatementLine, error)
+	ListJournalEntriesFromDB(context.Context, time.Time, time.Time) ([]domain.JournalEntry, error)
+	GetItemTotalsFromDB(context.Context, domain.ItemFilter) ([]domain.ItemTotal, error)
+	ListBillsFromDB(context.Context, domain.BillFilter) ([]domain.Bill, error)
+	SearchBillsFromDB(context.Context, domain.SearchFilter) ([]domain.SearchResult, error)
+	GetDailyRevenueFromDB(context.Context, time.Time, time.Time) ([]domain.DailyRevenue, error)
+	GetCategoryRevenueFromDB(context.Context, time.Time, time.Time) ([]domain.ItemTotal, error)
+	GetBillCountsFromDB(context.Context) ([]domain.BillCount, error)
+	GetAnalyticsRefreshFromDB(context.Context) (*time.Time, error)
 }
 
 // Initialize billing service with an Execution and Repository entities
@@ -54,6 +89,255 @@ func initService() (*Service, error) {
 	}, nil
 }
 
+// ScopeMiddleware scopes every request to the authenticated caller and the tenant they belong to,
+// which the API, Repository and Execution layers read back from the request context.
+//
+//encore:middleware target=all
+func (s *Service) ScopeMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok {
+		return middleware.Response{Err: &errs.Error{Code: errs.Unauthenticated, Message: "authentication required"}}
+	}
+
+	ctx := tenant.NewContext(req.Context(), caller.TenantID)
+	return next(req.WithContext(authn.NewContext(ctx, caller)))
+}
+
+// Check that the caller of a request may act on a bill owned by the given user.
+// Users may only act on their own bills, while admins may act on any bill of their tenant.
+func authorize(ctx context.Context, ownerID string) error {
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !caller.CanAccess(ownerID) {
+		return fmt.Errorf("user %s is not permitted to access bills of user %s", caller.UserID, ownerID)
+	}
+	return nil
+}
+
+// Scope a listing of bills to the user the caller may list them for. Users may only list their own bills,
+// and list them when they name no user, while admins list the bills of every user unless they name one.
+func scopeToCaller(ctx context.Context, userID *uuid.UUID) (*uuid.UUID, error) {
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == nil && !caller.IsAdmin() {
+		callerID, err := uuid.Parse(caller.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user %s cannot own bills: %v", caller.UserID, err)
+		}
+		return &callerID, nil
+	}
+	if userID != nil {
+		if err := authorize(ctx, userID.String()); err != nil {
+			return nil, err
+		}
+	}
+	return userID, nil
+}
+
+// Check that the caller is an admin, returning an API error with the given message otherwise.
+func requireAdmin(ctx context.Context, msg string) error {
+	caller, err := authn.FromContext(ctx)
+	if err != nil {
+		return newError(errs.Unauthenticated, ErrorDetails{}, "Could not identify caller", err)
+	}
+	if !caller.IsAdmin() {
+		return newError(errs.PermissionDenied, ErrorDetails{}, msg, nil)
+	}
+	return nil
+}
+
+// Resolve the currency a report is normalized to: the requested one, or the reporting currency of the tenant
+// the request is scoped to.
+func reportCurrency(ctx context.Context, requested string) (string, error) {
+	if requested != "" {
+		if _, err := domain.IsValidCurrency(requested); err != nil {
+			return "", newError(errs.InvalidArgument, ErrorDetails{Field: "currency"}, "Invalid request parameters", err)
+		}
+		return requested, nil
+	}
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return "", newError(errs.Internal, ErrorDetails{}, "Could not resolve tenant", err)
+	}
+	currency, err := tenant.ReportingCurrency(tenantID)
+	if err != nil {
+		return "", newError(errs.Internal, ErrorDetails{}, "Could not resolve reporting currency", err)
+	}
+	return currency, nil
+}
+
+// Resolve the close approval policy of the tenant the request is scoped to.
+func approvalPolicy(ctx context.Context) (*workflows.ApprovalPolicy, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := tenant.Lookup(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workflows.ApprovalPolicy{
+		Limit:   domain.Money{Amount: domain.MinorUnit(cfg.ApprovalLimit), Currency: cfg.ApprovalCurrency},
+		Timeout: conf.CLOSE_APPROVAL_TIMEOUT,
+	}, nil
+}
+
+// Resolve the credit limit of a new bill, preferring the customer's own limit over the tenant's.
+// Returns nil when neither caps the bill.
+func creditLimit(cfg conf.TenantConfig, customerLimit *domain.Money) *domain.CreditLimit {
+	limit := domain.Money{Amount: domain.MinorUnit(cfg.CreditLimit), Currency: cfg.CreditCurrency}
+	if customerLimit != nil {
+		limit = *customerLimit
+	}
+	if limit.Amount <= 0 {
+		return nil
+	}
+
+	return &domain.CreditLimit{Limit: limit, WarnAt: cfg.CreditWarnAt}
+}
+
+// Resolve the payment terms of a new bill from its tenant's configuration.
+// Returns nil when the tenant's bills are not due, and so never dunned.
+func paymentTerms(cfg conf.TenantConfig) *domain.PaymentTerms {
+	if cfg.PaymentTerms <= 0 {
+		return nil
+	}
+
+	return &domain.PaymentTerms{
+		DueIn:   cfg.PaymentTerms,
+		Dunning: conf.DUNNING_SCHEDULE,
+	}
+}
+
+// Report the prepaid credit applied to a closed bill, if any, as an adjustment of the bill.
+func creditApplied(bill *domain.Bill) *domain.Money {
+	if bill.CreditApplied.Amount <= 0 {
+		return nil
+	}
+	return &bill.CreditApplied
+}
+
+// Look up an open bill the caller may modify and check that its workflow still accepts changes.
+// Bills that exist but are no longer open are reported apart from bills that do not exist at all.
+func (s *Service) getOpenBill(ctx context.Context, id string) (*domain.Bill, error) {
+	details := ErrorDetails{BillID: id}
+
+	bill, err := s.Repository.GetOpenBillFromDB(ctx, id)
+	if err != nil {
+		if !errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.Internal, details, "Could not look up bill", err)
+		}
+
+		// Only reveal that the bill was closed to callers who may see it
+		closedBill, err := s.Repository.GetClosedBillFromDB(ctx, id)
+		if err == nil && authorize(ctx, closedBill.UserID.String()) == nil {
+			details.Status = closedBill.Status
+			if closedBill.Status == domain.BillCancelled {
+				return nil, newError(errs.FailedPrecondition, details, "Bill is cancelled", nil)
+			}
+			return nil, newError(errs.FailedPrecondition, details, "Bill is already closed", nil)
+		}
+		if err != nil && !errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.Internal, details, "Could not look up bill", err)
+		}
+		return nil, newError(errs.NotFound, details, "Bill not found", nil)
+	}
+
+	if err := authorize(ctx, bill.UserID.String()); err != nil {
+		return nil, newError(errs.PermissionDenied, details, "Access denied", err)
+	}
+
+	if err := s.Execution.IsWorkflowRunning(ctx, id); err != nil {
+		if errors.Is(err, billerr.ErrBillClosing) {
+			details.Status = domain.BillClosing
+			return nil, newError(errs.FailedPrecondition, details, "Bill is being closed", nil)
+		}
+		return nil, newError(errs.Internal, details, "Unexpected error fetching bill", err)
+	}
+
+	return bill, nil
+}
+
+// Check a conditional change of an open bill against the revision its If-Match header names.
+// Returns the revision the change was made against, so the workflow checks it again when it applies the change,
+// or zero for unconditional changes.
+func (s *Service) checkIfMatch(ctx context.Context, id string, ifMatch string) (int, error) {
+	details := ErrorDetails{BillID: id, Field: "If-Match"}
+
+	revisions, err := parseIfMatch(ifMatch)
+	if err != nil {
+		return 0, newError(errs.InvalidArgument, details, "Invalid If-Match header", err)
+	}
+	if len(revisions) == 0 {
+		return 0, nil
+	}
+
+	var bill domain.Bill
+	if err := s.Execution.GetBillQuery(ctx, id, &bill); err != nil {
+		return 0, newError(errs.Internal, ErrorDetails{BillID: id}, "Unable to query bill from Temporal", err)
+	}
+	if !slices.Contains(revisions, bill.Revision) {
+		details.Status = bill.Status
+		return 0, newError(errs.FailedPrecondition, details, "Bill was changed since it was read", nil)
+	}
+	return bill.Revision, nil
+}
+
+// Look up a bill the caller may see along with its items and history.
+// The history of open bills is kept by their workflow, that of closed bills is read from the database.
+func (s *Service) getBillHistory(ctx context.Context, id string) (*domain.Bill, error) {
+	details := ErrorDetails{BillID: id}
+
+	openBill, err := s.Repository.GetOpenBillFromDB(ctx, id)
+	if err == nil {
+		if err := authorize(ctx, openBill.UserID.String()); err != nil {
+			return nil, newError(errs.PermissionDenied, details, "Access denied", err)
+		}
+
+		var bill domain.Bill
+		if err := s.Execution.GetBillQuery(ctx, id, &bill); err != nil {
+			return nil, newError(errs.Internal, details, "Unable to query bill from Temporal", err)
+		}
+		return &bill, nil
+	}
+	if !errors.Is(err, billerr.ErrNotFound) {
+		return nil, newError(errs.Internal, details, "Could not look up bill", err)
+	}
+
+	bill, err := s.Repository.GetClosedBillFromDB(ctx, id)
+	if err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, newError(errs.NotFound, details, "Bill not found", nil)
+		}
+		return nil, newError(errs.Internal, details, "Could not look up bill", err)
+	}
+
+	if err := authorize(ctx, bill.UserID.String()); err != nil {
+		return nil, newError(errs.PermissionDenied, details, "Access denied", err)
+	}
+
+	bill.Items, err = s.Repository.GetClosedBillItemsFromDB(ctx, id)
+	if err != nil {
+		return nil, newError(errs.Internal, details, "Bill items not found", err)
+	}
+
+	bill.Events, err = s.Repository.GetBillEventsFromDB(ctx, id)
+	if err != nil {
+		return nil, newError(errs.Internal, details, "Could not look up bill events", err)
+	}
+
+	return bill, nil
+}
+
 func (s *Service) Shutdown(force context.Context) {
 	s.Execution.Close()
 }
@@ -0,0 +1,64 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/vvvakho/feezy/billing/conf"
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx scoped to the given tenant.
+func NewContext(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, tenantID)
+}
+
+// FromContext returns the tenant the context is scoped to.
+// Returns an error if the context carries no tenant, so queries are never left unscoped.
+func FromContext(ctx context.Context) (string, error) {
+	tenantID, ok := ctx.Value(ctxKey{}).(string)
+	if !ok || tenantID == "" {
+		return "", fmt.Errorf("no tenant in request context")
+	}
+	return tenantID, nil
+}
+
+// Lookup returns the configuration of a registered tenant.
+func Lookup(tenantID string) (conf.TenantConfig, error) {
+	cfg, ok := conf.TENANTS[tenantID]
+	if !ok {
+		return conf.TenantConfig{}, fmt.Errorf("unknown tenant: %s", tenantID)
+	}
+	return cfg, nil
+}
+
+// ValidateCurrency checks that the tenant allows bills in the given currency.
+func ValidateCurrency(tenantID string, currency string) error {
+	cfg, err := Lookup(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(cfg.Currencies, currency) {
+		return fmt.Errorf("currency %s is not enabled for tenant %s", currency, tenantID)
+	}
+	return nil
+}
+
+// ReportingCurrency returns the currency the tenant's revenue reports are normalized to.
+func ReportingCurrency(tenantID string) (string, error) {
+	cfg, err := Lookup(tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.ReportingCurrency != "" {
+		return cfg.ReportingCurrency, nil
+	}
+	if len(cfg.Currencies) == 0 {
+		return "", fmt.Errorf("tenant %s has no currencies to report in", tenantID)
+	}
+	return cfg.Currencies[0], nil
+}
@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vvvakho/feezy/billing/conf"
+)
+
+func TestFromContext(t *testing.T) {
+	_, err := FromContext(context.Background())
+	assert.Error(t, err)
+
+	tenantID, err := FromContext(NewContext(context.Background(), "acme"))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", tenantID)
+}
+
+func TestValidateCurrency(t *testing.T) {
+	conf.TENANTS["acme"] = conf.TenantConfig{Currencies: []string{"USD"}}
+	defer delete(conf.TENANTS, "acme")
+
+	assert.NoError(t, ValidateCurrency("acme", "USD"))
+	assert.Error(t, ValidateCurrency("acme", "GEL"))
+	assert.Error(t, ValidateCurrency("globex", "USD"))
+}
+
+func TestReportingCurrency(t *testing.T) {
+	conf.TENANTS["acme"] = conf.TenantConfig{Currencies: []string{"GEL", "USD"}}
+	conf.TENANTS["globex"] = conf.TenantConfig{Currencies: []string{"GEL", "USD"}, ReportingCurrency: "USD"}
+	defer delete(conf.TENANTS, "acme")
+	defer delete(conf.TENANTS, "globex")
+
+	currency, err := ReportingCurrency("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "GEL", currency)
+
+	currency, err = ReportingCurrency("globex")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", currency)
+
+	_, err = ReportingCurrency("initech")
+	assert.Error(t, err)
+}
@@ -1,19 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/workflows"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 )
 
-// Initialize a Temporal worker to handle bill creation,
+// Initialize Temporal workers to handle bill creation,
 // connected to PostgreSQL independently for horizontal scalability.
-// Register and listen for tasks associated with "create-bill-queue".
+// Register and listen for tasks on the "create-bill-queue" of every tenant, and on the
+// unprefixed "create-bill-queue" of bills opened before tenants were introduced.
 func main() {
 	// Connect to Temporal
 	c, err := client.Dial(client.Options{})
@@ -21,9 +28,6 @@ func main() {
 		log.Fatalf("Failed to connect to Temporal: %v", err)
 	}
 
-	// Create a worker pool for delegating tasks
-	w := worker.New(c, "create-bill-queue", worker.Options{})
-
 	// Establishing a separate DB connection lets us decouple from Encore
 	// in case we want to introduce independent horizontal worker scaling
 	postgres, err := sql.Open("postgres", conf.WORKER_DB_CONN)
@@ -36,19 +40,78 @@ func main() {
 		DB: postgres,
 	}
 
-	activities := &workflows.Activities{
-		Repository: &db,
-	}
+	// Create a worker pool per tenant, so tenants never share a task queue
+	for tenantID, cfg := range conf.TENANTS {
+		w := worker.New(c, workflows.TaskQueue(tenantID), worker.Options{})
 
-	// Register Workflow and Activities
-	w.RegisterWorkflow(workflows.BillWorkflow)
-	w.RegisterActivity(activities)
+		// Dunning reaches the notification and payments services as an admin of the tenant
+		api := &workflows.APIClient{
+			BaseURL: conf.API_BASE_URL,
+			APIKey:  os.Getenv("FEEZY_API_KEY_" + strings.ToUpper(tenantID)),
+			HTTP:    &http.Client{Timeout: 30 * time.Second},
+		}
 
-	// Start worker
-	err = w.Run(worker.InterruptCh())
-	if err != nil {
-		log.Fatalf("Unable to start Worker: %v", err)
+		activities := &workflows.Activities{
+			Repository: &db,
+			Notifier:   api,
+			Gateway:    api,
+		}
+
+		// Register Workflow and Activities
+		w.RegisterWorkflow(workflows.BillWorkflow)
+		w.RegisterWorkflow(workflows.AmendBillWorkflow)
+		w.RegisterWorkflow(workflows.DunningWorkflow)
+		w.RegisterWorkflow(workflows.LateFeeWorkflow)
+		w.RegisterWorkflow(workflows.AnalyticsWorkflow)
+		w.RegisterActivity(activities)
+
+		// Start worker
+		if err := w.Start(); err != nil {
+			log.Fatalf("Unable to start Worker for tenant %s: %v", tenantID, err)
+		}
+		defer w.Stop()
+
+		// Bills of the default tenant opened before tenants were introduced run on the unprefixed task queue,
+		// which is served until all of them have completed, along with the dunning they start
+		if tenantID == conf.DEFAULT_TENANT {
+			legacy := worker.New(c, workflows.BillTaskQueue, worker.Options{})
+			legacy.RegisterWorkflow(workflows.BillWorkflow)
+			legacy.RegisterWorkflow(workflows.DunningWorkflow)
+			legacy.RegisterActivity(activities)
+
+			if err := legacy.Start(); err != nil {
+				log.Fatalf("Unable to start Worker for legacy bills: %v", err)
+			}
+			defer legacy.Stop()
+		}
+
+		// Schedule late fee accrual for tenants with a late fee policy, once across all workers
+		if cfg.LateFee != nil {
+			_, err := c.ExecuteWorkflow(context.Background(), client.StartWorkflowOptions{
+				ID:                       workflows.LateFeeWorkflowID(tenantID),
+				TaskQueue:                workflows.TaskQueue(tenantID),
+				CronSchedule:             conf.LATE_FEE_SCHEDULE,
+				WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
+			}, workflows.LateFeeWorkflow, tenantID)
+			if err != nil {
+				log.Fatalf("Unable to schedule late fees for tenant %s: %v", tenantID, err)
+			}
+		}
+
+		// Schedule the refresh of the rollups behind the analytics reports, once across all workers
+		_, err := c.ExecuteWorkflow(context.Background(), client.StartWorkflowOptions{
+			ID:                       workflows.AnalyticsWorkflowID(tenantID),
+			TaskQueue:                workflows.TaskQueue(tenantID),
+			CronSchedule:             conf.ANALYTICS_SCHEDULE,
+			WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
+		}, workflows.AnalyticsWorkflow, tenantID)
+		if err != nil {
+			log.Fatalf("Unable to schedule analytics for tenant %s: %v", tenantID, err)
+		}
 	}
+
+	// Serve until interrupted
+	<-worker.InterruptCh()
 }
 
 // Dependency injection -- primarily for mock testing
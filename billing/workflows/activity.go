@@ -3,11 +3,14 @@ package workflows
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
@@ -15,6 +18,14 @@ import (
 
 var AddOpenBillToDB string = "AddOpenBillToDB"
 var AddClosedBillToDB string = "AddClosedBillToDB"
+var AddCancelledBillToDB string = "AddCancelledBillToDB"
+var AddAmendedBillToDB string = "AddAmendedBillToDB"
+var GetCollectionStatus string = "GetCollectionStatus"
+var UpdateCollectionStatus string = "UpdateCollectionStatus"
+var AccrueLateFees string = "AccrueLateFees"
+var RefreshAnalytics string = "RefreshAnalytics"
+var SendPaymentReminder string = "SendPaymentReminder"
+var ChargeBill string = "ChargeBill"
 
 // Default options across activities, adjust based on needs
 var ao = workflow.ActivityOptions{
@@ -28,11 +39,30 @@ var ao = workflow.ActivityOptions{
 
 type Activities struct {
 	Repository Repository
+	Notifier   Notifier
+	Gateway    PaymentGateway
 }
 
 type Repository interface {
 	AddOpenBillToDB(context.Context, *domain.Bill, *string) error
 	AddClosedBillToDB(context.Context, *domain.Bill, *string) error
+	AddCancelledBillToDB(context.Context, *domain.Bill, *string) error
+	AddAmendedBillToDB(context.Context, *domain.Bill, *domain.Amendment, *string) error
+	GetCollectionStatus(context.Context, *domain.Bill) (domain.CollectionStatus, error)
+	UpdateCollectionStatus(context.Context, *domain.Bill, domain.CollectionStatus) error
+	GetBalanceDue(context.Context, *domain.Bill) (domain.Money, error)
+	AccrueLateFees(context.Context, string, domain.LateFeePolicy, time.Time) (int, error)
+	RefreshAnalytics(context.Context, string, time.Time) (int, error)
+}
+
+// Notifier delivers payment reminders for unpaid bills to their customers, with the amount they owe.
+type Notifier interface {
+	SendPaymentReminder(context.Context, *domain.Bill, domain.Money) error
+}
+
+// PaymentGateway charges the customer of an unpaid bill for the amount they owe, reporting whether the charge succeeded.
+type PaymentGateway interface {
+	Charge(context.Context, *domain.Bill, domain.Money, string) (bool, error)
 }
 
 func (a *Activities) AddOpenBillToDB(ctx context.Context, bill *domain.Bill, requestID *string) error {
@@ -43,6 +73,60 @@ func (a *Activities) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, r
 	return a.Repository.AddClosedBillToDB(ctx, bill, requestID)
 }
 
+func (a *Activities) AddCancelledBillToDB(ctx context.Context, bill *domain.Bill, requestID *string) error {
+	return a.Repository.AddCancelledBillToDB(ctx, bill, requestID)
+}
+
+func (a *Activities) AddAmendedBillToDB(ctx context.Context, bill *domain.Bill, amendment *domain.Amendment, requestID *string) error {
+	return a.Repository.AddAmendedBillToDB(ctx, bill, amendment, requestID)
+}
+
+func (a *Activities) GetCollectionStatus(ctx context.Context, bill *domain.Bill) (domain.CollectionStatus, error) {
+	return a.Repository.GetCollectionStatus(ctx, bill)
+}
+
+func (a *Activities) UpdateCollectionStatus(ctx context.Context, bill *domain.Bill, status domain.CollectionStatus) error {
+	return a.Repository.UpdateCollectionStatus(ctx, bill, status)
+}
+
+// Accrue the late fees owed on a tenant's overdue bills as of the given time, under the tenant's late fee policy.
+// Returns the number of bills that were charged a fee.
+func (a *Activities) AccrueLateFees(ctx context.Context, tenantID string, at time.Time) (int, error) {
+	cfg, ok := conf.TENANTS[tenantID]
+	if !ok {
+		return 0, billerr.New(billerr.ErrInvalidRequest, fmt.Sprintf("unknown tenant %s", tenantID), nil)
+	}
+	if cfg.LateFee == nil {
+		return 0, nil
+	}
+	return a.Repository.AccrueLateFees(ctx, tenantID, *cfg.LateFee, at)
+}
+
+// Rebuild the analytics rollups of a tenant, recording them as refreshed at the given time.
+// Returns the number of days with revenue.
+func (a *Activities) RefreshAnalytics(ctx context.Context, tenantID string, at time.Time) (int, error) {
+	if _, ok := conf.TENANTS[tenantID]; !ok {
+		return 0, billerr.New(billerr.ErrInvalidRequest, fmt.Sprintf("unknown tenant %s", tenantID), nil)
+	}
+	return a.Repository.RefreshAnalytics(ctx, tenantID, at)
+}
+
+func (a *Activities) SendPaymentReminder(ctx context.Context, bill *domain.Bill) error {
+	amountDue, err := a.Repository.GetBalanceDue(ctx, bill)
+	if err != nil {
+		return err
+	}
+	return a.Notifier.SendPaymentReminder(ctx, bill, amountDue)
+}
+
+func (a *Activities) ChargeBill(ctx context.Context, bill *domain.Bill, requestID string) (bool, error) {
+	amountDue, err := a.Repository.GetBalanceDue(ctx, bill)
+	if err != nil {
+		return false, err
+	}
+	return a.Gateway.Charge(ctx, bill, amountDue, requestID)
+}
+
 type Repo struct {
 	DB *sql.DB
 }
@@ -50,15 +134,27 @@ type Repo struct {
 // Add a bill to the open bills table, ensuring idempotent updates based on requestID.
 // Ensures atomicity through transactions and avoids duplicate processing via requestID tracking.
 func (r *Repo) AddOpenBillToDB(ctx context.Context, bill *domain.Bill, requestID *string) error {
+	// Every bill must be scoped to a tenant
+	if bill.TenantID == "" {
+		return billerr.New(billerr.ErrInvalidRequest, "bill has no tenant", nil)
+	}
+
+	externalRef, metadata, err := encodeBillMetadata(bill)
+	if err != nil {
+		return err
+	}
+
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return fmt.Errorf("Error starting transaction: %v", err)
 	}
 
 	_, err = tx.Exec(`
-		INSERT INTO open_bills (id, user_id, status, currency, created_at, updated_at, request_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (id)
+		INSERT INTO open_bills (
+			id, user_id, status, currency, created_at, updated_at, request_id, single_open, tenant_id, external_ref, metadata
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (tenant_id, id)
 		DO UPDATE SET 
 			status = CASE WHEN open_bills.status <> EXCLUDED.status THEN EXCLUDED.status ELSE open_bills.status END,
 			updated_at = now()
@@ -71,15 +167,16 @@ func (r *Repo) AddOpenBillToDB(ctx context.Context, bill *domain.Bill, requestID
 		bill.CreatedAt,
 		time.Now(),
 		requestID,
+		conf.SINGLE_OPEN_BILL_PER_CURRENCY,
+		bill.TenantID,
+		externalRef,
+		metadata,
 	)
 
 	if err != nil {
 		tx.Rollback()
-		if isUserInputError(err) {
-			// Mark as a non-retryable error in Temporal
-			return temporal.NewNonRetryableApplicationError("Invalid input error", "UserInputError", err)
-		}
-		return fmt.Errorf("Error inserting/updating db: %v", err)
+		// Classify the failure so Temporal only retries transient errors
+		return billerr.FromPostgres("Error inserting/updating db", err)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -93,9 +190,63 @@ func (r *Repo) AddOpenBillToDB(ctx context.Context, bill *domain.Bill, requestID
 // Move a bill from open to closed database table, ensuring idempotency via requestID.
 // Ensures atomicity through transactions and handles duplicate requests gracefully.
 func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, requestID *string) error {
+	return r.moveToClosedBills(ctx, bill, requestID, domain.BillClosed)
+}
+
+// Move a voided bill from open to closed database table with a cancelled status,
+// so it remains listed with its cancellation record but can never be paid.
+func (r *Repo) AddCancelledBillToDB(ctx context.Context, bill *domain.Bill, requestID *string) error {
+	if bill.Cancellation == nil {
+		return billerr.New(billerr.ErrInvalidRequest, "bill has no cancellation record", nil)
+	}
+	return r.moveToClosedBills(ctx, bill, requestID, domain.BillCancelled)
+}
+
+// Move a bill into the closed_bills table with its final status, along with its items.
+func (r *Repo) moveToClosedBills(ctx context.Context, bill *domain.Bill, requestID *string, status domain.Status) error {
 	// Validate requestID before initiating transaction
 	if requestID == nil {
-		return temporal.NewNonRetryableApplicationError("requestID cannot be nil", "InvalidRequestError", nil)
+		return billerr.New(billerr.ErrInvalidRequest, "requestID cannot be nil", nil)
+	}
+
+	// Every bill must be scoped to a tenant
+	if bill.TenantID == "" {
+		return billerr.New(billerr.ErrInvalidRequest, "bill has no tenant", nil)
+	}
+
+	// Encode the billing profile snapshot, bills without a profile store NULL
+	var billingProfile []byte
+	if bill.BillingProfile != nil {
+		encoded, err := json.Marshal(bill.BillingProfile)
+		if err != nil {
+			return billerr.New(billerr.ErrInvalidRequest, "Invalid billing profile", err)
+		}
+		billingProfile = encoded
+	}
+
+	// Only bills closed with payment terms are due and dunned, and only those or bills paid on close
+	// carry a collection status, other bills store NULL
+	var dueAt sql.NullTime
+	var collectionStatus sql.NullString
+	if status == domain.BillClosed && bill.DueAt != nil {
+		dueAt = sql.NullTime{Time: *bill.DueAt, Valid: true}
+	}
+	if status == domain.BillClosed && bill.Collection != "" {
+		collectionStatus = sql.NullString{String: string(bill.Collection), Valid: true}
+	}
+
+	// Only cancelled bills carry a cancellation record, other bills store NULL
+	var cancelReason, cancelledBy sql.NullString
+	var cancelledAt sql.NullTime
+	if bill.Cancellation != nil {
+		cancelReason = sql.NullString{String: bill.Cancellation.Reason, Valid: true}
+		cancelledBy = sql.NullString{String: bill.Cancellation.ActorID, Valid: true}
+		cancelledAt = sql.NullTime{Time: bill.Cancellation.CancelledAt, Valid: true}
+	}
+
+	externalRef, metadata, err := encodeBillMetadata(bill)
+	if err != nil {
+		return err
 	}
 
 	tx, err := r.DB.BeginTx(ctx, nil)
@@ -106,9 +257,13 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 
 	// Attempt to move the bill from Temporal Workflow into the closed_bills table in database
 	res, err := tx.ExecContext(ctx, `
-		INSERT INTO closed_bills (id, user_id, status, total_amount, currency, created_at, updated_at, closed_at, request_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (id) 
+		INSERT INTO closed_bills (
+			id, user_id, status, total_amount, currency, created_at, updated_at, closed_at, request_id, billing_profile,
+			tenant_id, tax_rate, tax_amount, cancel_reason, cancelled_by, cancelled_at, due_at, collection_status,
+			external_ref, metadata
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		ON CONFLICT (tenant_id, id) 
 		DO UPDATE SET 
 			status = EXCLUDED.status,
 			total_amount = EXCLUDED.total_amount,
@@ -117,21 +272,29 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 	`,
 		bill.ID,
 		bill.UserID,
-		domain.BillClosed,
+		status,
 		bill.Total.Amount,
 		bill.Total.Currency,
 		bill.CreatedAt,
 		time.Now(),
 		time.Now(),
 		*requestID,
+		billingProfile,
+		bill.TenantID,
+		bill.TaxRate,
+		bill.Tax.Amount,
+		cancelReason,
+		cancelledBy,
+		cancelledAt,
+		dueAt,
+		collectionStatus,
+		externalRef,
+		metadata,
 	)
 
 	if err != nil {
-		if isUserInputError(err) {
-			// Mark this error as non-retryable for Temporal
-			return temporal.NewNonRetryableApplicationError("Invalid input error", "UserInputError", err)
-		}
-		return fmt.Errorf("Error inserting/updating db: %v", err)
+		// Classify the failure so Temporal only retries transient errors
+		return billerr.FromPostgres("Error inserting/updating db", err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
@@ -142,7 +305,7 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 	// If no rows affected, check if the existing request_id matches
 	if rowsAffected == 0 {
 		var existingRequestID string
-		err := tx.QueryRowContext(ctx, "SELECT request_id FROM closed_bills WHERE id = $1", bill.ID).Scan(&existingRequestID)
+		err := tx.QueryRowContext(ctx, "SELECT request_id FROM closed_bills WHERE id = $1 AND tenant_id = $2", bill.ID, bill.TenantID).Scan(&existingRequestID)
 		if err != nil {
 			return fmt.Errorf("error checking existing request_id: %v", err)
 		}
@@ -152,25 +315,198 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 			return tx.Commit() // Commit to signal successful idempotent operation
 		} else {
 			// Bill closed with a different requestID: non-retryable error
-			return temporal.NewNonRetryableApplicationError(
-				"bill already closed with a different request",
-				"DuplicateRequestError",
-				nil,
-			)
+			return billerr.New(billerr.ErrDuplicateRequest, "bill already closed with a different request", nil)
 		}
 	}
 
 	// Attempt to move the bill items from Temporal Workflow into the closed_bills_items table in database
+	if err := insertClosedBillItems(ctx, tx, bill); err != nil {
+		return err
+	}
+
+	// Persist the bill's history, which is no longer kept by its workflow
+	if err := insertBillEvents(ctx, tx, bill); err != nil {
+		return err
+	}
+
+	// Journal the amount due, then either the payment taken along with the close,
+	// or draw down the customer's prepaid credit before the bill becomes payable
+	if status == domain.BillClosed {
+		if err := InsertJournalEntry(ctx, tx, domain.BillClosedEntry(bill)); err != nil {
+			return err
+		}
+		if bill.Collection == domain.CollectionPaid {
+			entry, err := domain.SettlementEntry(bill, domain.CollectionPaid, bill.AmountDue(), bill.ClosedAt)
+			if err != nil {
+				return billerr.New(billerr.ErrInvalidRequest, "Error journaling payment", err)
+			}
+			if err := InsertJournalEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		} else if err := applyWalletCredit(ctx, tx, bill); err != nil {
+			return err
+		}
+	}
+
+	// Attempt to remove bill from Open Bills Database
+	_, err = tx.ExecContext(ctx, `DELETE FROM open_bills WHERE id = $1 AND tenant_id = $2`, bill.ID, bill.TenantID)
+	if err != nil {
+		return fmt.Errorf("Failed to remove from open_bills: %v", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Statements executed by the transactions that journal entries are recorded in.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// InsertJournalEntry records a journal entry with its postings within the given transaction,
+// for the money movements of both the workers and the billing service.
+// Entries without postings, such as those of bills closed at zero, are skipped,
+// as are entries whose movement was already journaled under the same source key.
+func InsertJournalEntry(ctx context.Context, tx Execer, entry *domain.JournalEntry) error {
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+	if err := entry.Validate(); err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Invalid journal entry", err)
+	}
+
+	occurredAt := entry.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO journal_entries (id, tenant_id, source, source_key, bill_id, description, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id, source_key) DO NOTHING;
+	`, entry.ID, entry.TenantID, entry.Source, entry.SourceKey, entry.BillID, entry.Description, occurredAt)
+	if err != nil {
+		return billerr.FromPostgres("Error inserting journal_entries", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	for _, posting := range entry.Postings {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO journal_postings (entry_id, tenant_id, account_code, debit, credit, currency)
+			VALUES ($1, $2, $3, $4, $5, $6);
+		`, entry.ID, entry.TenantID, posting.AccountCode, posting.Debit, posting.Credit, posting.Currency)
+		if err != nil {
+			return billerr.FromPostgres("Error inserting journal_postings", err)
+		}
+	}
+
+	return nil
+}
+
+// Apply the prepaid credit of a bill's customer in the bill currency to the bill, within the transaction closing it.
+// The wallet is locked while its credit is drawn down, and the consumption is recorded in its ledger
+// under a request ID derived from the bill, so credit is never applied to the same bill twice.
+func applyWalletCredit(ctx context.Context, tx *sql.Tx, bill *domain.Bill) error {
+	balance := domain.Money{Currency: bill.Total.Currency}
+	err := tx.QueryRowContext(ctx, `
+		SELECT balance::BIGINT FROM wallets
+		WHERE tenant_id = $1 AND user_id = $2 AND currency = $3
+		FOR UPDATE;
+	`, bill.TenantID, bill.UserID, bill.Total.Currency).Scan(&balance.Amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return billerr.FromPostgres("Error locking wallet", err)
+	}
+
+	applied, err := bill.ApplyCredit(balance)
+	if err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Error applying credit", err)
+	}
+	if applied.Amount <= 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE wallets SET balance = balance - $4, updated_at = now()
+		WHERE tenant_id = $1 AND user_id = $2 AND currency = $3;
+	`, bill.TenantID, bill.UserID, applied.Currency, applied.Amount)
+	if err != nil {
+		return billerr.FromPostgres("Error drawing down wallet", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO wallet_entries (id, tenant_id, user_id, type, amount, currency, bill_id, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`,
+		uuid.New(),
+		bill.TenantID,
+		bill.UserID,
+		domain.WalletConsumption,
+		applied.Amount,
+		applied.Currency,
+		bill.ID,
+		uuid.NewSHA1(bill.ID, []byte("wallet-credit")),
+		time.Now(),
+	)
+	if err != nil {
+		return billerr.FromPostgres("Error inserting wallet_entries", err)
+	}
+
+	if err := InsertJournalEntry(ctx, tx, domain.CreditAppliedEntry(bill, applied)); err != nil {
+		return err
+	}
+
+	// Show the credit as an adjustment of the closed bill, settling bills it fully covers
+	var collectionStatus sql.NullString
+	if bill.DueAt != nil {
+		collectionStatus = sql.NullString{String: string(bill.Collection), Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE closed_bills SET credit_applied = $3, collection_status = $4
+		WHERE id = $1 AND tenant_id = $2;
+	`, bill.ID, bill.TenantID, bill.CreditApplied.Amount, collectionStatus)
+	if err != nil {
+		return billerr.FromPostgres("Error applying credit to closed bill", err)
+	}
+
+	return nil
+}
+
+// Insert the items of a bill into the closed_bills_items table within the given transaction.
+func insertClosedBillItems(ctx context.Context, tx *sql.Tx, bill *domain.Bill) error {
 	for _, item := range bill.Items {
-		_, err = tx.ExecContext(ctx,
-			`INSERT INTO closed_bills_items (id, bill_id, item_id, description, quantity, unit_price, currency)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		var metadata []byte
+		if item.Metadata != nil {
+			encoded, err := json.Marshal(item.Metadata)
+			if err != nil {
+				return billerr.New(billerr.ErrInvalidRequest, "Invalid item metadata", err)
+			}
+			metadata = encoded
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO closed_bills_items (id, bill_id, item_id, description, quantity, unit_price, currency, tenant_id, metadata)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 			ON CONFLICT (id) 
 			DO UPDATE SET 
 				description = EXCLUDED.description,
 				quantity = EXCLUDED.quantity,
 				unit_price = EXCLUDED.unit_price,
-				currency = EXCLUDED.currency;`,
+				currency = EXCLUDED.currency,
+				metadata = EXCLUDED.metadata;`,
 			uuid.New(),
 			bill.ID,
 			item.ID,
@@ -178,16 +514,233 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 			item.Quantity,
 			item.PricePerUnit.Amount,
 			item.PricePerUnit.Currency,
+			bill.TenantID,
+			metadata,
 		)
 		if err != nil {
-			return fmt.Errorf("Error inserting/updating closed_bills_items: %v", err)
+			return billerr.FromPostgres("Error inserting/updating closed_bills_items", err)
 		}
 	}
 
-	// Attempt to remove bill from Open Bills Database
-	_, err = tx.ExecContext(ctx, `DELETE FROM open_bills WHERE id = $1`, bill.ID)
+	return nil
+}
+
+// Encode the external reference and metadata of a bill, bills without them store NULL.
+func encodeBillMetadata(bill *domain.Bill) (sql.NullString, []byte, error) {
+	externalRef := sql.NullString{String: bill.ExternalRef, Valid: bill.ExternalRef != ""}
+	if len(bill.Metadata) == 0 {
+		return externalRef, nil, nil
+	}
+
+	metadata, err := json.Marshal(bill.Metadata)
 	if err != nil {
-		return fmt.Errorf("Failed to remove from open_bills: %v", err)
+		return externalRef, nil, billerr.New(billerr.ErrInvalidRequest, "Invalid bill metadata", err)
+	}
+	return externalRef, metadata, nil
+}
+
+// Insert the events of a bill into the bill_events table within the given transaction.
+// Events already stored for the bill are left untouched.
+func insertBillEvents(ctx context.Context, tx *sql.Tx, bill *domain.Bill) error {
+	for _, event := range bill.Events {
+		var item []byte
+		if event.Item != nil {
+			encoded, err := json.Marshal(event.Item)
+			if err != nil {
+				return billerr.New(billerr.ErrInvalidRequest, "Invalid event item", err)
+			}
+			item = encoded
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO bill_events (bill_id, sequence, tenant_id, type, actor_id, request_id, item,
+				total_before, total_after, currency, threshold, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (tenant_id, bill_id, sequence) DO NOTHING;`,
+			bill.ID,
+			event.Sequence,
+			bill.TenantID,
+			event.Type,
+			event.ActorID,
+			event.RequestID,
+			item,
+			event.TotalBefore.Amount,
+			event.TotalAfter.Amount,
+			event.TotalAfter.Currency,
+			event.Threshold,
+			event.OccurredAt,
+		)
+		if err != nil {
+			return billerr.FromPostgres("Error inserting bill_events", err)
+		}
+	}
+
+	return nil
+}
+
+// Store the new version of an amended closed bill along with its amendment record,
+// ensuring idempotency via requestID and that every version is amended at most once.
+// The new version is collected in place of the original, which is marked as superseded.
+func (r *Repo) AddAmendedBillToDB(ctx context.Context, bill *domain.Bill, amendment *domain.Amendment, requestID *string) error {
+	// Validate requestID before initiating transaction
+	if requestID == nil {
+		return billerr.New(billerr.ErrInvalidRequest, "requestID cannot be nil", nil)
+	}
+
+	// Every bill must be scoped to a tenant
+	if bill.TenantID == "" || bill.AmendsBillID == nil {
+		return billerr.New(billerr.ErrInvalidRequest, "amended bill has no tenant or original version", nil)
+	}
+
+	changes, err := json.Marshal(amendment.Changes)
+	if err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Invalid item changes", err)
+	}
+
+	var billingProfile []byte
+	if bill.BillingProfile != nil {
+		encoded, err := json.Marshal(bill.BillingProfile)
+		if err != nil {
+			return billerr.New(billerr.ErrInvalidRequest, "Invalid billing profile", err)
+		}
+		billingProfile = encoded
+	}
+
+	externalRef, metadata, err := encodeBillMetadata(bill)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Check whether this request was already processed, or the original version was amended by another one
+	var existingRequestID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT request_id FROM closed_bills WHERE amends_bill_id = $1 AND tenant_id = $2
+	`, bill.AmendsBillID, bill.TenantID).Scan(&existingRequestID)
+	if err == nil {
+		if existingRequestID.String == *requestID {
+			// Idempotent request: already processed successfully
+			return tx.Commit()
+		}
+		return billerr.New(billerr.ErrBillAmended, "bill was already amended by a different request", nil)
+	}
+	if err != sql.ErrNoRows {
+		return billerr.FromPostgres("Error checking existing amendments", err)
+	}
+
+	// Lock the original version, whose due date, collection status and prepaid credit move to the new one
+	original := &domain.Bill{ID: *bill.AmendsBillID, TenantID: bill.TenantID}
+	var dueAt sql.NullTime
+	var collectionStatus sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT total_amount::BIGINT, tax_amount::BIGINT, currency, due_at, collection_status, credit_applied::BIGINT
+		FROM closed_bills WHERE id = $1 AND tenant_id = $2
+		FOR UPDATE;
+	`, original.ID, original.TenantID).Scan(
+		&original.Total.Amount, &original.Tax.Amount, &original.Total.Currency, &dueAt, &collectionStatus, &original.CreditApplied.Amount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return billerr.New(billerr.ErrNotFound, "original bill not found", err)
+		}
+		return billerr.FromPostgres("Error querying original bill", err)
+	}
+	original.Tax.Currency = original.Total.Currency
+	original.CreditApplied.Currency = original.Total.Currency
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO closed_bills (
+			id, user_id, status, total_amount, currency, created_at, updated_at, closed_at, request_id, billing_profile,
+			tenant_id, tax_rate, tax_amount, version, amends_bill_id, external_ref, metadata,
+			due_at, collection_status, credit_applied
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20);
+	`,
+		bill.ID,
+		bill.UserID,
+		domain.BillClosed,
+		bill.Total.Amount,
+		bill.Total.Currency,
+		bill.CreatedAt,
+		time.Now(),
+		bill.ClosedAt,
+		*requestID,
+		billingProfile,
+		bill.TenantID,
+		bill.TaxRate,
+		bill.Tax.Amount,
+		bill.Version,
+		bill.AmendsBillID,
+		externalRef,
+		metadata,
+		dueAt,
+		collectionStatus,
+		original.CreditApplied.Amount,
+	)
+	if err != nil {
+		// Classify the failure so Temporal only retries transient errors
+		return billerr.FromPostgres("Error inserting amended bill", err)
+	}
+
+	// Collect the new version in place of the original, together with the late fees charged on the original,
+	// so that dunning and late fees of the original stop
+	if collectionStatus.Valid {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE closed_bills SET collection_status = $3, updated_at = now() WHERE id = $1 AND tenant_id = $2;
+		`, original.ID, original.TenantID, domain.CollectionSuperseded)
+		if err != nil {
+			return billerr.FromPostgres("Error superseding original bill", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE bill_late_fees SET bill_id = $3 WHERE bill_id = $1 AND tenant_id = $2;
+		`, original.ID, original.TenantID, bill.ID)
+		if err != nil {
+			return billerr.FromPostgres("Error moving bill_late_fees", err)
+		}
+	}
+
+	if err := insertClosedBillItems(ctx, tx, bill); err != nil {
+		return err
+	}
+
+	if err := insertBillEvents(ctx, tx, bill); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bill_amendments (
+			id, tenant_id, original_bill_id, amended_bill_id, version, changes,
+			adjustment_type, adjustment_amount, currency, reason, actor_id, request_id, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);
+	`,
+		amendment.ID,
+		amendment.TenantID,
+		amendment.OriginalBillID,
+		amendment.AmendedBillID,
+		amendment.Version,
+		changes,
+		amendment.Adjustment.Type,
+		amendment.Adjustment.Amount.Amount,
+		amendment.Adjustment.Amount.Currency,
+		amendment.Reason,
+		amendment.ActorID,
+		*requestID,
+		amendment.CreatedAt,
+	)
+	if err != nil {
+		return billerr.FromPostgres("Error inserting bill amendment", err)
+	}
+
+	// Journal the credit or debit note against the amounts of the original version
+	if err := InsertJournalEntry(ctx, tx, domain.AmendmentEntry(original, bill, amendment)); err != nil {
+		return err
 	}
 
 	// Commit the transaction
@@ -198,13 +751,423 @@ func (r *Repo) AddClosedBillToDB(ctx context.Context, bill *domain.Bill, request
 	return nil
 }
 
-// Check whether an error is due to user input.
-func isUserInputError(err error) bool {
-	if err == nil {
-		return false
+// Look up whether a closed bill was paid since it fell due.
+func (r *Repo) GetCollectionStatus(ctx context.Context, bill *domain.Bill) (domain.CollectionStatus, error) {
+	var status sql.NullString
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT collection_status FROM closed_bills WHERE id = $1 AND tenant_id = $2`,
+		bill.ID, bill.TenantID,
+	).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", billerr.New(billerr.ErrNotFound, "closed bill not found", err)
+		}
+		return "", billerr.FromPostgres("Error querying collection status", err)
+	}
+
+	if !status.Valid {
+		return "", billerr.New(billerr.ErrInvalidRequest, "bill has no due date", nil)
+	}
+	return domain.CollectionStatus(status.String), nil
+}
+
+// Settle the collection of an unpaid closed bill, as either paid or uncollectible,
+// and journal the balance still due on it as collected or written off.
+// Bills that were already settled keep their status.
+func (r *Repo) UpdateCollectionStatus(ctx context.Context, bill *domain.Bill, status domain.CollectionStatus) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE closed_bills SET collection_status = $3, updated_at = now()
+		WHERE id = $1 AND tenant_id = $2 AND collection_status = $4;
+	`, bill.ID, bill.TenantID, status, domain.CollectionUnpaid)
+	if err != nil {
+		return billerr.FromPostgres("Error updating collection status", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return tx.Commit()
+	}
+
+	balance, err := getBalanceDue(ctx, tx, bill)
+	if err != nil {
+		return err
+	}
+
+	entry, err := domain.SettlementEntry(bill, status, balance, time.Now())
+	if err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Error journaling settlement", err)
+	}
+	if err := InsertJournalEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Look up the amount a customer still owes on a closed bill: its total and tax,
+// less the prepaid credit applied to it, plus the late fees charged since it fell due.
+func (r *Repo) GetBalanceDue(ctx context.Context, bill *domain.Bill) (domain.Money, error) {
+	return getBalanceDue(ctx, r.DB, bill)
+}
+
+// Queries shared by a database and the transactions on it.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func getBalanceDue(ctx context.Context, q querier, bill *domain.Bill) (domain.Money, error) {
+	balance := domain.Money{Currency: bill.Total.Currency}
+	err := q.QueryRowContext(ctx, `
+		SELECT (total_amount + tax_amount - credit_applied
+			+ (SELECT COALESCE(SUM(amount), 0) FROM bill_late_fees WHERE bill_id = $1 AND tenant_id = $2))::BIGINT
+		FROM closed_bills
+		WHERE id = $1 AND tenant_id = $2;
+	`, bill.ID, bill.TenantID).Scan(&balance.Amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return balance, billerr.New(billerr.ErrNotFound, "closed bill not found", err)
+		}
+		return balance, billerr.FromPostgres("Error querying balance due", err)
+	}
+
+	return balance, nil
+}
+
+// Charge the late fees accrued on every overdue bill of a tenant as of the given time, beyond the fees
+// already charged on it, as fee line items of the bill. Each bill is locked while its fees are charged,
+// so overlapping or retried runs never charge a fee twice. Bills that fail to be charged are logged and skipped,
+// to be charged by the next run. Returns the number of bills that were charged.
+func (r *Repo) AccrueLateFees(ctx context.Context, tenantID string, policy domain.LateFeePolicy, at time.Time) (int, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT b.id FROM closed_bills b
+		WHERE b.tenant_id = $1 AND b.collection_status = $2 AND b.due_at < $3
+			AND NOT EXISTS (SELECT 1 FROM closed_bills n WHERE n.amends_bill_id = b.id AND n.tenant_id = b.tenant_id)
+		ORDER BY b.due_at;
+	`, tenantID, domain.CollectionUnpaid, at)
+	if err != nil {
+		return 0, billerr.FromPostgres("Error querying overdue bills", err)
+	}
+
+	var billIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, billerr.FromPostgres("Error scanning overdue bill", err)
+		}
+		billIDs = append(billIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, billerr.FromPostgres("Error iterating overdue bills", err)
+	}
+
+	charged := 0
+	for _, billID := range billIDs {
+		ok, err := r.accrueLateFee(ctx, tenantID, billID, policy, at)
+		if err != nil {
+			// A bill that cannot be charged must not hold up the other overdue bills of the tenant
+			log.Printf("Skipping late fee on bill %s of tenant %s: %v", billID, tenantID, err)
+			continue
+		}
+		if ok {
+			charged++
+		}
+	}
+
+	return charged, nil
+}
+
+// Charge the late fee accrued on a single overdue bill, reporting whether a fee was charged.
+func (r *Repo) accrueLateFee(ctx context.Context, tenantID string, billID uuid.UUID, policy domain.LateFeePolicy, at time.Time) (bool, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the bill, skipping it if it was settled in the meantime
+	var amountDue domain.Money
+	var dueAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT (total_amount + tax_amount - credit_applied)::BIGINT, currency, due_at FROM closed_bills
+		WHERE id = $1 AND tenant_id = $2 AND collection_status = $3
+		FOR UPDATE;
+	`, billID, tenantID, domain.CollectionUnpaid).Scan(&amountDue.Amount, &amountDue.Currency, &dueAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, billerr.FromPostgres("Error locking overdue bill", err)
+	}
+
+	charged := domain.Money{Currency: amountDue.Currency}
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0)::BIGINT FROM bill_late_fees WHERE bill_id = $1 AND tenant_id = $2`,
+		billID, tenantID,
+	).Scan(&charged.Amount)
+	if err != nil {
+		return false, billerr.FromPostgres("Error summing bill_late_fees", err)
+	}
+
+	fee, err := policy.NextLateFee(billID, amountDue, dueAt, charged, at)
+	if err != nil {
+		return false, billerr.New(billerr.ErrInvalidRequest, "Error calculating late fee", err)
+	}
+	if fee == nil {
+		return false, nil
 	}
-	errMsg := err.Error()
-	// Check whether the error from the database transaction contains certain flags
-	return strings.Contains(errMsg, "duplicate key value")
-	// We can define other User related errors below
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bill_late_fees (id, bill_id, tenant_id, fee_type, description, amount, currency, accrued_through, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`,
+		fee.ID,
+		fee.BillID,
+		tenantID,
+		fee.Type,
+		fee.Description,
+		fee.Amount.Amount,
+		fee.Amount.Currency,
+		fee.AccruedThrough,
+		fee.CreatedAt,
+	)
+	if err != nil {
+		return false, billerr.FromPostgres("Error inserting bill_late_fees", err)
+	}
+
+	if err := InsertJournalEntry(ctx, tx, domain.LateFeeEntry(tenantID, fee)); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("Error committing transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// ListJournalEntries reads the journal entries of a tenant that occurred within [from, to), with their postings,
+// in the order they occurred. Used by the ledger export command, which reads the ledger outside Encore.
+func (r *Repo) ListJournalEntries(ctx context.Context, tenantID string, from time.Time, to time.Time) ([]domain.JournalEntry, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT e.id, e.source, e.source_key, e.bill_id, e.description, e.occurred_at,
+			p.account_code, p.debit::BIGINT, p.credit::BIGINT, p.currency
+		FROM journal_entries e
+		JOIN journal_postings p ON p.entry_id = e.id
+		WHERE e.tenant_id = $1 AND e.occurred_at >= $2 AND e.occurred_at < $3
+		ORDER BY e.occurred_at, e.source_key, p.id;
+	`, tenantID, from, to)
+	if err != nil {
+		return nil, billerr.FromPostgres("Error querying journal entries", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	for rows.Next() {
+		var entry domain.JournalEntry
+		var posting domain.Posting
+		var billID uuid.NullUUID
+
+		err := rows.Scan(&entry.ID, &entry.Source, &entry.SourceKey, &billID, &entry.Description, &entry.OccurredAt,
+			&posting.AccountCode, &posting.Debit, &posting.Credit, &posting.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning journal entry: %v", err)
+		}
+
+		// Rows of an entry are adjacent, one per posting
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Postings = append(entries[n-1].Postings, posting)
+			continue
+		}
+		entry.TenantID = tenantID
+		if billID.Valid {
+			entry.BillID = &billID.UUID
+		}
+		entry.Postings = []domain.Posting{posting}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, billerr.FromPostgres("Error iterating journal entries", err)
+	}
+
+	return entries, nil
+}
+
+// ImportClosedBill writes a historical bill, closed before it was imported, directly to the closed bills
+// with its items, history and journal entries, keeping the times it was created and closed.
+// Imported bills draw down no prepaid credit and are never dunned. Bills that were already imported are
+// left untouched, so imports may be re-run. Returns whether the bill was written.
+func (r *Repo) ImportClosedBill(ctx context.Context, bill *domain.Bill, requestID string) (bool, error) {
+	if bill.TenantID == "" {
+		return false, billerr.New(billerr.ErrInvalidRequest, "bill has no tenant", nil)
+	}
+
+	// Only settled bills carry a collection status, so no payment is ever followed up
+	var collectionStatus sql.NullString
+	if bill.Collection != "" {
+		collectionStatus = sql.NullString{String: string(bill.Collection), Valid: true}
+	}
+
+	externalRef, metadata, err := encodeBillMetadata(bill)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO closed_bills (
+			id, user_id, status, total_amount, currency, created_at, updated_at, closed_at, request_id,
+			tenant_id, tax_rate, tax_amount, collection_status, external_ref, metadata
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (tenant_id, id) DO NOTHING;
+	`,
+		bill.ID,
+		bill.UserID,
+		domain.BillClosed,
+		bill.Total.Amount,
+		bill.Total.Currency,
+		bill.CreatedAt,
+		bill.ClosedAt,
+		requestID,
+		bill.TenantID,
+		bill.TaxRate,
+		bill.Tax.Amount,
+		collectionStatus,
+		externalRef,
+		metadata,
+	)
+	if err != nil {
+		return false, billerr.FromPostgres("Error inserting closed_bills", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error checking rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertClosedBillItems(ctx, tx, bill); err != nil {
+		return false, err
+	}
+	if err := insertBillEvents(ctx, tx, bill); err != nil {
+		return false, err
+	}
+
+	// Journal the bill as of its close, and its settlement if it was settled before the import
+	if err := InsertJournalEntry(ctx, tx, domain.BillClosedEntry(bill)); err != nil {
+		return false, err
+	}
+	if bill.Collection == domain.CollectionPaid || bill.Collection == domain.CollectionUncollectible {
+		settlement, err := domain.SettlementEntry(bill, bill.Collection, bill.AmountDue(), bill.ClosedAt)
+		if err != nil {
+			return false, billerr.New(billerr.ErrInvalidRequest, "Invalid collection status", err)
+		}
+		if err := InsertJournalEntry(ctx, tx, settlement); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("Error committing transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// Rebuild the analytics rollups of a tenant from its bills within a single transaction, so reports never read
+// a partial refresh. Revenue counts the latest version of every closed bill, on the day it was closed, while
+// cancelled bills and versions replaced by an amendment are left out. Returns the number of days with revenue.
+func (r *Repo) RefreshAnalytics(ctx context.Context, tenantID string, at time.Time) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"revenue_daily", "revenue_daily_categories", "bill_counts"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE tenant_id = $1`, tenantID); err != nil {
+			return 0, billerr.FromPostgres("Error clearing "+table, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO revenue_daily (tenant_id, day, currency, bills, subtotal, tax)
+		SELECT b.tenant_id, b.closed_at::DATE, b.currency, COUNT(*), SUM(b.total_amount)::BIGINT, SUM(b.tax_amount)::BIGINT
+		FROM closed_bills b
+		WHERE b.tenant_id = $1 AND b.status = $2
+			AND NOT EXISTS (SELECT 1 FROM closed_bills n WHERE n.amends_bill_id = b.id AND n.tenant_id = b.tenant_id)
+		GROUP BY 1, 2, 3;
+	`, tenantID, domain.BillClosed)
+	if err != nil {
+		return 0, billerr.FromPostgres("Error rolling up revenue", err)
+	}
+	days, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking rows affected: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO revenue_daily_categories (tenant_id, day, currency, category, lines, quantity, amount)
+		SELECT b.tenant_id, b.closed_at::DATE, i.currency, COALESCE(i.metadata->>'Category', ''),
+			COUNT(*), SUM(i.quantity)::BIGINT, SUM(i.quantity * i.unit_price)::BIGINT
+		FROM closed_bills_items i
+		JOIN closed_bills b ON b.id = i.bill_id AND b.tenant_id = i.tenant_id
+		WHERE b.tenant_id = $1 AND b.status = $2
+			AND NOT EXISTS (SELECT 1 FROM closed_bills n WHERE n.amends_bill_id = b.id AND n.tenant_id = b.tenant_id)
+		GROUP BY 1, 2, 3, 4;
+	`, tenantID, domain.BillClosed)
+	if err != nil {
+		return 0, billerr.FromPostgres("Error rolling up revenue by category", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bill_counts (tenant_id, status, currency, bills)
+		SELECT tenant_id, status, currency, COUNT(*)
+		FROM (
+			SELECT tenant_id, status, currency FROM open_bills WHERE tenant_id = $1
+			UNION ALL
+			SELECT b.tenant_id, b.status, b.currency FROM closed_bills b
+			WHERE b.tenant_id = $1 AND NOT EXISTS (SELECT 1 FROM closed_bills n WHERE n.amends_bill_id = b.id AND n.tenant_id = b.tenant_id)
+		) bills
+		GROUP BY 1, 2, 3;
+	`, tenantID)
+	if err != nil {
+		return 0, billerr.FromPostgres("Error counting bills", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO analytics_refreshes (tenant_id, refreshed_at) VALUES ($1, $2)
+		ON CONFLICT (tenant_id) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at;
+	`, tenantID, at)
+	if err != nil {
+		return 0, billerr.FromPostgres("Error recording analytics refresh", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Error committing transaction: %v", err)
+	}
+
+	return int(days), nil
 }
@@ -2,12 +2,14 @@ package workflows
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"encore.dev/et"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/vvvakho/feezy/billing/billerr"
 	"github.com/vvvakho/feezy/billing/service/domain"
 )
 
@@ -30,6 +32,7 @@ func TestAddOpenBillToDB(t *testing.T) {
 
 	bill := &domain.Bill{
 		ID:        billID,
+		TenantID:  "default",
 		UserID:    userID,
 		Total:     domain.Money{Currency: "USD"},
 		CreatedAt: time.Now(),
@@ -70,10 +73,13 @@ func TestAddClosedBillToDB(t *testing.T) {
 	requestID := uuid.New().String()
 
 	bill := &domain.Bill{
-		ID:        billID,
-		UserID:    userID,
-		Total:     domain.Money{Amount: 100, Currency: "USD"},
-		CreatedAt: time.Now(),
+		ID:          billID,
+		TenantID:    "default",
+		UserID:      userID,
+		Total:       domain.Money{Amount: 100, Currency: "USD"},
+		ExternalRef: "PO-1001",
+		Metadata:    map[string]string{"cost_center": "CC-42"},
+		CreatedAt:   time.Now(),
 	}
 
 	// Insert bill into open_bills before closing it
@@ -101,9 +107,428 @@ func TestAddClosedBillToDB(t *testing.T) {
 	assert.Equal(t, "USD", dbBill.Total.Currency)
 	assert.Equal(t, domain.BillClosed, dbBill.Status)
 
+	// Verify that the external reference and metadata were kept
+	var externalRef, costCenter string
+	err = testDB.QueryRow(ctx, `SELECT external_ref, metadata->>'cost_center' FROM closed_bills WHERE id = $1`, billID).Scan(&externalRef, &costCenter)
+	assert.NoError(t, err)
+	assert.Equal(t, "PO-1001", externalRef)
+	assert.Equal(t, "CC-42", costCenter)
+
 	// Verify that the bill was removed from `open_bills`
 	var count int
 	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM open_bills WHERE id = $1`, billID).Scan(&count)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, count) // Should be removed
 }
+
+func TestAddCancelledBillToDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	// Generate test data
+	billID := uuid.New()
+	userID := uuid.New()
+	requestID := uuid.New().String()
+
+	bill := &domain.Bill{
+		ID:        billID,
+		TenantID:  "default",
+		UserID:    userID,
+		Total:     domain.Money{Amount: 100, Currency: "USD"},
+		CreatedAt: time.Now(),
+	}
+
+	// Insert bill into open_bills before cancelling it
+	_, err = testDB.Exec(ctx, `
+		INSERT INTO open_bills (id, user_id, currency, status, created_at, updated_at, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, billID, userID, "USD", domain.BillOpen, time.Now(), time.Now(), requestID)
+	assert.NoError(t, err)
+
+	// Bills without a cancellation record are rejected
+	err = activities.AddCancelledBillToDB(ctx, bill, &requestID)
+	assert.Error(t, err)
+
+	// Execute the activity to cancel the bill
+	assert.NoError(t, bill.Cancel("Created by mistake", userID.String(), time.Now()))
+	bill.Record(domain.EventBillCancelled, userID.String(), requestID, nil, bill.Total, time.Now())
+	err = activities.AddCancelledBillToDB(ctx, bill, &requestID)
+	assert.NoError(t, err)
+
+	// Verify that the bill was moved to `closed_bills` with its cancellation
+	var status domain.Status
+	var reason, cancelledBy string
+	err = testDB.QueryRow(ctx, `SELECT status, cancel_reason, cancelled_by FROM closed_bills WHERE id = $1`, billID).Scan(
+		&status, &reason, &cancelledBy,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BillCancelled, status)
+	assert.Equal(t, "Created by mistake", reason)
+	assert.Equal(t, userID.String(), cancelledBy)
+
+	// Verify that the bill's history was persisted with it
+	var eventType domain.EventType
+	err = testDB.QueryRow(ctx, `SELECT type FROM bill_events WHERE bill_id = $1 AND sequence = 1`, billID).Scan(&eventType)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EventBillCancelled, eventType)
+
+	// Verify that the bill was removed from `open_bills`
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM open_bills WHERE id = $1`, billID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestAddAmendedBillToDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	// Generate test data
+	item := domain.Item{ID: uuid.New(), Description: "Consulting", PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 1}
+	original := &domain.Bill{
+		ID:       uuid.New(),
+		TenantID: "default",
+		UserID:   uuid.New(),
+		Status:   domain.BillClosed,
+		Version:  1,
+		Items:    []domain.Item{item},
+		Total:    domain.Money{Amount: 100, Currency: "USD"},
+	}
+
+	// Insert the original version into closed_bills
+	_, err = testDB.Exec(ctx, `
+		INSERT INTO closed_bills (id, user_id, status, total_amount, currency, request_id, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, original.ID, original.UserID, domain.BillClosed, 100, "USD", uuid.New(), "default")
+	assert.NoError(t, err)
+
+	item.Quantity = 2
+	bill, amendment, err := domain.NewAmendment(original, []domain.Item{item}, "Undercounted hours", uuid.NewString(), time.Now())
+	assert.NoError(t, err)
+
+	// Execute the activity, retrying it with the same request ID is a no-op
+	requestID := uuid.NewString()
+	assert.NoError(t, activities.AddAmendedBillToDB(ctx, bill, amendment, &requestID))
+	assert.NoError(t, activities.AddAmendedBillToDB(ctx, bill, amendment, &requestID))
+
+	// Verify that the new version links to the original and the amendment was recorded
+	var version int
+	var amendsBillID uuid.UUID
+	err = testDB.QueryRow(ctx, `SELECT version, amends_bill_id FROM closed_bills WHERE id = $1`, bill.ID).Scan(&version, &amendsBillID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, original.ID, amendsBillID)
+
+	var adjustmentType string
+	err = testDB.QueryRow(ctx, `SELECT adjustment_type FROM bill_amendments WHERE amended_bill_id = $1`, bill.ID).Scan(&adjustmentType)
+	assert.NoError(t, err)
+	assert.Equal(t, string(domain.AdjustmentDebit), adjustmentType)
+
+	// A second amendment of the same version is rejected
+	otherRequestID := uuid.NewString()
+	other, otherAmendment, err := domain.NewAmendment(original, nil, "Billed by mistake", uuid.NewString(), time.Now())
+	assert.NoError(t, err)
+	err = activities.AddAmendedBillToDB(ctx, other, otherAmendment, &otherRequestID)
+	assert.Equal(t, billerr.ErrBillAmended, billerr.Kind(err))
+}
+
+func TestCollectBillInDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	// Close a bill with a due date
+	requestID := uuid.New().String()
+	bill := &domain.Bill{
+		ID:           uuid.New(),
+		TenantID:     "default",
+		UserID:       uuid.New(),
+		Total:        domain.Money{Amount: 100, Currency: "USD"},
+		PaymentTerms: &domain.PaymentTerms{DueIn: 24 * time.Hour},
+		CreatedAt:    time.Now(),
+	}
+	bill.SetDueDate(time.Now().Add(-72 * time.Hour))
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, bill, &requestID))
+
+	status, err := activities.GetCollectionStatus(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionUnpaid, status)
+
+	// Interest accrued over the two days the bill is overdue is charged once, however often fees are accrued
+	policy := domain.LateFeePolicy{Type: domain.LateFeeDailyInterest, Rate: 500}
+	at := time.Now()
+	charged, err := repo.AccrueLateFees(ctx, "default", policy, at)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, charged, 1)
+	_, err = repo.AccrueLateFees(ctx, "default", policy, at)
+	assert.NoError(t, err)
+
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM bill_late_fees WHERE bill_id = $1`, bill.ID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Late fees are owed on top of the bill total
+	balance, err := repo.GetBalanceDue(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money{Amount: 110, Currency: "USD"}, balance)
+
+	// A settled bill keeps its status
+	assert.NoError(t, activities.UpdateCollectionStatus(ctx, bill, domain.CollectionPaid))
+	assert.NoError(t, activities.UpdateCollectionStatus(ctx, bill, domain.CollectionUncollectible))
+
+	status, err = activities.GetCollectionStatus(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionPaid, status)
+}
+
+func TestAmendedBillCollectionInDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	// Close an overdue bill and charge it a late fee
+	item := domain.Item{ID: uuid.New(), Description: "Consulting", PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 1}
+	requestID := uuid.New().String()
+	original := &domain.Bill{
+		ID:           uuid.New(),
+		TenantID:     "default",
+		UserID:       uuid.New(),
+		Status:       domain.BillClosed,
+		Items:        []domain.Item{item},
+		Total:        domain.Money{Amount: 100, Currency: "USD"},
+		PaymentTerms: &domain.PaymentTerms{DueIn: 24 * time.Hour},
+		CreatedAt:    time.Now(),
+	}
+	original.SetDueDate(time.Now().Add(-72 * time.Hour))
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, original, &requestID))
+
+	policy := domain.LateFeePolicy{Type: domain.LateFeeFlat, Amount: domain.Money{Amount: 10, Currency: "USD"}}
+	_, err = repo.AccrueLateFees(ctx, "default", policy, time.Now())
+	assert.NoError(t, err)
+
+	// Amend the bill
+	item.Quantity = 2
+	bill, amendment, err := domain.NewAmendment(original, []domain.Item{item}, "Undercounted hours", uuid.NewString(), time.Now())
+	assert.NoError(t, err)
+	amendRequestID := uuid.NewString()
+	assert.NoError(t, activities.AddAmendedBillToDB(ctx, bill, amendment, &amendRequestID))
+
+	// The original is no longer collected, and the new version is collected by the same due date
+	status, err := activities.GetCollectionStatus(ctx, original)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionSuperseded, status)
+
+	status, err = activities.GetCollectionStatus(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionUnpaid, status)
+
+	var dueAt time.Time
+	err = testDB.QueryRow(ctx, `SELECT due_at FROM closed_bills WHERE id = $1`, bill.ID).Scan(&dueAt)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, *original.DueAt, dueAt, time.Second)
+
+	// The late fee moves to the new version and is not charged again
+	_, err = repo.AccrueLateFees(ctx, "default", policy, time.Now())
+	assert.NoError(t, err)
+
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM bill_late_fees WHERE bill_id = $1`, bill.ID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	balance, err := repo.GetBalanceDue(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money{Amount: 210, Currency: "USD"}, balance)
+}
+
+func TestApplyWalletCreditInDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	// The customer prepaid less than the amount due
+	userID := uuid.New()
+	_, err = testDB.Exec(ctx, `INSERT INTO wallets (tenant_id, user_id, currency, balance) VALUES ('default', $1, 'USD', 60)`, userID)
+	assert.NoError(t, err)
+
+	requestID := uuid.New().String()
+	bill := &domain.Bill{
+		ID:           uuid.New(),
+		TenantID:     "default",
+		UserID:       userID,
+		Total:        domain.Money{Amount: 100, Currency: "USD"},
+		PaymentTerms: &domain.PaymentTerms{DueIn: 24 * time.Hour},
+		CreatedAt:    time.Now(),
+	}
+	bill.SetDueDate(time.Now())
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, bill, &requestID))
+
+	// Closing twice draws the credit down once
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, bill, &requestID))
+
+	var walletBalance domain.MinorUnit
+	err = testDB.QueryRow(ctx, `SELECT balance::BIGINT FROM wallets WHERE user_id = $1`, userID).Scan(&walletBalance)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MinorUnit(0), walletBalance)
+
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM wallet_entries WHERE bill_id = $1 AND type = 'consumption'`, bill.ID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The rest remains due
+	balance, err := repo.GetBalanceDue(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money{Amount: 40, Currency: "USD"}, balance)
+
+	status, err := activities.GetCollectionStatus(ctx, bill)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CollectionUnpaid, status)
+}
+
+func TestJournalBillInDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+	activities := Activities{Repository: &repo}
+
+	requestID := uuid.New().String()
+	bill := &domain.Bill{
+		ID:        uuid.New(),
+		TenantID:  "default",
+		UserID:    uuid.New(),
+		Total:     domain.Money{Amount: 100, Currency: "USD"},
+		Tax:       domain.Money{Amount: 10, Currency: "USD"},
+		CreatedAt: time.Now(),
+	}
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, bill, &requestID))
+
+	// Closing twice journals the bill once
+	assert.NoError(t, activities.AddClosedBillToDB(ctx, bill, &requestID))
+	assert.NoError(t, activities.UpdateCollectionStatus(ctx, bill, domain.CollectionPaid))
+
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM journal_entries WHERE bill_id = $1`, bill.ID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Once paid, nothing remains receivable and the ledger balances
+	var receivable, debit, credit domain.MinorUnit
+	err = testDB.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(p.debit - p.credit) FILTER (WHERE p.account_code = '1100'), 0)::BIGINT,
+			COALESCE(SUM(p.debit), 0)::BIGINT,
+			COALESCE(SUM(p.credit), 0)::BIGINT
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE e.bill_id = $1`, bill.ID).Scan(&receivable, &debit, &credit)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MinorUnit(0), receivable)
+	assert.Equal(t, debit, credit)
+
+	// Unbalanced entries are rejected when the transaction commits
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	entry := &domain.JournalEntry{ID: uuid.New(), TenantID: "default", Source: domain.JournalPayment, SourceKey: "payment:unbalanced", OccurredAt: time.Now()}
+	_, err = tx.ExecContext(ctx, `INSERT INTO journal_entries (id, tenant_id, source, source_key, description, occurred_at) VALUES ($1, $2, $3, $4, '', $5)`,
+		entry.ID, entry.TenantID, entry.Source, entry.SourceKey, entry.OccurredAt)
+	assert.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO journal_postings (entry_id, tenant_id, account_code, debit, credit, currency) VALUES ($1, $2, '1000', 100, 0, 'USD')`,
+		entry.ID, entry.TenantID)
+	assert.NoError(t, err)
+	assert.Error(t, tx.Commit())
+}
+
+func TestImportClosedBillInDB(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new test database
+	testDB, err := et.NewTestDatabase(ctx, "bills")
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	repo := Repo{DB: testDB.Stdlib()}
+
+	closedAt := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	bill := &domain.Bill{
+		ID:         uuid.New(),
+		TenantID:   "default",
+		UserID:     uuid.New(),
+		Items:      []domain.Item{{ID: uuid.New(), Quantity: 1, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}}},
+		Total:      domain.Money{Amount: 100, Currency: "USD"},
+		Tax:        domain.Money{Amount: 10, Currency: "USD"},
+		Status:     domain.BillClosed,
+		Collection: domain.CollectionPaid,
+		CreatedAt:  closedAt.Add(-24 * time.Hour),
+		ClosedAt:   closedAt,
+	}
+
+	written, err := repo.ImportClosedBill(ctx, bill, bill.ID.String())
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	// Re-running the import leaves the bill untouched
+	written, err = repo.ImportClosedBill(ctx, bill, bill.ID.String())
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	// The bill keeps its historical close time and is never dunned
+	var storedClosedAt time.Time
+	var dueAt sql.NullTime
+	err = testDB.QueryRow(ctx, `SELECT closed_at, due_at FROM closed_bills WHERE id = $1`, bill.ID).Scan(&storedClosedAt, &dueAt)
+	assert.NoError(t, err)
+	assert.True(t, closedAt.Equal(storedClosedAt))
+	assert.False(t, dueAt.Valid)
+
+	// Closing and payment are journaled as of the close
+	var count int
+	err = testDB.QueryRow(ctx, `SELECT COUNT(*) FROM journal_entries WHERE bill_id = $1 AND occurred_at = $2`, bill.ID, closedAt).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,38 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// AnalyticsWorkflowID returns the ID of the scheduled workflow refreshing a tenant's analytics rollups.
+func AnalyticsWorkflowID(tenantID string) string {
+	return WorkflowID(tenantID, "analytics")
+}
+
+// AnalyticsWorkflow rebuilds the revenue and bill count rollups the analytics reports of a tenant are served from.
+// Workers start it on the cron schedule of conf.ANALYTICS_SCHEDULE. Each run rebuilds the rollups as a whole,
+// so a run which is retried or overlaps another leaves them as a single run would.
+func AnalyticsWorkflow(ctx workflow.Context, tenantID string) (int, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		// Runs go through every closed bill of the tenant
+		StartToCloseTimeout: 10 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second * 2,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var days int
+	if err := workflow.ExecuteActivity(ctx, RefreshAnalytics, tenantID, workflow.Now(ctx)).Get(ctx, &days); err != nil {
+		return 0, fmt.Errorf("Error refreshing analytics: %v", err)
+	}
+
+	workflow.GetLogger(ctx).Info("Analytics refreshed", "TenantID", tenantID, "Days", days)
+	return days, nil
+}
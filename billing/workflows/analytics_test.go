@@ -0,0 +1,36 @@
+package workflows
+
+import (
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *UnitTestSuite) Test_AnalyticsWorkflow() {
+	start := time.Date(2025, 1, 1, 0, 15, 0, 0, time.UTC)
+	s.env.SetStartTime(start)
+
+	// Rollups are recorded as refreshed at the start of the run
+	s.mockActivities.On("RefreshAnalytics", mock.Anything, "acme", mock.MatchedBy(func(at time.Time) bool {
+		return at.Equal(start)
+	})).Return(31, nil).Once()
+
+	s.env.ExecuteWorkflow(AnalyticsWorkflow, "acme")
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var days int
+	s.NoError(s.env.GetWorkflowResult(&days))
+	s.Equal(31, days)
+}
+
+func (s *UnitTestSuite) Test_AnalyticsWorkflowFails() {
+	s.mockActivities.On("RefreshAnalytics", mock.Anything, "acme", mock.Anything).Return(0, errors.New("database unavailable"))
+
+	s.env.ExecuteWorkflow(AnalyticsWorkflow, "acme")
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+}
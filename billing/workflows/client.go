@@ -0,0 +1,103 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
+// APIClient reaches the notification and payments services through the Feezy API,
+// authenticated with an admin API key of the tenant whose bills it collects.
+type APIClient struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+type paymentReminder struct {
+	BillID    string       `json:"bill_id"`
+	UserID    string       `json:"user_id"`
+	AmountDue domain.Money `json:"amount_due"`
+	DueAt     time.Time    `json:"due_at"`
+}
+
+type charge struct {
+	BillID    string       `json:"bill_id"`
+	UserID    string       `json:"user_id"`
+	RequestID string       `json:"request_id"`
+	Amount    domain.Money `json:"amount"`
+}
+
+type chargeResult struct {
+	Status string `json:"status"`
+}
+
+// SendPaymentReminder asks the notification service to remind the customer of the amount they owe on an unpaid bill.
+func (c *APIClient) SendPaymentReminder(ctx context.Context, bill *domain.Bill, amountDue domain.Money) error {
+	return c.post(ctx, "/notifications/payment-reminders", &paymentReminder{
+		BillID:    bill.ID.String(),
+		UserID:    bill.UserID.String(),
+		AmountDue: amountDue,
+		DueAt:     *bill.DueAt,
+	}, nil)
+}
+
+// Charge asks the payments service to charge the customer for the amount they owe on a bill.
+// The request ID keeps a retried charge from being taken twice.
+func (c *APIClient) Charge(ctx context.Context, bill *domain.Bill, amount domain.Money, requestID string) (bool, error) {
+	var result chargeResult
+	err := c.post(ctx, "/payments/charge", &charge{
+		BillID:    bill.ID.String(),
+		UserID:    bill.UserID.String(),
+		RequestID: requestID,
+		Amount:    amount,
+	}, &result)
+	if err != nil {
+		return false, err
+	}
+
+	return result.Status == "succeeded", nil
+}
+
+// Send a JSON request to the API, decoding the response into out if given.
+// Server failures are transient, while rejected requests fail immediately.
+func (c *APIClient) post(ctx context.Context, path string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Error encoding request", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return billerr.New(billerr.ErrInvalidRequest, "Error building request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return billerr.New(billerr.ErrTransient, "Error calling "+path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return billerr.New(billerr.ErrTransient, fmt.Sprintf("%s returned %s", path, resp.Status), nil)
+	}
+	if resp.StatusCode >= 300 {
+		return billerr.New(billerr.ErrInvalidRequest, fmt.Sprintf("%s returned %s", path, resp.Status), nil)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return billerr.New(billerr.ErrTransient, "Error decoding response of "+path, err)
+	}
+	return nil
+}
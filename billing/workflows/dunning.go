@@ -0,0 +1,111 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// DunningWorkflowID returns the ID of the workflow collecting a tenant's closed bill.
+func DunningWorkflowID(tenantID string, billID string) string {
+	return WorkflowID(tenantID, "dunning-"+billID)
+}
+
+// Start collecting a bill that was closed with a due date, in a workflow that outlives the bill's own.
+func startDunning(ctx workflow.Context, bill *domain.Bill) error {
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        DunningWorkflowID(bill.TenantID, bill.ID.String()),
+		ParentClosePolicy: enums.PARENT_CLOSE_POLICY_ABANDON,
+	})
+
+	// Wait for the dunning workflow to start, so it is not lost when the bill workflow completes
+	child := workflow.ExecuteChildWorkflow(ctx, DunningWorkflow, bill)
+	return child.GetChildWorkflowExecution().Get(ctx, nil)
+}
+
+// DunningWorkflow follows up on the payment of a closed bill, taking the steps of its dunning schedule
+// at their offsets from the due date: reminding the customer, retrying the payment, and finally marking
+// the bill as uncollectible. The schedule ends as soon as the bill is paid.
+// Reminders and payments cover the late fees accrued on the bill by then, see LateFeeWorkflow.
+func DunningWorkflow(ctx workflow.Context, bill *domain.Bill) (domain.CollectionStatus, error) {
+	logger := workflow.GetLogger(ctx)
+
+	if bill.DueAt == nil || bill.PaymentTerms == nil {
+		return "", fmt.Errorf("Bill %s has no due date", bill.ID)
+	}
+
+	// Set retry policy for transient failures (e.g., network issues)
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second * 2,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	})
+
+	for i, step := range bill.PaymentTerms.Dunning {
+		// Wait until the step is due
+		if wait := bill.DueAt.Add(step.Offset).Sub(workflow.Now(ctx)); wait > 0 {
+			if err := workflow.Sleep(ctx, wait); err != nil {
+				return "", err
+			}
+		}
+
+		// Stop following up once the bill was settled
+		var status domain.CollectionStatus
+		if err := workflow.ExecuteActivity(ctx, GetCollectionStatus, bill).Get(ctx, &status); err != nil {
+			return "", fmt.Errorf("Error checking collection status: %v", err)
+		}
+		if status != domain.CollectionUnpaid {
+			logger.Info("Bill settled, ending dunning", "BillID", bill.ID, "Status", status)
+			return status, nil
+		}
+
+		// Derive the ID of each step from the bill, so a step is never applied twice
+		requestID := uuid.NewSHA1(bill.ID, []byte(fmt.Sprintf("dunning-%d", i))).String()
+
+		logger.Info("Taking dunning step", "BillID", bill.ID, "Step", i+1, "Action", step.Action)
+		switch step.Action {
+		case domain.DunningRemind:
+			if err := workflow.ExecuteActivity(ctx, SendPaymentReminder, bill).Get(ctx, nil); err != nil {
+				// A missed reminder does not hold up the rest of the schedule
+				logger.Error("Error sending payment reminder", "BillID", bill.ID, "Error", err)
+			}
+
+		case domain.DunningRetryPayment:
+			var paid bool
+			if err := workflow.ExecuteActivity(ctx, ChargeBill, bill, requestID).Get(ctx, &paid); err != nil {
+				logger.Error("Error retrying payment", "BillID", bill.ID, "Error", err)
+			}
+			if paid {
+				return settle(ctx, bill, domain.CollectionPaid)
+			}
+
+		case domain.DunningUncollectible:
+			return settle(ctx, bill, domain.CollectionUncollectible)
+
+		default:
+			logger.Warn("Skipping unknown dunning action", "BillID", bill.ID, "Action", step.Action)
+		}
+	}
+
+	return domain.CollectionUnpaid, nil
+}
+
+// Record the final collection status of a bill.
+func settle(ctx workflow.Context, bill *domain.Bill, status domain.CollectionStatus) (domain.CollectionStatus, error) {
+	if err := workflow.ExecuteActivity(ctx, UpdateCollectionStatus, bill, status).Get(ctx, nil); err != nil {
+		return "", fmt.Errorf("Error updating collection status: %v", err)
+	}
+
+	workflow.GetLogger(ctx).Info("Bill settled", "BillID", bill.ID, "Status", status)
+	return status, nil
+}
@@ -0,0 +1,197 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/vvvakho/feezy/billing/service/domain"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// Closed bill due at the given time, dunned with a reminder, a payment retry
+// and finally marked uncollectible, one hour apart.
+func newDueBill(dueAt time.Time) *domain.Bill {
+	return &domain.Bill{
+		ID:         uuid.New(),
+		TenantID:   "default",
+		UserID:     uuid.New(),
+		Status:     domain.BillClosed,
+		Total:      domain.Money{Amount: 1000, Currency: "USD"},
+		Tax:        domain.Money{Amount: 180, Currency: "USD"},
+		DueAt:      &dueAt,
+		Collection: domain.CollectionUnpaid,
+		PaymentTerms: &domain.PaymentTerms{
+			DueIn: 24 * time.Hour,
+			Dunning: []domain.DunningStep{
+				{Offset: 0, Action: domain.DunningRemind},
+				{Offset: time.Hour, Action: domain.DunningRetryPayment},
+				{Offset: 2 * time.Hour, Action: domain.DunningUncollectible},
+			},
+		},
+	}
+}
+
+func (s *UnitTestSuite) Test_DunningWorkflowPaidOnRetry() {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(start)
+	bill := newDueBill(start.Add(time.Hour))
+
+	s.mockActivities.On("GetCollectionStatus", mock.Anything, mock.Anything).Return(domain.CollectionUnpaid, nil).Twice()
+	s.mockActivities.On("SendPaymentReminder", mock.Anything, mock.Anything).Return(nil).Once()
+	s.mockActivities.On("ChargeBill", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Once()
+	s.mockActivities.On("UpdateCollectionStatus", mock.Anything, mock.Anything, domain.CollectionPaid).Return(nil).Once()
+
+	s.env.ExecuteWorkflow(DunningWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var status domain.CollectionStatus
+	s.NoError(s.env.GetWorkflowResult(&status))
+	s.Equal(domain.CollectionPaid, status)
+
+	// Steps wait for their offset from the due date
+	s.False(s.env.Now().Before(bill.DueAt.Add(time.Hour)))
+	s.mockActivities.AssertNotCalled(s.T(), "UpdateCollectionStatus", mock.Anything, mock.Anything, domain.CollectionUncollectible)
+}
+
+func (s *UnitTestSuite) Test_DunningWorkflowUncollectible() {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(start)
+	bill := newDueBill(start)
+
+	var chargeRequestID string
+	s.mockActivities.On("GetCollectionStatus", mock.Anything, mock.Anything).Return(domain.CollectionUnpaid, nil).Times(3)
+	s.mockActivities.On("SendPaymentReminder", mock.Anything, mock.Anything).Return(nil).Once()
+	s.mockActivities.On("ChargeBill", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { chargeRequestID = args.String(2) }).
+		Return(false, nil).Once()
+	s.mockActivities.On("UpdateCollectionStatus", mock.Anything, mock.Anything, domain.CollectionUncollectible).Return(nil).Once()
+
+	s.env.ExecuteWorkflow(DunningWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var status domain.CollectionStatus
+	s.NoError(s.env.GetWorkflowResult(&status))
+	s.Equal(domain.CollectionUncollectible, status)
+
+	// Steps are applied under a request ID derived from the bill
+	s.Equal(uuid.NewSHA1(bill.ID, []byte("dunning-1")).String(), chargeRequestID)
+}
+
+func (s *UnitTestSuite) Test_DunningWorkflowStopsOncePaid() {
+	bill := newDueBill(time.Now())
+
+	s.mockActivities.On("GetCollectionStatus", mock.Anything, mock.Anything).Return(domain.CollectionPaid, nil).Once()
+
+	s.env.ExecuteWorkflow(DunningWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.mockActivities.AssertNotCalled(s.T(), "SendPaymentReminder", mock.Anything, mock.Anything)
+}
+
+func (s *UnitTestSuite) Test_CloseBillStartsDunning() {
+	bill := &domain.Bill{
+		ID:           uuid.New(),
+		TenantID:     "default",
+		Status:       domain.BillOpen,
+		Total:        domain.Money{Amount: 0, Currency: "USD"},
+		Items:        []domain.Item{},
+		PaymentTerms: &domain.PaymentTerms{DueIn: 24 * time.Hour},
+	}
+	requestID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// The closed bill is stored with its due date
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.DueAt != nil && b.DueAt.Equal(b.ClosedAt.Add(24*time.Hour)) && b.Collection == domain.CollectionUnpaid
+	}), requestID).Return(nil).Once()
+	s.env.OnWorkflow(DunningWorkflow, mock.Anything, mock.Anything).Return(domain.CollectionPaid, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+		}, requestID, &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 0, false)
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{RequestID: requestID})
+	}, time.Millisecond*2)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *UnitTestSuite) Test_PaidCloseSkipsDunning() {
+	bill := &domain.Bill{
+		ID:           uuid.New(),
+		TenantID:     "default",
+		Status:       domain.BillOpen,
+		Total:        domain.Money{Amount: 0, Currency: "USD"},
+		Items:        []domain.Item{},
+		PaymentTerms: &domain.PaymentTerms{DueIn: 24 * time.Hour},
+	}
+	requestID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// The bill is stored as paid, with its due date
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.DueAt != nil && b.Collection == domain.CollectionPaid
+	}), requestID).Return(nil).Once()
+	dunned := false
+	s.env.OnWorkflow(DunningWorkflow, mock.Anything, mock.Anything).Return(domain.CollectionPaid, nil).Run(func(args mock.Arguments) {
+		dunned = true
+	}).Maybe()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal(domain.CollectionPaid, result.(*domain.Bill).Collection)
+			},
+		}, requestID, &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 0, true)
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{RequestID: requestID})
+	}, time.Millisecond*2)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.False(dunned)
+}
+
+func (s *UnitTestSuite) Test_AmendBillStartsDunning() {
+	bill, amendment := newAmendment(s)
+	dueAt := time.Now().Add(24 * time.Hour)
+	bill.DueAt = &dueAt
+	bill.Collection = domain.CollectionUnpaid
+	bill.PaymentTerms = &domain.PaymentTerms{DueIn: 24 * time.Hour}
+
+	s.mockActivities.On("AddAmendedBillToDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	// The new version is dunned in place of the original
+	s.env.OnWorkflow(DunningWorkflow, mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.ID == bill.ID
+	})).Return(domain.CollectionPaid, nil).Once()
+
+	s.env.ExecuteWorkflow(AmendBillWorkflow, bill, amendment, uuid.NewString())
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+}
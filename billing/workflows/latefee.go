@@ -0,0 +1,38 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// LateFeeWorkflowID returns the ID of the scheduled workflow accruing late fees on a tenant's overdue bills.
+func LateFeeWorkflowID(tenantID string) string {
+	return WorkflowID(tenantID, "late-fees")
+}
+
+// LateFeeWorkflow accrues late fees on the overdue bills of a tenant, under the tenant's late fee policy.
+// Workers start it on the cron schedule of conf.LATE_FEE_SCHEDULE, and each run charges the fees accrued
+// up to its start time, so that a run which is retried or overlaps another never charges a fee twice.
+func LateFeeWorkflow(ctx workflow.Context, tenantID string) (int, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		// Runs go through every overdue bill of the tenant
+		StartToCloseTimeout: 10 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second * 2,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var charged int
+	if err := workflow.ExecuteActivity(ctx, AccrueLateFees, tenantID, workflow.Now(ctx)).Get(ctx, &charged); err != nil {
+		return 0, fmt.Errorf("Error accruing late fees: %v", err)
+	}
+
+	workflow.GetLogger(ctx).Info("Late fees accrued", "TenantID", tenantID, "Bills", charged)
+	return charged, nil
+}
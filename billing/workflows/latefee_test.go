@@ -0,0 +1,36 @@
+package workflows
+
+import (
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *UnitTestSuite) Test_LateFeeWorkflow() {
+	start := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(start)
+
+	// Fees are accrued up to the start of the run
+	s.mockActivities.On("AccrueLateFees", mock.Anything, "acme", mock.MatchedBy(func(at time.Time) bool {
+		return at.Equal(start)
+	})).Return(3, nil).Once()
+
+	s.env.ExecuteWorkflow(LateFeeWorkflow, "acme")
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var charged int
+	s.NoError(s.env.GetWorkflowResult(&charged))
+	s.Equal(3, charged)
+}
+
+func (s *UnitTestSuite) Test_LateFeeWorkflowFails() {
+	s.mockActivities.On("AccrueLateFees", mock.Anything, "acme", mock.Anything).Return(0, errors.New("database unavailable"))
+
+	s.env.ExecuteWorkflow(LateFeeWorkflow, "acme")
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/vvvakho/feezy/billing/billerr"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
@@ -12,16 +13,63 @@ import (
 )
 
 type AddItemSignal struct {
-	LineItem domain.Item
+	LineItem         domain.Item
+	RequestID        string
+	ActorID          string
+	ExpectedRevision int // revision of the bill the change was made against, zero if unconditional
+}
+
+// AddItemsUpdate carries a batch of line items that are added to a bill together.
+type AddItemsUpdate struct {
+	LineItems        []domain.Item
+	RequestID        string
+	ActorID          string
+	ExpectedRevision int // revision of the bill the change was made against, zero if unconditional
+}
+
+// UpdateItemUpdate carries a change of a line item, made against the version of the item it was read at.
+type UpdateItemUpdate struct {
+	Update           domain.ItemUpdate
+	RequestID        string
+	ActorID          string
+	ExpectedRevision int // revision of the bill the change was made against, zero if unconditional
 }
 
 type RemoveItemSignal struct {
-	LineItem domain.Item
+	LineItem         domain.Item
+	RequestID        string
+	ActorID          string
+	ExpectedRevision int // revision of the bill the change was made against, zero if unconditional
 }
 
 type CloseBillSignal struct {
-	Route     string
+	Route            string
+	RequestID        string
+	ActorID          string
+	BillingProfile   *domain.BillingProfile
+	ApprovalPolicy   *ApprovalPolicy
+	ExpectedRevision int  // revision of the bill the close was requested against, zero if unconditional
+	Paid             bool // whether the bill's payment was taken along with the close
+}
+
+// ApprovalPolicy decides which bills need a second approver before they are closed.
+type ApprovalPolicy struct {
+	Limit   domain.Money
+	Timeout time.Duration
+}
+
+// CloseApprovalSignal carries the decision of a second approver about a pending close request.
+type CloseApprovalSignal struct {
+	RequestID  string // close request the decision is about
+	Approved   bool
+	ApproverID string
+	Comment    string
+}
+
+type CancelBillSignal struct {
 	RequestID string
+	Reason    string
+	ActorID   string
 }
 
 type CloseWorkflowSignal struct {
@@ -49,6 +97,10 @@ var CloseWorkflowRoute = SignalRoute{
 	Name: "CloseWorkflowSignal",
 }
 
+var CloseApprovalRoute = SignalRoute{
+	Name: "CloseApprovalSignal",
+}
+
 // Register Temporal signal handlers for processing bill events.
 func registerSignalHandlers(
 	ctx workflow.Context,
@@ -58,6 +110,7 @@ func registerSignalHandlers(
 	removeLineItemChan,
 	closeBillChan,
 	closeWorkflowChan workflow.ReceiveChannel,
+	closeDecidedChan workflow.Channel,
 	bill *domain.Bill,
 	logger log.Logger,
 ) {
@@ -78,7 +131,7 @@ func registerSignalHandlers(
 
 	// Register a handler for closing bill (through a signal)
 	selector.AddReceive(closeBillChan, func(c workflow.ReceiveChannel, _ bool) {
-		if err := HandleCloseBillSignal(ctx, mu, c, bill, logger); err != nil {
+		if err := HandleCloseBillSignal(ctx, mu, c, closeDecidedChan, bill, logger); err != nil {
 			logger.Error("Closing bill", "Error", err)
 		}
 	})
@@ -89,6 +142,13 @@ func registerSignalHandlers(
 			logger.Error("Closing workflow", "Error", err)
 		}
 	})
+
+	// Wake up once a close request awaiting approval was decided, so a closed bill finishes the workflow
+	selector.AddReceive(closeDecidedChan, func(c workflow.ReceiveChannel, _ bool) {
+		var requestID string
+		c.Receive(ctx, &requestID)
+		logger.Info("Close approval decided", "BillID", bill.ID, "RequestID", requestID, "Status", bill.Status)
+	})
 }
 
 // Handler function for adding line item to bill.
@@ -100,15 +160,26 @@ func HandleAddLineItemSignal(ctx workflow.Context, mu workflow.Mutex, c workflow
 	}
 	defer mu.Unlock()
 
+	// Take the signal off its channel even if it is rejected, or the workflow would be woken up for it forever
+	var addSignal AddItemSignal
+	c.Receive(ctx, &addSignal)
+
 	if bill.Status != domain.BillOpen {
 		return fmt.Errorf("Bill is no longer open")
 	}
 
-	var addSignal AddItemSignal
-	c.Receive(ctx, &addSignal)
+	// Changes made against an earlier revision would overwrite changes their sender has not seen
+	if err := bill.CheckRevision(addSignal.ExpectedRevision); err != nil {
+		return err
+	}
 
+	totalBefore := bill.Total
 	lineItem := addSignal.LineItem
 	if err := bill.AddLineItem(lineItem); err != nil {
+		if errors.Is(err, domain.ErrCreditLimitExceeded) {
+			// Signals cannot report failures to the client, so rejected additions are kept in the bill history
+			bill.Record(domain.EventCreditLimitExceeded, addSignal.ActorID, addSignal.RequestID, &lineItem, totalBefore, workflow.Now(ctx))
+		}
 		return err
 	}
 
@@ -117,8 +188,111 @@ func HandleAddLineItemSignal(ctx workflow.Context, mu workflow.Mutex, c workflow
 	}
 
 	bill.UpdatedAt = time.Now()
+	bill.Record(domain.EventItemAdded, addSignal.ActorID, addSignal.RequestID, &lineItem, totalBefore, workflow.Now(ctx))
 
-	return nil
+	return bill.RecordCreditWarnings(addSignal.ActorID, addSignal.RequestID, totalBefore, workflow.Now(ctx))
+}
+
+// Handler function for adding a batch of line items to a bill through an update call.
+// Either every item is added, recorded as one event per item under the request, or none are.
+func HandleAddLineItemsUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain.Bill, logger log.Logger) error {
+	// Set up a handler function to process AddLineItemsUpdate events
+	err := workflow.SetUpdateHandler(ctx, "AddLineItemsUpdate", func(ctx workflow.Context, requestID string, items []domain.Item, actorID string, expectedRevision int) (*domain.Bill, error) {
+		// Use mutex locking for safe concurrency
+		err := mu.Lock(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error locking mutex: %v", err)
+		}
+		defer mu.Unlock()
+
+		// Only open bills accept items
+		switch bill.Status {
+		case domain.BillClosed:
+			return nil, billerr.New(billerr.ErrBillClosed, "Bill already closed", nil)
+		case domain.BillClosing:
+			return nil, billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil)
+		case domain.BillCancelled:
+			return nil, billerr.New(billerr.ErrBillCancelled, "Bill is cancelled", nil)
+		case domain.BillAwaitingApproval:
+			return nil, billerr.New(billerr.ErrAwaitingApproval, "Bill is awaiting close approval", nil)
+		}
+
+		// Changes made against an earlier revision would overwrite changes their sender has not seen
+		if err := bill.CheckRevision(expectedRevision); err != nil {
+			logger.Warn("Received batch of line items for a stale revision", "BillID", bill.ID, "Error", err)
+			return nil, billerr.New(billerr.ErrStaleRevision, "Bill was changed since it was read", err)
+		}
+
+		totalBefore := bill.Total
+		now := workflow.Now(ctx)
+		if err := bill.AddLineItems(items, actorID, requestID, now); err != nil {
+			logger.Warn("Rejecting batch of line items", "BillID", bill.ID, "RequestID", requestID, "Error", err)
+			return nil, billerr.New(billerr.ErrUserInput, "Items rejected", err)
+		}
+		bill.UpdatedAt = time.Now()
+
+		if err := bill.RecordCreditWarnings(actorID, requestID, totalBefore, now); err != nil {
+			return nil, err
+		}
+		return bill, nil
+	})
+
+	return err
+}
+
+// Handler function for changing the quantity, description or price of a line item through an update call.
+// The change is rejected if the item was changed since the version it was made against.
+func HandleUpdateLineItemUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain.Bill, logger log.Logger) error {
+	// Set up a handler function to process UpdateLineItemUpdate events
+	err := workflow.SetUpdateHandler(ctx, "UpdateLineItemUpdate", func(ctx workflow.Context, requestID string, update domain.ItemUpdate, actorID string, expectedRevision int) (*domain.Bill, error) {
+		// Use mutex locking for safe concurrency
+		err := mu.Lock(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error locking mutex: %v", err)
+		}
+		defer mu.Unlock()
+
+		// Only items of open bills can be changed
+		switch bill.Status {
+		case domain.BillClosed:
+			return nil, billerr.New(billerr.ErrBillClosed, "Bill already closed", nil)
+		case domain.BillClosing:
+			return nil, billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil)
+		case domain.BillCancelled:
+			return nil, billerr.New(billerr.ErrBillCancelled, "Bill is cancelled", nil)
+		case domain.BillAwaitingApproval:
+			return nil, billerr.New(billerr.ErrAwaitingApproval, "Bill is awaiting close approval", nil)
+		}
+
+		// Changes made against an earlier revision would overwrite changes their sender has not seen
+		if err := bill.CheckRevision(expectedRevision); err != nil {
+			logger.Warn("Received line item update for a stale revision", "BillID", bill.ID, "Error", err)
+			return nil, billerr.New(billerr.ErrStaleRevision, "Bill was changed since it was read", err)
+		}
+
+		totalBefore := bill.Total
+		now := workflow.Now(ctx)
+		item, err := bill.UpdateLineItem(update)
+		switch {
+		case errors.Is(err, domain.ErrItemNotFound):
+			return nil, billerr.New(billerr.ErrNotFound, "Item not found in bill", err)
+		case errors.Is(err, domain.ErrItemVersionConflict):
+			logger.Warn("Rejecting stale item update", "BillID", bill.ID, "ItemID", update.ID, "RequestID", requestID)
+			return nil, billerr.New(billerr.ErrVersionConflict, "Item was changed by another request", err)
+		case err != nil:
+			return nil, billerr.New(billerr.ErrUserInput, "Item update rejected", err)
+		}
+
+		bill.UpdatedAt = time.Now()
+		bill.Record(domain.EventItemUpdated, actorID, requestID, &item, totalBefore, now)
+
+		if err := bill.RecordCreditWarnings(actorID, requestID, totalBefore, now); err != nil {
+			return nil, err
+		}
+		return bill, nil
+	})
+
+	return err
 }
 
 // Handler function for removing line item from bill.
@@ -130,30 +304,38 @@ func HandleRemoveLineItemSignal(ctx workflow.Context, mu workflow.Mutex, c workf
 	}
 	defer mu.Unlock()
 
+	// Take the signal off its channel even if it is rejected, or the workflow would be woken up for it forever
+	var removeSignal RemoveItemSignal
+	c.Receive(ctx, &removeSignal)
+
 	if bill.Status != domain.BillOpen {
 		return fmt.Errorf("Bill is no longer open")
 	}
 
-	var removeSignal RemoveItemSignal
-	c.Receive(ctx, &removeSignal)
+	// Changes made against an earlier revision would overwrite changes their sender has not seen
+	if err := bill.CheckRevision(removeSignal.ExpectedRevision); err != nil {
+		return err
+	}
 
+	totalBefore := bill.Total
 	lineItem := removeSignal.LineItem
 	if err := bill.RemoveLineItem(lineItem); err != nil {
 		return err
 	}
 
 	bill.UpdatedAt = time.Now()
+	bill.Record(domain.EventItemRemoved, removeSignal.ActorID, removeSignal.RequestID, &lineItem, totalBefore, workflow.Now(ctx))
 
 	return nil
 }
 
 // Handler function for closing bill through a signal call.
-func HandleCloseBillSignal(ctx workflow.Context, mu workflow.Mutex, c workflow.ReceiveChannel, bill *domain.Bill, logger log.Logger) error {
+func HandleCloseBillSignal(ctx workflow.Context, mu workflow.Mutex, c workflow.ReceiveChannel, closeDecidedChan workflow.Channel, bill *domain.Bill, logger log.Logger) error {
 	for {
-		// If the bill is already closed, ignore further signals
-		if bill.Status == domain.BillClosed {
+		// If the bill is already closed or cancelled, ignore further signals
+		if bill.IsFinal() {
 			logger.Warn("Received close bill signal, but bill is already closed", "BillID", bill.ID)
-			return fmt.Errorf("Bill already closed")
+			return billerr.New(billerr.ErrBillClosed, "Bill already closed", nil)
 		}
 
 		// Use mutex locking for safe concurrency
@@ -163,61 +345,53 @@ func HandleCloseBillSignal(ctx workflow.Context, mu workflow.Mutex, c workflow.R
 		}
 		defer mu.Unlock()
 
+		// Unpack the signal contents
+		var closeSignal CloseBillSignal
+		c.Receive(ctx, &closeSignal)
+
+		// A close awaiting approval is decided by its approver, not by another close request
+		if bill.Status == domain.BillAwaitingApproval {
+			logger.Warn("Received close bill signal, but bill is awaiting close approval", "BillID", bill.ID)
+			return billerr.New(billerr.ErrAwaitingApproval, "Bill is awaiting close approval", nil)
+		}
+
 		// Inititate bill closing status
 		bill.Status = domain.BillClosing
 		bill.UpdatedAt = time.Now()
 
-		// Unpack the signal contents
-		var closeSignal CloseBillSignal
-		c.Receive(ctx, &closeSignal)
+		if err := bill.CheckRevision(closeSignal.ExpectedRevision); err != nil {
+			bill.Status = domain.BillOpen
+			return err
+		}
+
+		// Snapshot the billing profile the bill is closed with
+		bill.BillingProfile = closeSignal.BillingProfile
 
 		// Calculate bill total or throw an error in case of failure
+		totalBefore := bill.Total
 		if err := bill.CalculateTotal(); err != nil {
 			logger.Error("Error calculating bill total", "Error", err)
 			bill.Status = domain.BillOpen
 			return fmt.Errorf("Error closing bill: %v", err)
 		}
 
-		// Set retry policy for transient failures (e.g., network issues)
-		retryPolicy := &temporal.RetryPolicy{
-			InitialInterval:    time.Second * 2,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    time.Minute,
-			MaximumAttempts:    5,
+		// Bills above the approval limit are only closed once a second approver accepts
+		required, err := approvalRequired(bill, closeSignal.ApprovalPolicy)
+		if err != nil {
+			logger.Error("Error checking close approval", "Error", err)
+			bill.Status = domain.BillOpen
+			return fmt.Errorf("Error closing bill: %v", err)
 		}
-		activityOptions := workflow.ActivityOptions{
-			StartToCloseTimeout: time.Minute,
-			RetryPolicy:         retryPolicy,
+		if required {
+			requestCloseApproval(ctx, mu, bill, closeSignal.RequestID, closeSignal.ActorID, totalBefore, *closeSignal.ApprovalPolicy, closeSignal.Paid, closeDecidedChan, logger)
+			return nil
 		}
 
-		ctx = workflow.WithActivityOptions(ctx, activityOptions)
+		// Record the closing so it is stored along with the closed bill
+		bill.Record(domain.EventBillClosed, closeSignal.ActorID, closeSignal.RequestID, nil, totalBefore, workflow.Now(ctx))
 
-		// Initiate the activity to move the bill to a closed_bills database table
-		err = workflow.ExecuteActivity(ctx, AddClosedBillToDB, bill, closeSignal.RequestID).Get(ctx, nil)
-		if err != nil {
-			var appErr *temporal.ApplicationError
-			// Check the type of error to determine action
-			if errors.As(err, &appErr) {
-				if appErr.Type() == "DuplicateRequestError" {
-					// Ignore request and exit if it is a duplicate request
-					logger.Warn("Duplicate close request detected, ignoring", "RequestID", closeSignal.RequestID)
-					return fmt.Errorf("duplicate close request ignored")
-				} else if appErr.Type() == "UserInputError" {
-					// Cancel request if error is due to user input
-					// Set the bill status back to open
-					logger.Error("Invalid input, rejecting close request", "Error", appErr)
-					bill.Status = domain.BillOpen
-					return err
-				} else if appErr.Type() == "InvalidRequestError" {
-					// Cancel request if error is due to invalid request
-					// Set the bill status back to open
-					logger.Error("Invalid request, rejecting close request", "Error", appErr)
-					bill.Status = domain.BillOpen
-					return err
-				}
-			}
-			// If error is still present after the retry policy and is not of the above type:
-			logger.Error("Error executing AddClosedBillToDB activity", "Error", err)
+		// Move the bill to the closed_bills table
+		if err := storeClosedBill(ctx, bill, closeSignal.RequestID, closeSignal.Paid, logger); err != nil {
 			return err
 		}
 
@@ -232,17 +406,25 @@ func HandleCloseBillSignal(ctx workflow.Context, mu workflow.Mutex, c workflow.R
 }
 
 // Handler function for closing bill through an update call.
-func HandleCloseBillUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain.Bill, logger log.Logger) error {
+func HandleCloseBillUpdate(ctx workflow.Context, mu workflow.Mutex, closeDecidedChan workflow.Channel, bill *domain.Bill, logger log.Logger) error {
 	// Set up a handler function to process CloseBillUpdate events
-	err := workflow.SetUpdateHandler(ctx, "CloseBillUpdate", func(ctx workflow.Context, requestID string) (*domain.Bill, error) {
+	err := workflow.SetUpdateHandler(ctx, "CloseBillUpdate", func(ctx workflow.Context, requestID string, profile *domain.BillingProfile, actorID string, policy *ApprovalPolicy, expectedRevision int, paid bool) (*domain.Bill, error) {
 		// Check that bill is not already closed
 		if bill.Status == domain.BillClosed {
 			logger.Warn("Received close bill update, but bill is already closed", "BillID", bill.ID)
-			return nil, fmt.Errorf("Bill already closed")
+			return nil, billerr.New(billerr.ErrBillClosed, "Bill already closed", nil)
 			// Check that bill is not in the middle of closing
 		} else if bill.Status == domain.BillClosing {
 			logger.Warn("Received close bill update, but bill is currently closing", "BillID", bill.ID)
-			return nil, fmt.Errorf("Bill is in the middle of closing")
+			return nil, billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil)
+			// Check that bill was not cancelled
+		} else if bill.Status == domain.BillCancelled {
+			logger.Warn("Received close bill update, but bill is cancelled", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrBillCancelled, "Bill is cancelled", nil)
+			// Check that bill is not already awaiting approval of its closing
+		} else if bill.Status == domain.BillAwaitingApproval {
+			logger.Warn("Received close bill update, but bill is awaiting close approval", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrAwaitingApproval, "Bill is awaiting close approval", nil)
 		}
 
 		// Use mutex locking for safe concurrency
@@ -252,17 +434,89 @@ func HandleCloseBillUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain
 		}
 		defer mu.Unlock()
 
+		// Close requests made against an earlier revision would close items their sender has not seen
+		if err := bill.CheckRevision(expectedRevision); err != nil {
+			logger.Warn("Received close bill update for a stale revision", "BillID", bill.ID, "Error", err)
+			return nil, billerr.New(billerr.ErrStaleRevision, "Bill was changed since it was read", err)
+		}
+
 		// Inititate bill closing status
 		bill.Status = domain.BillClosing
 		bill.UpdatedAt = time.Now()
 
+		// Snapshot the billing profile the bill is closed with
+		bill.BillingProfile = profile
+
 		// Calculate bill total or throw an error in case of failure
+		totalBefore := bill.Total
 		if err := bill.CalculateTotal(); err != nil {
 			logger.Error("Error calculating bill total", "Error", err)
 			bill.Status = domain.BillOpen
-			return nil, fmt.Errorf("Error closing bill: %v", err)
+			return nil, billerr.New(billerr.ErrInvalidRequest, "Error closing bill", err)
+		}
+
+		// Bills above the approval limit are only closed once a second approver accepts
+		required, err := approvalRequired(bill, policy)
+		if err != nil {
+			logger.Error("Error checking close approval", "Error", err)
+			bill.Status = domain.BillOpen
+			return nil, billerr.New(billerr.ErrInvalidRequest, "Error closing bill", err)
+		}
+		if required {
+			requestCloseApproval(ctx, mu, bill, requestID, actorID, totalBefore, *policy, paid, closeDecidedChan, logger)
+			return bill, nil
+		}
+
+		// Record the closing so it is stored along with the closed bill
+		bill.Record(domain.EventBillClosed, actorID, requestID, nil, totalBefore, workflow.Now(ctx))
+
+		// Move the bill to the closed_bills table
+		if err := storeClosedBill(ctx, bill, requestID, paid, logger); err != nil {
+			return nil, err
 		}
 
+		// Finish the action of closing bill
+		bill.Status = domain.BillClosed
+		logger.Info("Bill successfully saved as closed in DB", "BillID", bill.ID)
+		return bill, nil
+	})
+
+	return err
+}
+
+// Handler function for cancelling bill through an update call.
+func HandleCancelBillUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain.Bill, logger log.Logger) error {
+	// Set up a handler function to process CancelBillUpdate events
+	err := workflow.SetUpdateHandler(ctx, "CancelBillUpdate", func(ctx workflow.Context, requestID string, reason string, actorID string) (*domain.Bill, error) {
+		// Only open bills can be cancelled
+		switch bill.Status {
+		case domain.BillClosed:
+			logger.Warn("Received cancel bill update, but bill is already closed", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrBillClosed, "Bill already closed", nil)
+		case domain.BillClosing:
+			logger.Warn("Received cancel bill update, but bill is currently closing", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrBillClosing, "Bill is in the middle of closing", nil)
+		case domain.BillCancelled:
+			logger.Warn("Received cancel bill update, but bill is already cancelled", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrBillCancelled, "Bill already cancelled", nil)
+		case domain.BillAwaitingApproval:
+			logger.Warn("Received cancel bill update, but bill is awaiting close approval", "BillID", bill.ID)
+			return nil, billerr.New(billerr.ErrAwaitingApproval, "Bill is awaiting close approval", nil)
+		}
+
+		// Use mutex locking for safe concurrency
+		err := mu.Lock(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error locking mutex: %v", err)
+		}
+		defer mu.Unlock()
+
+		// Void the bill, keeping its items and totals for the record
+		if err := bill.Cancel(reason, actorID, workflow.Now(ctx)); err != nil {
+			return nil, billerr.New(billerr.ErrInvalidRequest, "Error cancelling bill", err)
+		}
+		bill.Record(domain.EventBillCancelled, actorID, requestID, nil, bill.Total, workflow.Now(ctx))
+
 		// Set retry policy for transient failures (e.g., network issues)
 		retryPolicy := &temporal.RetryPolicy{
 			InitialInterval:    time.Second * 2,
@@ -277,44 +531,204 @@ func HandleCloseBillUpdate(ctx workflow.Context, mu workflow.Mutex, bill *domain
 
 		ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
-		// Initiate the activity to move the bill to a closed_bills database table
-		err = workflow.ExecuteActivity(ctx, AddClosedBillToDB, bill, requestID).Get(ctx, nil)
+		// Initiate the activity to move the bill to the closed_bills table as cancelled
+		err = workflow.ExecuteActivity(ctx, AddCancelledBillToDB, bill, requestID).Get(ctx, nil)
 		if err != nil {
-			var appErr *temporal.ApplicationError
-			// Check the type of error to determine action
-			if errors.As(err, &appErr) {
-				if appErr.Type() == "DuplicateRequestError" {
-					// Ignore request and exit if it is a duplicate request
-					logger.Warn("Duplicate close request detected, ignoring", "RequestID", requestID)
-					return nil, fmt.Errorf("duplicate close request ignored")
-				} else if appErr.Type() == "UserInputError" {
-					// Cancel request if error is due to user input
-					// Set the bill status back to open
-					logger.Error("Invalid input, rejecting close request", "Error", appErr)
-					bill.Status = domain.BillOpen
-					return nil, err
-				} else if appErr.Type() == "InvalidRequestError" {
-					// Cancel request if error is due to invalid request
-					// Set the bill status back to open
-					logger.Error("Invalid request, rejecting close request", "Error", appErr)
-					bill.Status = domain.BillOpen
-					return nil, err
-				}
+			// Reopen the bill, since it was not recorded as cancelled
+			bill.Status = domain.BillOpen
+			bill.Cancellation = nil
+			bill.Unrecord(domain.EventBillCancelled)
+
+			switch kind := billerr.Kind(err); kind {
+			case billerr.ErrDuplicateRequest:
+				logger.Warn("Duplicate cancel request detected, ignoring", "RequestID", requestID)
+				return nil, billerr.New(kind, "duplicate cancel request ignored", err)
+			case billerr.ErrUserInput, billerr.ErrInvalidRequest:
+				logger.Error("Rejecting cancel request", "Error", err)
+				return nil, billerr.New(kind, "cancel request rejected", err)
 			}
-			// If error is still present after the retry policy and is not of the above type:
-			logger.Error("Error executing AddClosedBillToDB activity", "Error", err)
+			logger.Error("Error executing AddCancelledBillToDB activity", "Error", err)
 			return nil, err
 		}
 
-		// Finish the action of closing bill
-		bill.Status = domain.BillClosed
-		logger.Info("Bill successfully saved as closed in DB", "BillID", bill.ID)
+		logger.Info("Bill successfully saved as cancelled in DB", "BillID", bill.ID)
 		return bill, nil
 	})
 
 	return err
 }
 
+// Move a bill that is being closed to the closed_bills table, as paid if its payment was taken along with the close.
+// Requests rejected because of their input reopen the bill, as it was before the close.
+func storeClosedBill(ctx workflow.Context, bill *domain.Bill, requestID string, paid bool, logger log.Logger) error {
+	// Set retry policy for transient failures (e.g., network issues)
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second * 2,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	activityOptions := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	// Closed bills fall due after the payment terms they were created with
+	bill.SetDueDate(workflow.Now(ctx))
+	if paid {
+		bill.MarkPaid()
+	}
+
+	// Initiate the activity to move the bill to a closed_bills database table
+	err := workflow.ExecuteActivity(ctx, AddClosedBillToDB, bill, requestID).Get(ctx, nil)
+	if err != nil {
+		// Check the kind of error to determine action
+		switch kind := billerr.Kind(err); kind {
+		case billerr.ErrDuplicateRequest:
+			// Replays of the same close are stored without error, so a unique violation, such as a
+			// request ID already used to close another bill, means the bill was not closed
+			logger.Warn("Duplicate close request detected, reopening bill", "RequestID", requestID, "Error", err)
+			bill.Status = domain.BillOpen
+			bill.ClearDueDate()
+			bill.Unrecord(domain.EventBillClosed)
+			return billerr.New(kind, "duplicate close request rejected", err)
+		case billerr.ErrUserInput, billerr.ErrInvalidRequest:
+			// Cancel request if error is due to user input or an invalid request
+			// Set the bill status back to open
+			logger.Error("Rejecting close request", "Error", err)
+			bill.Status = domain.BillOpen
+			bill.ClearDueDate()
+			bill.Unrecord(domain.EventBillClosed)
+			return billerr.New(kind, "close request rejected", err)
+		}
+		// If error is still present after the retry policy and is not of the above kind:
+		logger.Error("Error executing AddClosedBillToDB activity", "Error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Check whether closing a bill needs a second approver under the given policy.
+// Bills are closed without approval when no policy applies.
+func approvalRequired(bill *domain.Bill, policy *ApprovalPolicy) (bool, error) {
+	if policy == nil {
+		return false, nil
+	}
+	return bill.RequiresApproval(policy.Limit)
+}
+
+// Put a bill that is being closed on hold until a second approver accepts or rejects its closing.
+// The decision is awaited in the background, so the close request returns right away.
+// Once decided, the bill is either closed or reopened, and the main loop is woken through closeDecidedChan.
+// Approved bills that cannot be stored as closed are reopened too.
+func requestCloseApproval(
+	ctx workflow.Context,
+	mu workflow.Mutex,
+	bill *domain.Bill,
+	requestID string,
+	actorID string,
+	totalBefore domain.Money,
+	policy ApprovalPolicy,
+	paid bool,
+	closeDecidedChan workflow.Channel,
+	logger log.Logger,
+) {
+	now := workflow.Now(ctx)
+	bill.Status = domain.BillAwaitingApproval
+	bill.Approval = &domain.CloseApproval{
+		RequestID:   requestID,
+		RequestedBy: actorID,
+		RequestedAt: now,
+		Limit:       policy.Limit,
+		Deadline:    now.Add(policy.Timeout),
+	}
+	bill.Record(domain.EventCloseApprovalRequested, actorID, requestID, nil, totalBefore, now)
+	logger.Info("Bill awaiting close approval", "BillID", bill.ID, "RequestID", requestID)
+
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		decision := awaitCloseApproval(ctx, requestID, actorID, policy.Timeout, logger)
+
+		if err := mu.Lock(ctx); err != nil {
+			logger.Error("Error locking mutex", "Error", err)
+			return
+		}
+
+		now := workflow.Now(ctx)
+		bill.Approval.DecidedAt = now
+		if decision != nil {
+			bill.Approval.DecidedBy = decision.ApproverID
+			bill.Approval.Comment = decision.Comment
+		}
+
+		if decision == nil || !decision.Approved {
+			// Rejected or timed out, the bill accepts changes again
+			logger.Info("Close request rejected", "BillID", bill.ID, "RequestID", requestID, "TimedOut", decision == nil)
+			bill.Status = domain.BillOpen
+			bill.Record(domain.EventCloseRejected, bill.Approval.DecidedBy, requestID, nil, bill.Total, now)
+		} else {
+			bill.Approval.Approved = true
+			bill.Record(domain.EventCloseApproved, decision.ApproverID, requestID, nil, bill.Total, now)
+
+			bill.Status = domain.BillClosing
+			bill.Record(domain.EventBillClosed, actorID, requestID, nil, bill.Total, now)
+			if err := storeClosedBill(ctx, bill, requestID, paid, logger); err != nil {
+				// The close request already returned, so rather than leave the bill closing for good,
+				// reopen it and record the failure for the requester to close it again
+				logger.Error("Error closing approved bill, reopening it", "BillID", bill.ID, "Error", err)
+				bill.Status = domain.BillOpen
+				bill.ClearDueDate()
+				bill.Unrecord(domain.EventBillClosed)
+				bill.Record(domain.EventCloseFailed, "", requestID, nil, bill.Total, workflow.Now(ctx))
+			} else {
+				bill.Status = domain.BillClosed
+				logger.Info("Bill successfully saved as closed in DB", "BillID", bill.ID)
+			}
+		}
+
+		mu.Unlock()
+		closeDecidedChan.Send(ctx, requestID)
+	})
+}
+
+// Wait for the decision about a close request until the timeout, returning nil if none arrives.
+// Decisions about other close requests and decisions by the requester of the close are ignored.
+func awaitCloseApproval(ctx workflow.Context, requestID string, requesterID string, timeout time.Duration, logger log.Logger) *CloseApprovalSignal {
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+
+	approvalChan := workflow.GetSignalChannel(ctx, CloseApprovalRoute.Name)
+	timer := workflow.NewTimer(timerCtx, timeout)
+
+	var decision *CloseApprovalSignal
+	timedOut := false
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(approvalChan, func(c workflow.ReceiveChannel, _ bool) {
+		var signal CloseApprovalSignal
+		c.Receive(ctx, &signal)
+
+		switch {
+		case signal.RequestID != requestID:
+			logger.Warn("Ignoring decision about another close request", "RequestID", signal.RequestID)
+		case signal.ApproverID == requesterID:
+			logger.Warn("Ignoring decision by the requester of the close", "ApproverID", signal.ApproverID)
+		default:
+			decision = &signal
+		}
+	})
+	selector.AddFuture(timer, func(f workflow.Future) {
+		timedOut = true
+	})
+
+	for decision == nil && !timedOut {
+		selector.Select(ctx)
+	}
+	return decision
+}
+
 // Handler function for closing a workflow through signal call.
 func HandleCloseWorkflowSignal(ctx workflow.Context, mu workflow.Mutex, c workflow.ReceiveChannel, bill *domain.Bill, logger log.Logger) error {
 	// Unpack the signal contents
@@ -328,8 +742,10 @@ func HandleCloseWorkflowSignal(ctx workflow.Context, mu workflow.Mutex, c workfl
 	}
 	defer mu.Unlock()
 
-	// Change bill status to closed to finish workflow
+	// Change bill status to closed to finish workflow, keeping the status of cancelled bills
 	logger.Info("Received CloseWorkflow signal, finishing workflows.", "BillID", bill.ID)
-	bill.Status = domain.BillClosed
+	if !bill.IsFinal() {
+		bill.Status = domain.BillClosed
+	}
 	return nil
 }
@@ -4,8 +4,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/mock"
+	"github.com/vvvakho/feezy/billing/billerr"
 	"github.com/vvvakho/feezy/billing/service/domain"
+	"go.temporal.io/sdk/testsuite"
 )
 
 func (s *UnitTestSuite) Test_AddLineItem() {
@@ -501,3 +504,864 @@ func (s *UnitTestSuite) Test_RemoveLineItemComplete() {
 	s.mockActivities.AssertCalled(s.T(), "AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything)
 	s.mockActivities.AssertCalled(s.T(), "AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything)
 }
+
+func (s *UnitTestSuite) Test_CloseBillSnapshotsBillingProfile() {
+	// Initialize a new bill
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total: domain.Money{
+			Amount:   0,
+			Currency: "USD",
+		},
+		Items: []domain.Item{},
+	}
+
+	profile := &domain.BillingProfile{
+		Name:    "Acme LLC",
+		Address: domain.Address{City: "Tbilisi", Country: "GE"},
+		TaxID:   "404000000",
+		Locale:  "ka-GE",
+	}
+
+	// Only accept a closed bill carrying the billing profile it was closed with
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.BillingProfile != nil && *b.BillingProfile == *profile
+	}), mock.Anything).Return(nil)
+
+	// Send signal to close the bill with a billing profile
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{
+			Route:          "CloseBillRoute",
+			RequestID:      uuid.NewString(),
+			BillingProfile: profile,
+		})
+	}, time.Millisecond*1)
+
+	// Execute the workflow
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	// Ensure workflow completed successfully
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(profile, result.BillingProfile)
+}
+
+func (s *UnitTestSuite) Test_CloseBillKeepsMetadata() {
+	// Initialize a new bill with the customer's own reference and metadata
+	bill := &domain.Bill{
+		ID:          uuid.New(),
+		Status:      domain.BillOpen,
+		Total:       domain.Money{Currency: "USD"},
+		Items:       []domain.Item{},
+		ExternalRef: "PO-1001",
+		Metadata:    map[string]string{"cost_center": "CC-42", "note": "Annual renewal"},
+	}
+
+	// Both the open and the closed bill are stored with their reference and metadata
+	carriesMetadata := mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.ExternalRef == bill.ExternalRef && b.Metadata["cost_center"] == "CC-42"
+	})
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, carriesMetadata, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, carriesMetadata, mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{
+			Route:     "CloseBillRoute",
+			RequestID: uuid.NewString(),
+		})
+	}, time.Millisecond*1)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(bill.ExternalRef, result.ExternalRef)
+	s.Equal(bill.Metadata, result.Metadata)
+}
+
+func (s *UnitTestSuite) Test_CloseBillUpdateRejectsInvalidInput() {
+	// Initialize a new bill
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total: domain.Money{
+			Amount:   0,
+			Currency: "USD",
+		},
+		Items: []domain.Item{},
+	}
+
+	// Fail the first close attempt with a check constraint violation, then accept the bill
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything).
+		Return(billerr.FromPostgres("Error inserting/updating db", &pq.Error{Code: "23514"})).Once()
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Close the bill through an update, which must report the kind of failure
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrUserInput, billerr.Kind(err))
+			},
+		}, uuid.NewString(), &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 0, false)
+	}, time.Millisecond*1)
+
+	// The bill is open again and can still be closed
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		err = res.Get(&queriedBill)
+		s.NoError(err)
+		s.Equal(domain.BillOpen, queriedBill.Status)
+		s.Empty(queriedBill.Events) // the rejected closing is not part of the history
+
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{
+			Route:     "CloseBillRoute",
+			RequestID: uuid.NewString(),
+		})
+	}, time.Millisecond*5)
+
+	// Execute the workflow
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	// Ensure workflow completed successfully
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.mockActivities.AssertNumberOfCalls(s.T(), "AddClosedBillToDB", 2)
+}
+
+func (s *UnitTestSuite) Test_CloseBillUpdateRejectsReusedRequestID() {
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total:  domain.Money{Amount: 0, Currency: "USD"},
+		Items:  []domain.Item{},
+	}
+
+	// The request ID was already used to close another bill of the tenant
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything).
+		Return(billerr.FromPostgres("Error inserting/updating db", &pq.Error{Code: "23505"})).Once()
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrDuplicateRequest, billerr.Kind(err))
+			},
+		}, uuid.NewString(), &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 0, false)
+	}, time.Millisecond*1)
+
+	// The bill is open again, still takes items and can be closed under another request
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+		s.Equal(domain.BillOpen, queriedBill.Status)
+		s.Nil(queriedBill.DueAt)
+		s.Empty(queriedBill.Events)
+
+		item := domain.Item{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 10, Currency: "USD"}, Quantity: 1}
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: uuid.NewString()})
+	}, time.Millisecond*5)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{
+			Route:     "CloseBillRoute",
+			RequestID: uuid.NewString(),
+		})
+	}, time.Millisecond*10)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.mockActivities.AssertNumberOfCalls(s.T(), "AddClosedBillToDB", 2)
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(domain.BillClosed, result.Status)
+	s.Len(result.Items, 1)
+}
+
+func (s *UnitTestSuite) Test_CancelBillUpdate() {
+	// Initialize a new bill
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total: domain.Money{
+			Amount:   0,
+			Currency: "USD",
+		},
+		Items: []domain.Item{},
+	}
+
+	actorID := uuid.NewString()
+
+	// Only accept a bill carrying the cancellation it was voided with
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddCancelledBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.Status == domain.BillCancelled && b.Cancellation != nil &&
+			b.Cancellation.Reason == "Created by mistake" && b.Cancellation.ActorID == actorID
+	}), mock.Anything).Return(nil)
+
+	// Add an item, then cancel the bill through an update
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{
+			LineItem: domain.Item{
+				ID:           uuid.New(),
+				PricePerUnit: domain.Money{Amount: 50, Currency: "USD"},
+				Quantity:     1,
+			},
+		})
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CancelBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+			},
+		}, uuid.NewString(), "Created by mistake", actorID)
+	}, time.Millisecond*5)
+
+	// A cancelled bill can no longer be closed
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrBillCancelled, billerr.Kind(err))
+			},
+		}, uuid.NewString(), &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 0, false)
+	}, time.Millisecond*10)
+
+	// Finish the workflow, as the API does after a cancellation
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{
+			RequestID: uuid.NewString(),
+		})
+	}, time.Millisecond*15)
+
+	// Execute the workflow
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	// Ensure workflow completed successfully, keeping the cancelled status and items
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(domain.BillCancelled, result.Status)
+	s.Equal(1, len(result.Items))
+	s.Equal(domain.MinorUnit(50), result.Total.Amount)
+	s.mockActivities.AssertNotCalled(s.T(), "AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *UnitTestSuite) Test_BillEventsRecorded() {
+	// Initialize a new bill
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total: domain.Money{
+			Amount:   0,
+			Currency: "USD",
+		},
+		Items: []domain.Item{},
+	}
+	item := domain.Item{
+		ID:           uuid.New(),
+		PricePerUnit: domain.Money{Amount: 50, Currency: "USD"},
+		Quantity:     3,
+	}
+	actorID := uuid.NewString()
+	closeRequestID := uuid.NewString()
+
+	// The recorded history is handed to the activity storing the closed bill
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return len(b.Events) == 3 && b.Events[2].Type == domain.EventBillClosed
+	}), closeRequestID).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: "add-1", ActorID: actorID})
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		removed := item
+		removed.Quantity = 1
+		s.env.SignalWorkflow(RemoveLineItemRoute.Name, RemoveItemSignal{LineItem: removed, RequestID: "remove-1", ActorID: actorID})
+	}, time.Millisecond*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+		}, closeRequestID, &domain.BillingProfile{}, actorID, &ApprovalPolicy{}, 0, false)
+	}, time.Millisecond*3)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{RequestID: closeRequestID})
+	}, time.Millisecond*4)
+
+	// Execute the workflow
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Require().Len(result.Events, 3)
+
+	added, removed, closed := result.Events[0], result.Events[1], result.Events[2]
+	s.Equal(domain.EventItemAdded, added.Type)
+	s.Equal("add-1", added.RequestID)
+	s.Equal(domain.MinorUnit(0), added.TotalBefore.Amount)
+	s.Equal(domain.MinorUnit(150), added.TotalAfter.Amount)
+
+	s.Equal(domain.EventItemRemoved, removed.Type)
+	s.Equal(int64(1), removed.Item.Quantity)
+	s.Equal(domain.MinorUnit(150), removed.TotalBefore.Amount)
+	s.Equal(domain.MinorUnit(100), removed.TotalAfter.Amount)
+
+	s.Equal(domain.EventBillClosed, closed.Type)
+	s.Equal(closeRequestID, closed.RequestID)
+	s.Equal(3, closed.Sequence)
+	for _, event := range result.Events {
+		s.Equal(actorID, event.ActorID)
+	}
+}
+
+func (s *UnitTestSuite) Test_AddLineItemCreditLimit() {
+	// Bill capped at 1000 USD, warning at 80% of the limit
+	bill := &domain.Bill{
+		ID:          uuid.New(),
+		Status:      domain.BillOpen,
+		Total:       domain.Money{Amount: 0, Currency: "USD"},
+		Items:       []domain.Item{},
+		CreditLimit: &domain.CreditLimit{Limit: domain.Money{Amount: 1000, Currency: "USD"}, WarnAt: []int{80}},
+	}
+	actorID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		item := domain.Item{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 8}
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: "add-1", ActorID: actorID})
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		// Would take the total to 1300 USD
+		item := domain.Item{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 500, Currency: "USD"}, Quantity: 1}
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: "add-2", ActorID: actorID})
+	}, time.Millisecond*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+
+		// The rejected addition leaves the bill unchanged
+		s.Len(queriedBill.Items, 1)
+		s.Equal(domain.MinorUnit(800), queriedBill.Total.Amount)
+
+		s.Require().Len(queriedBill.Events, 3)
+		added, warning, rejected := queriedBill.Events[0], queriedBill.Events[1], queriedBill.Events[2]
+		s.Equal(domain.EventItemAdded, added.Type)
+		s.Equal(domain.EventCreditLimitWarning, warning.Type)
+		s.Equal(80, warning.Threshold)
+		s.Equal("add-1", warning.RequestID)
+		s.Equal(domain.EventCreditLimitExceeded, rejected.Type)
+		s.Equal("add-2", rejected.RequestID)
+		s.Equal(domain.MinorUnit(800), rejected.TotalAfter.Amount)
+
+		s.env.CancelWorkflow()
+	}, time.Millisecond*3)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+// Open bill whose total of 2000 USD exceeds the approval limit of the given policy.
+func newBillAboveApprovalLimit() (*domain.Bill, *ApprovalPolicy) {
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total:  domain.Money{Amount: 0, Currency: "USD"},
+		Items: []domain.Item{{
+			ID:           uuid.New(),
+			PricePerUnit: domain.Money{Amount: 1000, Currency: "USD"},
+			Quantity:     2,
+		}},
+	}
+	policy := &ApprovalPolicy{Limit: domain.Money{Amount: 1000, Currency: "USD"}, Timeout: time.Hour}
+	return bill, policy
+}
+
+func (s *UnitTestSuite) Test_CloseBillUpdateAwaitsApproval() {
+	bill, policy := newBillAboveApprovalLimit()
+	requesterID := uuid.NewString()
+	approverID := uuid.NewString()
+	requestID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, requestID).Return(nil).Once()
+
+	// The close request returns right away, leaving the bill awaiting approval
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal(domain.BillAwaitingApproval, result.(*domain.Bill).Status)
+			},
+		}, requestID, &domain.BillingProfile{}, requesterID, policy, 0, false)
+	}, time.Millisecond*1)
+
+	// Changes and further close requests are refused while the approval is pending
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrAwaitingApproval, billerr.Kind(err))
+			},
+		}, uuid.NewString(), &domain.BillingProfile{}, requesterID, policy, 0, false)
+	}, time.Minute)
+
+	// A second approver accepts the request, which closes the bill
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseApprovalRoute.Name, CloseApprovalSignal{RequestID: requestID, Approved: true, ApproverID: approverID})
+	}, time.Minute*10)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(domain.BillClosed, result.Status)
+	s.True(result.Approval.Approved)
+	s.Equal(approverID, result.Approval.DecidedBy)
+
+	var types []domain.EventType
+	for _, event := range result.Events {
+		types = append(types, event.Type)
+	}
+	s.Equal([]domain.EventType{domain.EventCloseApprovalRequested, domain.EventCloseApproved, domain.EventBillClosed}, types)
+}
+
+func (s *UnitTestSuite) Test_SignalsWhileAwaitingApproval() {
+	bill, policy := newBillAboveApprovalLimit()
+	requesterID := uuid.NewString()
+	approverID := uuid.NewString()
+	requestID := uuid.NewString()
+	item := bill.Items[0]
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, requestID).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+		}, requestID, &domain.BillingProfile{}, requesterID, policy, 0, false)
+	}, time.Millisecond*1)
+
+	// Item changes and close requests signaled while the approval is pending are rejected
+	s.env.RegisterDelayedCallback(func() {
+		added := domain.Item{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 10, Currency: "USD"}, Quantity: 1}
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: added, RequestID: uuid.NewString()})
+		s.env.SignalWorkflow(RemoveLineItemRoute.Name, RemoveItemSignal{LineItem: item, RequestID: uuid.NewString()})
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{
+			Route:          CloseBillRoute.Name,
+			RequestID:      uuid.NewString(),
+			ActorID:        requesterID,
+			BillingProfile: &domain.BillingProfile{},
+			ApprovalPolicy: policy,
+		})
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+		s.Equal(domain.BillAwaitingApproval, queriedBill.Status)
+		s.Equal(requestID, queriedBill.Approval.RequestID)
+	}, time.Minute*2)
+
+	// The pending request is still the one decided
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseApprovalRoute.Name, CloseApprovalSignal{RequestID: requestID, Approved: true, ApproverID: approverID})
+	}, time.Minute*10)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result domain.Bill
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(domain.BillClosed, result.Status)
+	s.Equal([]domain.Item{item}, result.Items)
+
+	var types []domain.EventType
+	for _, event := range result.Events {
+		types = append(types, event.Type)
+	}
+	s.Equal([]domain.EventType{domain.EventCloseApprovalRequested, domain.EventCloseApproved, domain.EventBillClosed}, types)
+}
+
+func (s *UnitTestSuite) Test_CloseBillApprovalTimesOut() {
+	bill, policy := newBillAboveApprovalLimit()
+	requesterID := uuid.NewString()
+	requestID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+		}, requestID, &domain.BillingProfile{}, requesterID, policy, 0, false)
+	}, time.Millisecond*1)
+
+	// The requester cannot approve their own close request
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseApprovalRoute.Name, CloseApprovalSignal{RequestID: requestID, Approved: true, ApproverID: requesterID})
+	}, time.Minute*10)
+
+	// Once the approval timed out, the bill is open again
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+		s.Equal(domain.BillOpen, queriedBill.Status)
+		s.False(queriedBill.Approval.Approved)
+		s.Empty(queriedBill.Approval.DecidedBy)
+		s.Equal(domain.EventCloseRejected, queriedBill.Events[len(queriedBill.Events)-1].Type)
+
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{RequestID: uuid.NewString()})
+	}, time.Hour*2)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.mockActivities.AssertNotCalled(s.T(), "AddClosedBillToDB", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *UnitTestSuite) Test_CloseBillApprovedButNotStored() {
+	bill, policy := newBillAboveApprovalLimit()
+	requesterID := uuid.NewString()
+	requestID := uuid.NewString()
+
+	// Storing the approved bill keeps failing until its retries run out
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.Anything, requestID).
+		Return(billerr.New(billerr.ErrTransient, "Error inserting/updating db", nil))
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+		}, requestID, &domain.BillingProfile{}, requesterID, policy, 0, false)
+	}, time.Millisecond*1)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseApprovalRoute.Name, CloseApprovalSignal{RequestID: requestID, Approved: true, ApproverID: uuid.NewString()})
+	}, time.Minute*10)
+
+	// The bill is reopened rather than left closing, with the failure in its history
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+		s.Equal(domain.BillOpen, queriedBill.Status)
+		s.Nil(queriedBill.DueAt)
+
+		var types []domain.EventType
+		for _, event := range queriedBill.Events {
+			types = append(types, event.Type)
+		}
+		s.Equal([]domain.EventType{domain.EventCloseApprovalRequested, domain.EventCloseApproved, domain.EventCloseFailed}, types)
+
+		s.env.SignalWorkflow(CloseWorkflowRoute.Name, CloseWorkflowSignal{RequestID: uuid.NewString()})
+	}, time.Hour*2)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *UnitTestSuite) Test_AddLineItemsUpdate() {
+	// Bill capped at 1000 USD
+	bill := &domain.Bill{
+		ID:          uuid.New(),
+		Status:      domain.BillOpen,
+		Total:       domain.Money{Amount: 0, Currency: "USD"},
+		Items:       []domain.Item{},
+		CreditLimit: &domain.CreditLimit{Limit: domain.Money{Amount: 1000, Currency: "USD"}},
+	}
+	actorID := uuid.NewString()
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	batch := []domain.Item{
+		{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 2},
+		{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 50, Currency: "USD"}, Quantity: 4},
+	}
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("AddLineItemsUpdate", "batch-1", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+			},
+		}, "batch-1", batch, actorID, 0)
+	}, time.Millisecond*1)
+
+	// The second item would take the total over the limit, so neither is added
+	overLimit := []domain.Item{
+		{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 1},
+		{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 600, Currency: "USD"}, Quantity: 1},
+	}
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("AddLineItemsUpdate", "batch-2", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrUserInput, billerr.Kind(err))
+			},
+		}, "batch-2", overLimit, actorID, 0)
+	}, time.Millisecond*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+
+		s.Len(queriedBill.Items, 2)
+		s.Equal(domain.MinorUnit(400), queriedBill.Total.Amount)
+
+		// Each item of the batch is recorded under the request, with the running total
+		s.Require().Len(queriedBill.Events, 2)
+		s.Equal("batch-1", queriedBill.Events[1].RequestID)
+		s.Equal(domain.MinorUnit(200), queriedBill.Events[1].TotalBefore.Amount)
+		s.Equal(domain.MinorUnit(400), queriedBill.Events[1].TotalAfter.Amount)
+
+		s.env.CancelWorkflow()
+	}, time.Millisecond*3)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *UnitTestSuite) Test_UpdateLineItemUpdate() {
+	itemID := uuid.New()
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total:  domain.Money{Amount: 200, Currency: "USD"},
+		Items: []domain.Item{
+			{ID: itemID, PricePerUnit: domain.Money{Amount: 100, Currency: "USD"}, Quantity: 2, Description: "Seat", Version: 1},
+		},
+	}
+	actorID := uuid.NewString()
+	quantity := int64(3)
+	price := domain.Money{Amount: 150, Currency: "USD"}
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("UpdateLineItemUpdate", "update-1", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+			},
+		}, "update-1", domain.ItemUpdate{ID: itemID, Version: 1, Quantity: &quantity}, actorID, 0)
+	}, time.Millisecond*1)
+
+	// Made against the version the first update replaced
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("UpdateLineItemUpdate", "update-2", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrVersionConflict, billerr.Kind(err))
+			},
+		}, "update-2", domain.ItemUpdate{ID: itemID, Version: 1, PricePerUnit: &price}, actorID, 0)
+	}, time.Millisecond*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("UpdateLineItemUpdate", "update-3", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrNotFound, billerr.Kind(err))
+			},
+		}, "update-3", domain.ItemUpdate{ID: uuid.New(), Version: 1, Quantity: &quantity}, actorID, 0)
+	}, time.Millisecond*3)
+
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+
+		s.Require().Len(queriedBill.Items, 1)
+		s.Equal(int64(3), queriedBill.Items[0].Quantity)
+		s.Equal("Seat", queriedBill.Items[0].Description)
+		s.Equal(2, queriedBill.Items[0].Version)
+		s.Equal(domain.MinorUnit(300), queriedBill.Total.Amount)
+
+		// Only the accepted update is recorded, with the item as updated
+		s.Require().Len(queriedBill.Events, 1)
+		s.Equal(domain.EventItemUpdated, queriedBill.Events[0].Type)
+		s.Equal(2, queriedBill.Events[0].Item.Version)
+		s.Equal(domain.MinorUnit(200), queriedBill.Events[0].TotalBefore.Amount)
+
+		s.env.CancelWorkflow()
+	}, time.Millisecond*4)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *UnitTestSuite) Test_StaleRevision() {
+	bill := &domain.Bill{
+		ID:       uuid.New(),
+		Status:   domain.BillOpen,
+		Total:    domain.Money{Amount: 0, Currency: "USD"},
+		Items:    []domain.Item{},
+		Revision: 2,
+	}
+	item := domain.Item{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 50, Currency: "USD"}, Quantity: 1}
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Added against the bill's current revision
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: "add-1", ExpectedRevision: 2})
+	}, time.Millisecond*1)
+
+	// Made against the revision the first addition replaced
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(AddLineItemRoute.Name, AddItemSignal{LineItem: item, RequestID: "add-2", ExpectedRevision: 2})
+		s.env.SignalWorkflow(RemoveLineItemRoute.Name, RemoveItemSignal{LineItem: item, RequestID: "remove-1", ExpectedRevision: 2})
+	}, time.Millisecond*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow("CloseBillUpdate", uuid.NewString(), &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrStaleRevision, billerr.Kind(err))
+			},
+		}, uuid.NewString(), &domain.BillingProfile{}, uuid.NewString(), &ApprovalPolicy{}, 2, false)
+
+		s.env.UpdateWorkflow("AddLineItemsUpdate", "batch-1", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrStaleRevision, billerr.Kind(err))
+			},
+		}, "batch-1", []domain.Item{{ID: uuid.New(), PricePerUnit: domain.Money{Amount: 50, Currency: "USD"}, Quantity: 1}}, uuid.NewString(), 2)
+
+		quantity := int64(5)
+		s.env.UpdateWorkflow("UpdateLineItemUpdate", "update-1", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.Fail("update should not be rejected", err)
+			},
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.Equal(billerr.ErrStaleRevision, billerr.Kind(err))
+			},
+		}, "update-1", domain.ItemUpdate{ID: item.ID, Version: 1, Quantity: &quantity}, uuid.NewString(), 2)
+	}, time.Millisecond*3)
+
+	s.env.RegisterDelayedCallback(func() {
+		res, err := s.env.QueryWorkflow("getBill")
+		s.NoError(err)
+		var queriedBill domain.Bill
+		s.NoError(res.Get(&queriedBill))
+
+		// Only the first addition was applied, and the bill is still open
+		s.Equal(domain.BillOpen, queriedBill.Status)
+		s.Require().Len(queriedBill.Items, 1)
+		s.Equal(int64(1), queriedBill.Items[0].Quantity)
+		s.Equal(3, queriedBill.Revision)
+
+		s.env.CancelWorkflow()
+	}, time.Millisecond*4)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
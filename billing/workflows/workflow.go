@@ -5,11 +5,34 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
+// Prefix of the task queues serving bill workflows, each tenant is served on its own queue.
+var BillTaskQueue = "create-bill-queue"
+
+// TaskQueue returns the task queue serving a tenant's bill workflows.
+func TaskQueue(tenantID string) string {
+	return BillTaskQueue + "-" + tenantID
+}
+
+// LegacyWorkflowID returns the ID of the workflow managing a bill opened before tenants were introduced,
+// which runs on the unprefixed BillTaskQueue and belongs to the default tenant.
+func LegacyWorkflowID(billID string) string {
+	return billID
+}
+
+// WorkflowID returns the ID of the workflow managing a tenant's bill,
+// so that bill workflows of different tenants never collide or resolve to each other.
+func WorkflowID(tenantID string, billID string) string {
+	return tenantID + "/" + billID
+}
+
 // BillWorkflow is a Temporal workflow that represents a stateful, long-running
 // bill instance, beginning at bill creation and armed with signal and update receptors for
 // processing bill events, such as adding or removing items, or querying and closing bill.
@@ -31,8 +54,8 @@ func BillWorkflow(ctx workflow.Context, bill *domain.Bill) (*domain.Bill, error)
 
 	// Start listening for bill events
 	for {
-		// If bill status is closed, immediately finish work
-		if bill.Status == domain.BillClosed {
+		// If bill is closed or cancelled, immediately finish work
+		if bill.IsFinal() {
 			logger.Info("Bill closed, finishing workflows.", "BillID", bill.ID)
 			break
 		}
@@ -41,6 +64,13 @@ func BillWorkflow(ctx workflow.Context, bill *domain.Bill) (*domain.Bill, error)
 		selector.Select(ctx)
 	}
 
+	// Follow up on the payment of bills closed with a due date, unless they were paid on close
+	if bill.Status == domain.BillClosed && bill.DueAt != nil && bill.Collection == domain.CollectionUnpaid {
+		if err := startDunning(ctx, bill); err != nil {
+			logger.Error("Error starting dunning workflow", "BillID", bill.ID, "Error", err)
+		}
+	}
+
 	return bill, nil
 }
 
@@ -48,7 +78,15 @@ func BillWorkflow(ctx workflow.Context, bill *domain.Bill) (*domain.Bill, error)
 func initWorkflow(ctx workflow.Context, bill *domain.Bill) (workflow.Context, workflow.Selector, log.Logger, error) {
 	logger := workflow.GetLogger(ctx)
 
-	bill.CreatedAt = time.Now()
+	// Bills opened before tenants were introduced belong to the default tenant
+	if bill.TenantID == "" {
+		bill.TenantID = conf.DEFAULT_TENANT
+	}
+
+	// Bills keep the time they were created, which predates their workflow for imported bills
+	if bill.CreatedAt.IsZero() {
+		bill.CreatedAt = time.Now()
+	}
 	bill.UpdatedAt = time.Now()
 
 	// Create a mutex for safe concurrency during requests
@@ -64,12 +102,33 @@ func initWorkflow(ctx workflow.Context, bill *domain.Bill) (workflow.Context, wo
 	// Add custom activitiy options to context
 	ctx = workflow.WithActivityOptions(ctx, ao)
 
+	// Channel waking up the workflow once a close request awaiting approval is decided
+	closeDecidedChan := workflow.NewBufferedChannel(ctx, 1)
+
 	// Register the Update handler for closing the bill
-	err := HandleCloseBillUpdate(ctx, mu, bill, logger)
+	err := HandleCloseBillUpdate(ctx, mu, closeDecidedChan, bill, logger)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("Error registering handler for CloseBillUpdate: %v", err)
 	}
 
+	// Register the Update handler for cancelling the bill
+	err = HandleCancelBillUpdate(ctx, mu, bill, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Error registering handler for CancelBillUpdate: %v", err)
+	}
+
+	// Register the Update handler for adding a batch of line items to the bill
+	err = HandleAddLineItemsUpdate(ctx, mu, bill, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Error registering handler for AddLineItemsUpdate: %v", err)
+	}
+
+	// Register the Update handler for changing a line item in place
+	err = HandleUpdateLineItemUpdate(ctx, mu, bill, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Error registering handler for UpdateLineItemUpdate: %v", err)
+	}
+
 	// Set up channels for receiving signals
 	addLineItemChan := workflow.GetSignalChannel(ctx, AddLineItemRoute.Name)
 	removeLineItemChan := workflow.GetSignalChannel(ctx, RemoveLineItemRoute.Name)
@@ -86,9 +145,54 @@ func initWorkflow(ctx workflow.Context, bill *domain.Bill) (workflow.Context, wo
 		removeLineItemChan,
 		closeBillChan,
 		closeWorkflowChan,
+		closeDecidedChan,
 		bill,
 		logger,
 	)
 
 	return ctx, selector, logger, nil
 }
+
+// AmendWorkflowID returns the ID of the workflow amending a tenant's closed bill,
+// so that concurrent amendments of the same bill version are started only once.
+func AmendWorkflowID(tenantID string, billID string) string {
+	return WorkflowID(tenantID, "amend-"+billID)
+}
+
+// AmendBillWorkflow is a short-lived Temporal workflow storing a new version of a closed bill
+// together with the amendment describing the changed items and the issued credit or debit.
+func AmendBillWorkflow(ctx workflow.Context, bill *domain.Bill, amendment *domain.Amendment, requestID string) (*domain.Amendment, error) {
+	logger := workflow.GetLogger(ctx)
+
+	// Set retry policy for transient failures (e.g., network issues)
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second * 2,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	})
+
+	err := workflow.ExecuteActivity(ctx, AddAmendedBillToDB, bill, amendment, requestID).Get(ctx, nil)
+	if err != nil {
+		logger.Error("Error storing amended bill", "BillID", bill.ID, "Error", err)
+		if kind := billerr.Kind(err); kind != nil {
+			return nil, billerr.New(kind, "Error amending bill", err)
+		}
+		return nil, billerr.New(billerr.ErrInternal, "Error amending bill", err)
+	}
+
+	logger.Info("Bill amended", "BillID", *bill.AmendsBillID, "AmendedBillID", bill.ID, "Version", bill.Version)
+
+	// Follow up on the payment of the new version, whose original is no longer dunned
+	if bill.DueAt != nil && bill.PaymentTerms != nil && bill.Collection == domain.CollectionUnpaid {
+		if err := startDunning(ctx, bill); err != nil {
+			logger.Error("Error starting dunning workflow", "BillID", bill.ID, "Error", err)
+		}
+	}
+
+	return amendment, nil
+}
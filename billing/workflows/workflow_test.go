@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/conf"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	"go.temporal.io/sdk/testsuite"
 )
@@ -30,6 +32,54 @@ func (m *MockActivities) AddClosedBillToDB(ctx context.Context, bill *domain.Bil
 	return args.Error(0)
 }
 
+// Mock implementation of AddCancelledBillToDB activity.
+func (m *MockActivities) AddCancelledBillToDB(ctx context.Context, bill *domain.Bill, requestID string) error {
+	args := m.Called(ctx, bill, requestID)
+	return args.Error(0)
+}
+
+// Mock implementation of AddAmendedBillToDB activity.
+func (m *MockActivities) AddAmendedBillToDB(ctx context.Context, bill *domain.Bill, amendment *domain.Amendment, requestID string) error {
+	args := m.Called(ctx, bill, amendment, requestID)
+	return args.Error(0)
+}
+
+// Mock implementation of GetCollectionStatus activity.
+func (m *MockActivities) GetCollectionStatus(ctx context.Context, bill *domain.Bill) (domain.CollectionStatus, error) {
+	args := m.Called(ctx, bill)
+	return args.Get(0).(domain.CollectionStatus), args.Error(1)
+}
+
+// Mock implementation of UpdateCollectionStatus activity.
+func (m *MockActivities) UpdateCollectionStatus(ctx context.Context, bill *domain.Bill, status domain.CollectionStatus) error {
+	args := m.Called(ctx, bill, status)
+	return args.Error(0)
+}
+
+// Mock implementation of AccrueLateFees activity.
+func (m *MockActivities) AccrueLateFees(ctx context.Context, tenantID string, at time.Time) (int, error) {
+	args := m.Called(ctx, tenantID, at)
+	return args.Int(0), args.Error(1)
+}
+
+// Mock implementation of RefreshAnalytics activity.
+func (m *MockActivities) RefreshAnalytics(ctx context.Context, tenantID string, at time.Time) (int, error) {
+	args := m.Called(ctx, tenantID, at)
+	return args.Int(0), args.Error(1)
+}
+
+// Mock implementation of SendPaymentReminder activity.
+func (m *MockActivities) SendPaymentReminder(ctx context.Context, bill *domain.Bill) error {
+	args := m.Called(ctx, bill)
+	return args.Error(0)
+}
+
+// Mock implementation of ChargeBill activity.
+func (m *MockActivities) ChargeBill(ctx context.Context, bill *domain.Bill, requestID string) (bool, error) {
+	args := m.Called(ctx, bill, requestID)
+	return args.Bool(0), args.Error(1)
+}
+
 // UnitTestSuite defines the test suite for workflow tests.
 type UnitTestSuite struct {
 	suite.Suite
@@ -44,6 +94,14 @@ func (s *UnitTestSuite) SetupTest() {
 	s.mockActivities = new(MockActivities)
 	s.env.RegisterActivity(s.mockActivities.AddOpenBillToDB)
 	s.env.RegisterActivity(s.mockActivities.AddClosedBillToDB)
+	s.env.RegisterActivity(s.mockActivities.AddCancelledBillToDB)
+	s.env.RegisterActivity(s.mockActivities.AddAmendedBillToDB)
+	s.env.RegisterActivity(s.mockActivities.GetCollectionStatus)
+	s.env.RegisterActivity(s.mockActivities.UpdateCollectionStatus)
+	s.env.RegisterActivity(s.mockActivities.AccrueLateFees)
+	s.env.RegisterActivity(s.mockActivities.RefreshAnalytics)
+	s.env.RegisterActivity(s.mockActivities.SendPaymentReminder)
+	s.env.RegisterActivity(s.mockActivities.ChargeBill)
 }
 
 // AfterTest asserts that all expectations were met after each test.
@@ -99,3 +157,83 @@ func (s *UnitTestSuite) TestBillWorkflow_Execution() {
 	require.True(s.T(), s.env.IsWorkflowCompleted())
 	require.NoError(s.T(), s.env.GetWorkflowError())
 }
+
+// TestBillWorkflow_LegacyBill tests that bills opened before tenants were introduced are stored under the default tenant.
+func (s *UnitTestSuite) TestBillWorkflow_LegacyBill() {
+	bill := &domain.Bill{
+		ID:     uuid.New(),
+		Status: domain.BillOpen,
+		Total:  domain.Money{Amount: 0, Currency: "USD"},
+	}
+
+	s.mockActivities.On("AddOpenBillToDB", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.mockActivities.On("AddClosedBillToDB", mock.Anything, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.TenantID == conf.DEFAULT_TENANT
+	}), mock.Anything).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(CloseBillRoute.Name, CloseBillSignal{RequestID: uuid.NewString()})
+	}, time.Millisecond*10)
+
+	s.env.ExecuteWorkflow(BillWorkflow, bill)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+}
+
+// newAmendment returns a closed bill amended to a higher quantity of its only item.
+func newAmendment(s *UnitTestSuite) (*domain.Bill, *domain.Amendment) {
+	item := domain.Item{
+		ID:           uuid.New(),
+		Description:  "Consulting",
+		PricePerUnit: domain.Money{Amount: 20, Currency: "USD"},
+		Quantity:     1,
+	}
+	original := &domain.Bill{
+		ID:       uuid.New(),
+		TenantID: "default",
+		UserID:   uuid.New(),
+		Status:   domain.BillClosed,
+		Version:  1,
+		Items:    []domain.Item{item},
+		Total:    domain.Money{Amount: 20, Currency: "USD"},
+	}
+
+	item.Quantity = 2
+	bill, amendment, err := domain.NewAmendment(original, []domain.Item{item}, "Undercounted hours", uuid.NewString(), time.Now())
+	require.NoError(s.T(), err)
+	return bill, amendment
+}
+
+// TestAmendBillWorkflow_Execution tests that the amended bill is stored and the amendment returned.
+func (s *UnitTestSuite) TestAmendBillWorkflow_Execution() {
+	bill, amendment := newAmendment(s)
+	requestID := uuid.NewString()
+
+	s.mockActivities.On("AddAmendedBillToDB", mock.Anything, mock.Anything, mock.Anything, requestID).Return(nil).Once()
+
+	s.env.ExecuteWorkflow(AmendBillWorkflow, bill, amendment, requestID)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result domain.Amendment
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	s.Equal(amendment.AmendedBillID, result.AmendedBillID)
+	s.Equal(domain.AdjustmentDebit, result.Adjustment.Type)
+}
+
+// TestAmendBillWorkflow_AlreadyAmended tests that amending a superseded version keeps the kind of the failure.
+func (s *UnitTestSuite) TestAmendBillWorkflow_AlreadyAmended() {
+	bill, amendment := newAmendment(s)
+
+	s.mockActivities.On("AddAmendedBillToDB", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(billerr.New(billerr.ErrBillAmended, "bill was already amended", nil)).Once()
+
+	s.env.ExecuteWorkflow(AmendBillWorkflow, bill, amendment, uuid.NewString())
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	s.Equal(billerr.ErrBillAmended, billerr.Kind(err))
+}
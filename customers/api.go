@@ -0,0 +1,115 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/billerr"
+)
+
+// CreateCustomer registers a customer account with its billing profile.
+// The customer ID is the user ID that bills are created for. Users may only register their own account,
+// and only admins may give it a credit limit.
+//
+//encore:api auth method=POST path=/customers
+func (s *Service) CreateCustomer(ctx context.Context, req *CreateCustomerRequest) (*Customer, error) {
+	if err := validateCreateCustomerRequest(req); err != nil {
+		return nil, fmt.Errorf("Could not validate request: %v", err)
+	}
+
+	caller, err := authorize(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.CreditLimit > 0 && !caller.IsAdmin() {
+		return nil, &errs.Error{Code: errs.PermissionDenied, Message: "only admins may set credit limits"}
+	}
+
+	customer := &Customer{
+		ID:                req.ID,
+		BillingName:       req.BillingName,
+		Address:           req.Address,
+		TaxID:             req.TaxID,
+		PreferredCurrency: req.PreferredCurrency,
+		Locale:            req.Locale,
+		CreditLimit:       req.CreditLimit,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := s.Repository.CreateCustomer(ctx, customer); err != nil {
+		return nil, fmt.Errorf("Could not create customer: %v", err)
+	}
+
+	return customer, nil
+}
+
+// GetCustomer retrieves a customer account and its billing profile by ID.
+// Users may only retrieve their own account.
+//
+//encore:api auth method=GET path=/customers/:id
+func (s *Service) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	if _, err := authorize(id); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.Repository.GetCustomer(ctx, id)
+	if err != nil {
+		if errors.Is(err, billerr.ErrNotFound) {
+			return nil, &errs.Error{Code: errs.NotFound, Message: fmt.Sprintf("Customer not found: %v", err)}
+		}
+		return nil, fmt.Errorf("Could not look up customer: %v", err)
+	}
+
+	return customer, nil
+}
+
+// UpdateCustomer replaces the billing profile of an existing customer.
+// Bills closed before the update keep the profile they were closed with.
+// Only admins may update customers, as the update sets their credit limit.
+//
+//encore:api auth method=PUT path=/customers/:id
+func (s *Service) UpdateCustomer(ctx context.Context, id string, req *UpdateCustomerRequest) (*Customer, error) {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok || !caller.IsAdmin() {
+		return nil, &errs.Error{Code: errs.PermissionDenied, Message: "only admins may update customers"}
+	}
+
+	if err := validateUpdateCustomerRequest(id, req); err != nil {
+		return nil, fmt.Errorf("Invalid request: %v", err)
+	}
+
+	customer := &Customer{
+		ID:                id,
+		BillingName:       req.BillingName,
+		Address:           req.Address,
+		TaxID:             req.TaxID,
+		PreferredCurrency: req.PreferredCurrency,
+		Locale:            req.Locale,
+		CreditLimit:       req.CreditLimit,
+	}
+
+	if err := s.Repository.UpdateCustomer(ctx, customer); err != nil {
+		return nil, fmt.Errorf("Could not update customer: %v", err)
+	}
+
+	return s.Repository.GetCustomer(ctx, id)
+}
+
+// Check that the authenticated caller may act on the account of the given customer,
+// which admins may for every customer of their tenant.
+func authorize(customerID string) (*authn.Data, error) {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok {
+		return nil, &errs.Error{Code: errs.Unauthenticated, Message: "authentication required"}
+	}
+	if !caller.CanAccess(customerID) {
+		return nil, &errs.Error{Code: errs.PermissionDenied, Message: fmt.Sprintf("user %s is not permitted to access customer %s", caller.UserID, customerID)}
+	}
+	return caller, nil
+}
@@ -0,0 +1,144 @@
+package customers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"encore.dev/storage/sqldb"
+	"github.com/vvvakho/feezy/billing/billerr"
+	"github.com/vvvakho/feezy/billing/tenant"
+)
+
+var CustomersDB = sqldb.NewDatabase("customers", sqldb.DatabaseConfig{
+	Migrations: "./migrations",
+})
+
+type Repo struct {
+	DB *sqldb.Database
+}
+
+func NewRepo() (*Repo, error) {
+	return &Repo{DB: CustomersDB}, nil
+}
+
+func (r *Repo) CreateCustomer(ctx context.Context, c *Customer) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(ctx, `
+		INSERT INTO customers (
+			id, billing_name, address_line1, address_line2, city, postal_code, country,
+			tax_id, preferred_currency, locale, credit_limit, created_at, updated_at, tenant_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+	`,
+		c.ID,
+		c.BillingName,
+		c.Address.Line1,
+		c.Address.Line2,
+		c.Address.City,
+		c.Address.PostalCode,
+		c.Address.Country,
+		c.TaxID,
+		c.PreferredCurrency,
+		c.Locale,
+		c.CreditLimit,
+		c.CreatedAt,
+		c.UpdatedAt,
+		tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting customer: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, billing_name, address_line1, address_line2, city, postal_code, country,
+			tax_id, preferred_currency, locale, credit_limit, created_at, updated_at
+		FROM customers
+		WHERE id = $1 AND tenant_id = $2;
+	`
+
+	var c Customer
+	err = r.DB.QueryRow(ctx, query, id, tenantID).Scan(
+		&c.ID,
+		&c.BillingName,
+		&c.Address.Line1,
+		&c.Address.Line2,
+		&c.Address.City,
+		&c.Address.PostalCode,
+		&c.Address.Country,
+		&c.TaxID,
+		&c.PreferredCurrency,
+		&c.Locale,
+		&c.CreditLimit,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("customer with ID %s not found: %w", id, billerr.ErrNotFound)
+		}
+		return nil, fmt.Errorf("error querying customers: %v", err)
+	}
+
+	return &c, nil
+}
+
+func (r *Repo) UpdateCustomer(ctx context.Context, c *Customer) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.DB.Exec(ctx, `
+		UPDATE customers SET
+			billing_name = $2,
+			address_line1 = $3,
+			address_line2 = $4,
+			city = $5,
+			postal_code = $6,
+			country = $7,
+			tax_id = $8,
+			preferred_currency = $9,
+			locale = $10,
+			credit_limit = $11,
+			updated_at = $12
+		WHERE id = $1 AND tenant_id = $13;
+	`,
+		c.ID,
+		c.BillingName,
+		c.Address.Line1,
+		c.Address.Line2,
+		c.Address.City,
+		c.Address.PostalCode,
+		c.Address.Country,
+		c.TaxID,
+		c.PreferredCurrency,
+		c.Locale,
+		c.CreditLimit,
+		time.Now(),
+		tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating customer: %v", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("customer with ID %s not found", c.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package customers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
+// Customer holds the account and billing profile of a user.
+// Its ID is the user ID referenced by bills.
+type Customer struct {
+	ID                string    `json:"id"`
+	BillingName       string    `json:"billing_name"`
+	Address           Address   `json:"address"`
+	TaxID             string    `json:"tax_id"`
+	PreferredCurrency string    `json:"preferred_currency"`
+	Locale            string    `json:"locale"`
+	CreditLimit       int64     `json:"credit_limit"` // cap on each open bill, in minor units of PreferredCurrency
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+type CreateCustomerRequest struct {
+	ID                string  `json:"id"`
+	BillingName       string  `json:"billing_name"`
+	Address           Address `json:"address"`
+	TaxID             string  `json:"tax_id"`
+	PreferredCurrency string  `json:"preferred_currency"`
+	Locale            string  `json:"locale"`
+	CreditLimit       int64   `json:"credit_limit"`
+}
+
+type UpdateCustomerRequest struct {
+	BillingName       string  `json:"billing_name"`
+	Address           Address `json:"address"`
+	TaxID             string  `json:"tax_id"`
+	PreferredCurrency string  `json:"preferred_currency"`
+	Locale            string  `json:"locale"`
+	CreditLimit       int64   `json:"credit_limit"`
+}
+
+// Locales are expected in the language[-REGION] form, e.g. "en" or "ka-GE"
+var localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+func validateCreateCustomerRequest(req *CreateCustomerRequest) error {
+	if _, err := uuid.Parse(req.ID); err != nil {
+		return fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	if req.CreditLimit < 0 {
+		return fmt.Errorf("Credit limit cannot be negative")
+	}
+
+	return validateProfile(req.BillingName, req.Address, req.PreferredCurrency, req.Locale)
+}
+
+func validateUpdateCustomerRequest(id string, req *UpdateCustomerRequest) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("Invalid ID: %v", err)
+	}
+
+	if req.CreditLimit < 0 {
+		return fmt.Errorf("Credit limit cannot be negative")
+	}
+
+	return validateProfile(req.BillingName, req.Address, req.PreferredCurrency, req.Locale)
+}
+
+func validateProfile(billingName string, address Address, currency string, locale string) error {
+	if strings.TrimSpace(billingName) == "" {
+		return fmt.Errorf("Billing name cannot be empty")
+	}
+
+	if len(address.Country) != 2 || strings.ToUpper(address.Country) != address.Country {
+		return fmt.Errorf("Invalid country code: %v", address.Country)
+	}
+
+	if _, err := domain.IsValidCurrency(currency); err != nil {
+		return fmt.Errorf("Invalid preferred currency: %v", err)
+	}
+
+	if !localePattern.MatchString(locale) {
+		return fmt.Errorf("Invalid locale: %v", locale)
+	}
+
+	return nil
+}
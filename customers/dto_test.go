@@ -0,0 +1,63 @@
+package customers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCreateCustomerRequest(t *testing.T) {
+	valid := func() CreateCustomerRequest {
+		return CreateCustomerRequest{
+			ID:                uuid.NewString(),
+			BillingName:       "Acme LLC",
+			Address:           Address{Line1: "1 Rustaveli Ave", City: "Tbilisi", Country: "GE"},
+			TaxID:             "404000000",
+			PreferredCurrency: "GEL",
+			Locale:            "ka-GE",
+		}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*CreateCustomerRequest)
+		expectErr bool
+	}{
+		{"Valid Request", func(r *CreateCustomerRequest) {}, false},
+		{"Valid Language Only Locale", func(r *CreateCustomerRequest) { r.Locale = "en" }, false},
+		{"Invalid ID", func(r *CreateCustomerRequest) { r.ID = "invalid-uuid" }, true},
+		{"Empty Billing Name", func(r *CreateCustomerRequest) { r.BillingName = " " }, true},
+		{"Invalid Country", func(r *CreateCustomerRequest) { r.Address.Country = "Georgia" }, true},
+		{"Lowercase Country", func(r *CreateCustomerRequest) { r.Address.Country = "ge" }, true},
+		{"Invalid Currency", func(r *CreateCustomerRequest) { r.PreferredCurrency = "EUR" }, true},
+		{"Invalid Locale", func(r *CreateCustomerRequest) { r.Locale = "english" }, true},
+		{"Valid Credit Limit", func(r *CreateCustomerRequest) { r.CreditLimit = 500000 }, false},
+		{"Negative Credit Limit", func(r *CreateCustomerRequest) { r.CreditLimit = -1 }, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid()
+			tc.modify(&req)
+			err := validateCreateCustomerRequest(&req)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateCustomerRequest(t *testing.T) {
+	req := UpdateCustomerRequest{
+		BillingName:       "Acme LLC",
+		Address:           Address{Country: "US"},
+		PreferredCurrency: "USD",
+		Locale:            "en-US",
+	}
+
+	assert.NoError(t, validateUpdateCustomerRequest(uuid.NewString(), &req))
+	assert.Error(t, validateUpdateCustomerRequest("invalid-uuid", &req))
+}
@@ -0,0 +1,17 @@
+CREATE TABLE customers (
+    id                 UUID PRIMARY KEY,
+    billing_name       TEXT NOT NULL,
+    address_line1      TEXT NOT NULL DEFAULT '',
+    address_line2      TEXT NOT NULL DEFAULT '',
+    city               TEXT NOT NULL DEFAULT '',
+    postal_code        TEXT NOT NULL DEFAULT '',
+    country            CHAR(2) NOT NULL,
+    tax_id             TEXT NOT NULL DEFAULT '',
+    preferred_currency CHAR(3) NOT NULL,
+    locale             TEXT NOT NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indices
+CREATE INDEX idx_customers_tax_id ON customers(tax_id);
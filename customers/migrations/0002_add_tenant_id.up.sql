@@ -0,0 +1,5 @@
+-- Existing customers belong to the default tenant
+ALTER TABLE customers ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+-- Indices
+CREATE INDEX idx_customers_tenant_id ON customers(tenant_id);
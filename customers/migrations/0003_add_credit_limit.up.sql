@@ -0,0 +1,2 @@
+-- Cap on the total of each open bill of the customer, in minor units of their preferred currency
+ALTER TABLE customers ADD COLUMN credit_limit BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Customer IDs are user IDs, and a user may be a customer of several tenants
+ALTER TABLE customers DROP CONSTRAINT customers_pkey;
+ALTER TABLE customers ADD PRIMARY KEY (tenant_id, id);
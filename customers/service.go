@@ -0,0 +1,47 @@
+package customers
+
+import (
+	"context"
+	"fmt"
+
+	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"encore.dev/middleware"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/tenant"
+)
+
+//encore:service
+type Service struct {
+	Repository Repository
+}
+
+// Interface for the Repository entity
+type Repository interface {
+	CreateCustomer(context.Context, *Customer) error
+	GetCustomer(context.Context, string) (*Customer, error)
+	UpdateCustomer(context.Context, *Customer) error
+}
+
+// Initialize customers service with a Repository entity
+func initService() (*Service, error) {
+	db, err := NewRepo()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to initialize Repository: %v", err)
+	}
+
+	return &Service{Repository: db}, nil
+}
+
+// TenantMiddleware scopes every request to the tenant of the authenticated caller, as propagated by the
+// service calling in, which the Repository reads back from the request context.
+//
+//encore:middleware target=all
+func (s *Service) TenantMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok {
+		return middleware.Response{Err: &errs.Error{Code: errs.Unauthenticated, Message: "authentication required"}}
+	}
+
+	return next(req.WithContext(tenant.NewContext(req.Context(), caller.TenantID)))
+}
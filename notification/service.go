@@ -1,5 +1,16 @@
 package notification
 
+import (
+	"context"
+	"time"
+
+	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"github.com/vvvakho/feezy/authn"
+	"github.com/vvvakho/feezy/billing/service/domain"
+)
+
 //encore:service
 type Service struct {
 }
@@ -7,3 +18,38 @@ type Service struct {
 func initService() (*Service, error) {
 	return &Service{}, nil
 }
+
+// PaymentReminderRequest asks for a customer to be reminded of an unpaid bill.
+type PaymentReminderRequest struct {
+	BillID    string       `json:"bill_id"`
+	UserID    string       `json:"user_id"`
+	AmountDue domain.Money `json:"amount_due"`
+	DueAt     time.Time    `json:"due_at"`
+}
+
+type PaymentReminderResponse struct {
+	Message string `json:"message"`
+}
+
+// SendPaymentReminder reminds a customer that a bill is due or overdue.
+// Sent by the dunning workflows of billing workers, authenticated as an admin of the bill's tenant.
+//
+//encore:api auth method=POST path=/notifications/payment-reminders
+func (s *Service) SendPaymentReminder(ctx context.Context, req *PaymentReminderRequest) (*PaymentReminderResponse, error) {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok || !caller.IsAdmin() {
+		return nil, &errs.Error{Code: errs.PermissionDenied, Message: "only admins may send payment reminders"}
+	}
+
+	// Simulate delivery (replace with a real email or SMS provider)
+	rlog.Info("Sending payment reminder",
+		"TenantID", caller.TenantID,
+		"BillID", req.BillID,
+		"UserID", req.UserID,
+		"Amount", req.AmountDue.Amount,
+		"Currency", req.AmountDue.Currency,
+		"DueAt", req.DueAt,
+	)
+
+	return &PaymentReminderResponse{Message: "Reminder has been sent"}, nil
+}
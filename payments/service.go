@@ -2,11 +2,12 @@ package payments
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"encore.dev/beta/auth"
 	"encore.dev/beta/errs"
 	"encore.dev/rlog"
+	"github.com/vvvakho/feezy/authn"
 	billing "github.com/vvvakho/feezy/billing/service"
 	"github.com/vvvakho/feezy/billing/service/domain"
 	bDomain "github.com/vvvakho/feezy/billing/service/domain"
@@ -18,6 +19,10 @@ import (
 type Service struct {
 }
 
+// Whether the simulated gateway approves charges. Charges are declined unless enabled,
+// so dunning goes on to its later steps instead of settling every overdue bill as paid.
+var GATEWAY_APPROVES_CHARGES = false
+
 // Initialize the payment service
 func initService() (*Service, error) {
 	return &Service{}, nil
@@ -39,13 +44,21 @@ type PayBillResponse struct {
 
 // PayBill processes a payment for a given bill.
 //
-//encore:api private method=POST path=/payments/pay
+//encore:api auth method=POST path=/payments/pay
 func (s *Service) PayBill(ctx context.Context, req *PayBillRequest) (*PayBillResponse, error) {
 
 	// Fetch the bill details from the Billing Service
 	bill, err := billing.GetBill(ctx, req.BillID)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving bill: %v", err)
+		return nil, errs.Wrap(err, "error retrieving bill")
+	}
+
+	// Cancelled bills are kept for the record, but are never payable
+	if bill.Status == bDomain.BillCancelled {
+		return nil, &errs.Error{
+			Code:    errs.FailedPrecondition,
+			Message: "cannot pay a cancelled bill",
+		}
 	}
 
 	// Check if the bill is open
@@ -60,13 +73,48 @@ func (s *Service) PayBill(ctx context.Context, req *PayBillRequest) (*PayBillRes
 	rlog.Info("Processing payment for bill", "BillID", req.BillID, "Amount", req.Amount, "Currency", req.Currency)
 	time.Sleep(2 * time.Second) // Simulating external API call
 
-	// Call the Billing Service to close the bill after payment
-	resp, err := billing.CloseBill(ctx, req.BillID, &billing.CloseBillRequest{
+	// Call the Billing Service to close the bill as paid, so it is journaled as collected and never dunned
+	resp, err := billing.ClosePaidBill(ctx, req.BillID, &billing.CloseBillRequest{
 		RequestID: req.BillID, // Use bill ID as request ID for idempotency
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error closing bill after payment: %v", err)
+		return nil, errs.Wrap(err, "error closing bill after payment")
 	}
 
 	return &PayBillResponse{Bill: resp.Bill, Message: resp.Status}, nil
 }
+
+// ChargeBillRequest represents a request to charge a customer for a closed bill.
+type ChargeBillRequest struct {
+	BillID    string        `json:"bill_id"`
+	UserID    string        `json:"user_id"`
+	RequestID string        `json:"request_id"`
+	Amount    bDomain.Money `json:"amount"`
+}
+
+// ChargeBillResponse reports whether the charge "succeeded" or was "declined".
+type ChargeBillResponse struct {
+	Status string `json:"status"`
+}
+
+// ChargeBill charges the customer of an unpaid closed bill through the payment gateway.
+// Used by the dunning workflows of billing workers to retry payments, authenticated as an admin of the bill's tenant.
+//
+//encore:api auth method=POST path=/payments/charge
+func (s *Service) ChargeBill(ctx context.Context, req *ChargeBillRequest) (*ChargeBillResponse, error) {
+	caller, ok := auth.Data().(*authn.Data)
+	if !ok || !caller.IsAdmin() {
+		return nil, &errs.Error{Code: errs.PermissionDenied, Message: "only admins may charge bills"}
+	}
+
+	// Simulate the gateway charge (replace with real payment gateway integration)
+	// The request ID is passed to the gateway as an idempotency key
+	rlog.Info("Charging bill", "TenantID", caller.TenantID, "BillID", req.BillID, "RequestID", req.RequestID,
+		"Amount", req.Amount.Amount, "Currency", req.Amount.Currency)
+	time.Sleep(2 * time.Second) // Simulating external API call
+
+	if !GATEWAY_APPROVES_CHARGES {
+		return &ChargeBillResponse{Status: "declined"}, nil
+	}
+	return &ChargeBillResponse{Status: "succeeded"}, nil
+}